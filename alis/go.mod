@@ -9,6 +9,7 @@ go 1.17
 //replace go.protobuf.alis.alis.exchange => ../../alis.exchange/alis/protobuf/go
 
 require (
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/pterm/pterm v0.12.40
 	github.com/spf13/cobra v1.4.0
@@ -17,9 +18,11 @@ require (
 	google.golang.org/genproto v0.0.0-20220407144326-9054f6ed7bac
 	google.golang.org/grpc v1.45.0
 	google.golang.org/protobuf v1.28.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
+	github.com/spf13/pflag v1.0.5
 	go.protobuf.alis.alis.exchange v0.0.0-20220426142100-dcad6e3fa486
 	google.golang.org/api v0.63.0
 )
@@ -27,7 +30,6 @@ require (
 require (
 	cloud.google.com/go v0.99.0 // indirect
 	github.com/atomicgo/cursor v0.0.1 // indirect
-	github.com/fsnotify/fsnotify v1.5.1 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/gookit/color v1.5.0 // indirect
@@ -42,7 +44,6 @@ require (
 	github.com/spf13/afero v1.6.0 // indirect
 	github.com/spf13/cast v1.4.1 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/subosito/gotenv v1.2.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
 	go.opencensus.io v0.23.0 // indirect
@@ -53,5 +54,4 @@ require (
 	google.golang.org/appengine v1.6.7 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/ini.v1 v1.66.2 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// allowBreakingFlag lets `alis neuron build` build protos which break the clients of the latest version.
+var allowBreakingFlag bool
+
+// The kinds of breaking changes.
+const (
+	// breakingWire changes break the clients of the neuron at runtime, for example a renumbered field.
+	breakingWire = "wire"
+	// breakingSource changes break the code generated from the protos, for example a renamed field.
+	breakingSource = "source"
+)
+
+// breakingChange is a change of the protos of a neuron which breaks the clients of a previous version.
+type breakingChange struct {
+	Kind string
+	// Element is the full name of the message, field, service or method which changed.
+	Element string
+	Message string
+}
+
+// breakingProtoCmd represents the proto breaking command
+var breakingProtoCmd = &cobra.Command{
+	Use:   "breaking",
+	Short: pterm.Blue("Checks the protos of a neuron for changes which break its latest built version"),
+	Long: pterm.Green(
+		`This method compiles the protos of the neuron in the proto repository of the workspace, as
+'alis neuron build' does, and compares them with the descriptor set stored with the latest
+built version of the neuron.  It reports the changes which break the existing clients:
+
+ - on the wire: removed or renumbered fields, fields of another type, and removed or changed
+   services and methods
+ - in the generated code: removed messages, renamed fields and removed fields whose number
+   is reserved
+
+The major version of a neuron is part of its ID, so breaking changes belong in a new neuron,
+for example resources-events-v2.  'alis neuron build' runs the same checks and refuses to
+build a version with breaking changes, unless run with --allow-breaking.`),
+	Example:           pterm.LightYellow("alis proto breaking {orgID}.{productID}.{neuronID}\nalis proto breaking alis.in.resources-events-v1 -o json"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		organisationID = strings.Split(args[0], ".")[0]
+		productID = strings.Split(args[0], ".")[1]
+		neuronID = strings.Split(args[0], ".")[2]
+		name := "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID
+
+		fds, err := getNeuronDescriptor(cmd.Context(), name)
+		if err != nil {
+			return err
+		}
+		if fds == nil {
+			return status.Errorf(codes.NotFound, "no protos found for %s in %s", args[0],
+				currentWorkspace().NeuronProtos(organisationID, productID, neuronID))
+		}
+
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		latest, err := latestBuiltVersion(cmd.Context(), clients, name)
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			if machineOutput() {
+				return printResources(cmd, []interface{}{})
+			}
+			pterm.Info.Printf("%s has no built versions yet, there is nothing to compare with\n", args[0])
+			return nil
+		}
+
+		changes := breakingChanges(latest.GetFileDescriptorSet(), fds)
+		err = breakingError(changes, args[0], latest.GetVersion())
+
+		if machineOutput() {
+			out := []map[string]interface{}{}
+			for _, c := range changes {
+				out = append(out, map[string]interface{}{"kind": c.Kind, "element": c.Element, "message": c.Message})
+			}
+			if err := printResources(cmd, out); err != nil {
+				return err
+			}
+			return err
+		}
+
+		if len(changes) == 0 {
+			pterm.Success.Printf("The protos of %s do not break version %s\n", args[0], latest.GetVersion())
+			return nil
+		}
+		if err := renderBreakingChanges(cmd, changes); err != nil {
+			return err
+		}
+		return err
+	},
+}
+
+func init() {
+	protoCmd.AddCommand(breakingProtoCmd)
+	argFromWorkingDir(breakingProtoCmd, neuronFromDir)
+}
+
+// latestBuiltVersion returns the latest version of neuron in state BUILT along with its file descriptor set, nil if
+// there is none.
+func latestBuiltVersion(ctx context.Context, clients *clientSet, neuron string) (*pbProducts.NeuronVersion, error) {
+	return searchNeuronVersions(ctx, clients, neuron, func(version *pbProducts.NeuronVersion) bool {
+		return version.GetState() == pbProducts.NeuronVersion_BUILT
+	})
+}
+
+// searchNeuronVersions returns the latest version of neuron which match accepts, along with its file descriptor set,
+// nil if there is none.  The versions are listed a page at a time with only their version and state, such that only
+// the descriptor of the returned version is retrieved.
+func searchNeuronVersions(ctx context.Context, clients *clientSet, neuron string, match func(version *pbProducts.NeuronVersion) bool) (*pbProducts.NeuronVersion, error) {
+	req := &pbProducts.ListNeuronVersionsRequest{
+		Parent:   neuron,
+		PageSize: 1,
+		ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version", "state"}},
+	}
+	for {
+		res, err := clients.Products.ListNeuronVersions(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range res.GetNeuronVersions() {
+			if !match(version) {
+				continue
+			}
+			// the same page again, along with the descriptor.
+			req.ReadMask = &fieldmaskpb.FieldMask{Paths: []string{"version", "file_descriptor_set"}}
+			res, err := clients.Products.ListNeuronVersions(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range res.GetNeuronVersions() {
+				if v.GetVersion() == version.GetVersion() {
+					return v, nil
+				}
+			}
+			return nil, status.Errorf(codes.Internal, "version %s of %s is missing from its page of versions", version.GetVersion(), neuron)
+		}
+		if res.GetNextPageToken() == "" {
+			return nil, nil
+		}
+		req.PageToken = res.GetNextPageToken()
+	}
+}
+
+// breakingChanges returns the changes from previous to current, the descriptor sets of two versions of a neuron,
+// which break the clients of previous.  Elements are matched by their full names, such that moving them between
+// files is not a change.
+func breakingChanges(previous, current *descriptorpb.FileDescriptorSet) []breakingChange {
+	var changes []breakingChange
+	report := func(kind string, element string, format string, a ...interface{}) {
+		changes = append(changes, breakingChange{Kind: kind, Element: element, Message: fmt.Sprintf(format, a...)})
+	}
+
+	oldMessages, oldServices := protoElements(previous)
+	newMessages, newServices := protoElements(current)
+
+	var messageNames []string
+	for name := range oldMessages {
+		messageNames = append(messageNames, name)
+	}
+	sort.Strings(messageNames)
+	var removed []string
+	for _, name := range messageNames {
+		oldMessage := oldMessages[name]
+		newMessage, ok := newMessages[name]
+		if !ok {
+			// the messages nested in a removed message, which sort after it, are not reported again.
+			if len(removed) == 0 || !strings.HasPrefix(name, removed[len(removed)-1]+".") {
+				report(breakingSource, name, "message %s was removed", name)
+				removed = append(removed, name)
+			}
+			continue
+		}
+
+		byNumber := map[int32]*descriptorpb.FieldDescriptorProto{}
+		byName := map[string]*descriptorpb.FieldDescriptorProto{}
+		for _, f := range newMessage.GetField() {
+			byNumber[f.GetNumber()] = f
+			byName[f.GetName()] = f
+		}
+		for _, oldField := range oldMessage.GetField() {
+			element := name + "." + oldField.GetName()
+			newField, ok := byNumber[oldField.GetNumber()]
+			switch {
+			case !ok && byName[oldField.GetName()] != nil:
+				report(breakingWire, element, "field %s was renumbered from %d to %d", element,
+					oldField.GetNumber(), byName[oldField.GetName()].GetNumber())
+			case !ok && reservedNumber(newMessage, oldField.GetNumber()):
+				report(breakingSource, element, "field %s (%d) was removed", element, oldField.GetNumber())
+			case !ok:
+				report(breakingWire, element, "field %s (%d) was removed without reserving its number", element,
+					oldField.GetNumber())
+			case fieldType(newField) != fieldType(oldField):
+				report(breakingWire, element, "field %s changed type from %s to %s", element,
+					fieldType(oldField), fieldType(newField))
+			case newField.GetName() != oldField.GetName():
+				report(breakingSource, element, "field %s (%d) was renamed to %s", element, oldField.GetNumber(),
+					newField.GetName())
+			}
+		}
+	}
+
+	var serviceNames []string
+	for name := range oldServices {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		newService, ok := newServices[name]
+		if !ok {
+			report(breakingWire, name, "service %s was removed", name)
+			continue
+		}
+
+		methods := map[string]*descriptorpb.MethodDescriptorProto{}
+		for _, m := range newService.GetMethod() {
+			methods[m.GetName()] = m
+		}
+		for _, oldMethod := range oldServices[name].GetMethod() {
+			element := name + "." + oldMethod.GetName()
+			newMethod, ok := methods[oldMethod.GetName()]
+			switch {
+			case !ok:
+				report(breakingWire, element, "rpc %s was removed", element)
+			case newMethod.GetInputType() != oldMethod.GetInputType():
+				report(breakingWire, element, "rpc %s changed its request from %s to %s", element,
+					shortTypeName(oldMethod.GetInputType()), shortTypeName(newMethod.GetInputType()))
+			case newMethod.GetOutputType() != oldMethod.GetOutputType():
+				report(breakingWire, element, "rpc %s changed its response from %s to %s", element,
+					shortTypeName(oldMethod.GetOutputType()), shortTypeName(newMethod.GetOutputType()))
+			case newMethod.GetClientStreaming() != oldMethod.GetClientStreaming(),
+				newMethod.GetServerStreaming() != oldMethod.GetServerStreaming():
+				report(breakingWire, element, "rpc %s changed its streaming", element)
+			}
+		}
+	}
+	return changes
+}
+
+// protoElements returns the messages, including nested ones, and the services of fds by their full names.
+func protoElements(fds *descriptorpb.FileDescriptorSet) (map[string]*descriptorpb.DescriptorProto, map[string]*descriptorpb.ServiceDescriptorProto) {
+	messages := map[string]*descriptorpb.DescriptorProto{}
+	services := map[string]*descriptorpb.ServiceDescriptorProto{}
+
+	var addMessages func(prefix string, list []*descriptorpb.DescriptorProto)
+	addMessages = func(prefix string, list []*descriptorpb.DescriptorProto) {
+		for _, m := range list {
+			messages[prefix+m.GetName()] = m
+			addMessages(prefix+m.GetName()+".", m.GetNestedType())
+		}
+	}
+	for _, file := range fds.GetFile() {
+		prefix := ""
+		if file.GetPackage() != "" {
+			prefix = file.GetPackage() + "."
+		}
+		addMessages(prefix, file.GetMessageType())
+		for _, s := range file.GetService() {
+			services[prefix+s.GetName()] = s
+		}
+	}
+	return messages, services
+}
+
+// fieldType returns the type of field as it would be declared, for example "repeated string" or
+// "alis.in.resources.events.v1.Event".
+func fieldType(field *descriptorpb.FieldDescriptorProto) string {
+	t := strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+	if field.GetTypeName() != "" {
+		t = strings.TrimPrefix(field.GetTypeName(), ".")
+	}
+	if field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		t = "repeated " + t
+	}
+	return t
+}
+
+// reservedNumber tells whether number is a reserved field number of message.
+func reservedNumber(message *descriptorpb.DescriptorProto, number int32) bool {
+	for _, r := range message.GetReservedRange() {
+		// the end of the range is exclusive.
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+// breakingError returns the error of the changes which break version of neuron, nil if there are none.
+func breakingError(changes []breakingChange, neuron string, version string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition, "found %d breaking change(s) against version %s of %s",
+		len(changes), version, neuron)
+}
+
+// nextMajorNeuronID returns the ID of the neuron for the next major version of neuronID, for example
+// resources-events-v2 for resources-events-v1.
+func nextMajorNeuronID(neuronID string) string {
+	i := strings.LastIndex(neuronID, "-v")
+	if i < 0 {
+		return neuronID
+	}
+	major, err := strconv.Atoi(neuronID[i+2:])
+	if err != nil {
+		return neuronID
+	}
+	return neuronID[:i] + "-v" + strconv.Itoa(major+1)
+}
+
+// renderBreakingChanges prints changes as a table.
+func renderBreakingChanges(cmd *cobra.Command, changes []breakingChange) error {
+	table := pterm.TableData{{"Kind", "Element", "Change"}}
+	for _, c := range changes {
+		kind := pterm.Red(c.Kind)
+		if c.Kind == breakingSource {
+			kind = pterm.Yellow(c.Kind)
+		}
+		table = append(table, []string{kind, c.Element, c.Message})
+	}
+	return renderTable(cmd, table)
+}
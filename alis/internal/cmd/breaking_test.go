@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestBreakingChanges(t *testing.T) {
+	previous := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{eventsProto()}}
+
+	// additions and moves between files do not break anything.
+	current := proto.Clone(previous).(*descriptorpb.FileDescriptorSet)
+	file := current.GetFile()[0]
+	file.Name = proto.String("alis/in/resources/events/v1/resources.proto")
+	file.MessageType[0].Field = append(file.MessageType[0].Field, &descriptorpb.FieldDescriptorProto{
+		Name: proto.String("create_time"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()})
+	if changes := breakingChanges(previous, current); len(changes) != 0 {
+		t.Errorf("got breaking changes for additions: %v", changes)
+	}
+
+	event, getRequest, listResponse := file.MessageType[0], file.MessageType[1], file.MessageType[3]
+	event.Field[0].Name = proto.String("id")
+	event.Field[1].Number = proto.Int32(4)
+	getRequest.Field = nil
+	getRequest.ReservedRange = []*descriptorpb.DescriptorProto_ReservedRange{{Start: proto.Int32(1), End: proto.Int32(2)}}
+	listResponse.Field[0].Label = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file.MessageType = append(file.MessageType[:2], file.MessageType[3])
+	methods := file.Service[0].Method
+	methods[0].OutputType = proto.String(".alis.in.resources.events.v1.GetEventResponse")
+	methods[1].ServerStreaming = proto.Bool(true)
+	file.Service[0].Method = methods[:2]
+
+	var got []string
+	for _, c := range breakingChanges(previous, current) {
+		got = append(got, fmt.Sprintf("%s %s: %s", c.Kind, c.Element, c.Message))
+	}
+	pkg := "alis.in.resources.events.v1."
+	want := []string{
+		"source " + pkg + "Event.name: field " + pkg + "Event.name (1) was renamed to id",
+		"wire " + pkg + "Event.labels: field " + pkg + "Event.labels was renumbered from 2 to 4",
+		"source " + pkg + "GetEventRequest.name: field " + pkg + "GetEventRequest.name (1) was removed",
+		"source " + pkg + "ListEventsRequest: message " + pkg + "ListEventsRequest was removed",
+		"wire " + pkg + "ListEventsResponse.events: field " + pkg + "ListEventsResponse.events changed type from repeated " +
+			pkg + "Event to " + pkg + "Event",
+		"wire " + pkg + "EventsService.GetEvent: rpc " + pkg + "EventsService.GetEvent changed its response from Event to GetEventResponse",
+		"wire " + pkg + "EventsService.ListEvents: rpc " + pkg + "EventsService.ListEvents changed its streaming",
+		"wire " + pkg + "EventsService.DeleteEvent: rpc " + pkg + "EventsService.DeleteEvent was removed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// a field removed without reserving its number breaks the wire format as well.
+	getRequest.ReservedRange = nil
+	for _, c := range breakingChanges(previous, current) {
+		if c.Element == pkg+"GetEventRequest.name" && c.Kind != breakingWire {
+			t.Errorf("%s: got a %s change, want a wire change", c.Message, c.Kind)
+		}
+	}
+}
+
+func TestProtoBreaking(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.repo("alis/products/in", map[string]string{"resources/events/v1/Dockerfile": "FROM scratch\n"})
+	e.repo("alis/proto", map[string]string{"alis/in/resources/events/v1/events.proto": "syntax = \"proto3\";\n"})
+
+	_, console := e.run("", "proto", "breaking", "alis.in.resources-events-v1")
+	if !strings.Contains(console, "nothing to compare with") {
+		t.Errorf("expected no versions to compare with, got:\n%s", console)
+	}
+
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/1", Version: "1.0.0",
+		State:             pbProducts.NeuronVersion_BUILT,
+		FileDescriptorSet: &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{eventsProto()}}})
+	file := eventsProto()
+	file.Service[0].Method = file.Service[0].Method[:2]
+	e.protocWrites(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+
+	// versions which failed to build are not compared with.
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/2", Version: "1.1.0",
+		State:             pbProducts.NeuronVersion_FAILED,
+		FileDescriptorSet: &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}})
+
+	_, console, err := e.runErr("", "proto", "breaking", "alis.in.resources-events-v1")
+	if errorCode(err) != codes.FailedPrecondition || !strings.Contains(console, "rpc alis.in.resources.events.v1.EventsService.DeleteEvent was removed") ||
+		!strings.Contains(console, "1.0.0") {
+		t.Errorf("got %v, want the removed rpc since version 1.0.0\n%s", err, console)
+	}
+
+	// the build fails unless breaking changes are allowed.
+	_, console, err = e.runErr("", "neuron", "build", "alis.in.resources-events-v1")
+	if err == nil || !strings.Contains(console, "alis neuron create alis.in.resources-events-v2") {
+		t.Errorf("build did not fail on the breaking changes:\n%s", console)
+	}
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 2 {
+		t.Fatalf("got %d neuron versions, want 2", got)
+	}
+	e.run("", "neuron", "build", "alis.in.resources-events-v1", "--allow-breaking")
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 3 {
+		t.Fatalf("got %d neuron versions, want 3", got)
+	}
+
+	// the version built with the breaking changes is compared with from then on.
+	_, console = e.run("", "proto", "breaking", "alis.in.resources-events-v1")
+	if !strings.Contains(console, "do not break version 1.1.1") {
+		t.Errorf("expected no breaking changes since version 1.1.1, got:\n%s", console)
+	}
+}
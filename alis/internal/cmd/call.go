@@ -0,0 +1,456 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	callDataFlag      string
+	callHostFlag      string
+	callPlaintextFlag bool
+)
+
+// dialNeuron connects to the neuron at host, in plaintext or with TLS and ID token credentials.  Tests may replace it
+// to serve the neuron in process.
+var dialNeuron = func(ctx context.Context, host string, plaintext bool) (*grpc.ClientConn, error) {
+	if plaintext {
+		return NewInsecureServerConnection(ctx, host)
+	}
+	return NewServerConnection(ctx, host)
+}
+
+// callCmd represents the call command
+var callCmd = &cobra.Command{
+	Use:   "call",
+	Short: pterm.Blue("Calls a method of a deployed neuron"),
+	Long: pterm.Green(
+		`This method calls a method of a neuron in one of the product deployments, using the descriptor
+set stored with the version deployed there, along with the protos it imports from the workspace.
+The request is read from JSON, and the response is printed as JSON, one message after the other
+for server streaming methods.
+
+The method is given as {Service}/{Method}, where the service may include its package.  The
+request is given with --data, as JSON, @{file} to read it from a file or - to read it from
+the standard input.
+
+The neuron is reached at v1.{neuron}.{resources|services}.{deployment-project}.{orgID}.alis.dev
+with your Google ID token, use --host to call it elsewhere.  With --host and no --deployment,
+the descriptor set is compiled from the protos in the workspace instead, which allows to call
+a neuron running locally, for example with --host localhost:8080 --plaintext.`),
+	Example: pterm.LightYellow("alis call {orgID}.{productID}.{neuronID} {Service}/{Method} -d '{json}' --deployment dev\n" +
+		"alis call alis.in.resources-events-v1 EventsService/GetEvent -d '{\"name\": \"events/123\"}' --deployment dev\n" +
+		"alis call alis.in.resources-events-v1 EventsService/ListEvents -d @request.json --host localhost:8080 --plaintext"),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), validateNeuronArg),
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		organisationID = strings.Split(args[0], ".")[0]
+		productID = strings.Split(args[0], ".")[1]
+		neuronID = strings.Split(args[0], ".")[2]
+		neuronName := "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID
+
+		var fds *descriptorpb.FileDescriptorSet
+		host := callHostFlag
+		if callHostFlag == "" || answered("deployment") {
+			deployment, neuronDeployment, err := selectNeuronDeployment(cmd, neuronName, "to call")
+			if err != nil {
+				return err
+			}
+			if host == "" {
+				host = neuronHost(neuronID, deployment.GetGoogleProjectId(), organisationID)
+			}
+			fds, err = neuronVersionDescriptor(cmd.Context(), neuronName, neuronDeployment.GetVersion())
+			if err != nil {
+				return err
+			}
+			if fds == nil {
+				pterm.Warning.Printf("Version %s of %s holds no descriptor set, using the protos in the workspace instead\n",
+					neuronDeployment.GetVersion(), args[0])
+			}
+		}
+		if fds == nil {
+			var err error
+			fds, err = getNeuronDescriptor(cmd.Context(), neuronName)
+			if err != nil {
+				return err
+			}
+			if fds == nil {
+				return status.Errorf(codes.NotFound, "no protos found for %s in %s", args[0],
+					currentWorkspace().NeuronProtos(organisationID, productID, neuronID))
+			}
+		}
+
+		fds, err := withImports(cmd.Context(), organisationID, fds)
+		if err != nil {
+			return err
+		}
+		files, err := descriptorFiles(fds)
+		if err != nil {
+			return err
+		}
+		method, err := findMethod(files, args[1])
+		if err != nil {
+			return err
+		}
+		// the fields of types which could not be resolved can neither be set nor printed.
+		for _, message := range []protoreflect.MessageDescriptor{method.Input(), method.Output()} {
+			if name := unresolvedType(message, map[protoreflect.FullName]bool{}); name != "" {
+				return status.Errorf(codes.FailedPrecondition, "%s refers to %s, which is neither in the descriptor set of %s "+
+					"nor in the protos of the workspace", message.FullName(), name, args[0])
+			}
+		}
+
+		req := dynamicpb.NewMessage(method.Input())
+		data, err := callData()
+		if err != nil {
+			return err
+		}
+		if err := protojson.Unmarshal(data, req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: %s", method.Input().FullName(), err)
+		}
+		pterm.Debug.Printf("Calling %s at %s with:\n%s\n", method.FullName(), host, req)
+
+		conn, err := dialNeuron(cmd.Context(), host, callPlaintextFlag)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return invokeMethod(cmd.Context(), conn, method, req, func(res *dynamicpb.Message) error {
+			return printResources(cmd, res)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(callCmd)
+	callCmd.Flags().StringVarP(&callDataFlag, "data", "d", "{}", pterm.Green("The request as JSON, @{file} to read it from a file, or - to read it from the standard input"))
+	callCmd.Flags().StringVar(&callHostFlag, "host", "", pterm.Green("The host, and optionally the port, to call the neuron at instead of its deployment"))
+	callCmd.Flags().BoolVar(&callPlaintextFlag, "plaintext", false, pterm.Green("Call the --host without TLS and credentials, for example a neuron running locally"))
+	addAnswerFlag(callCmd, "deployment", "The product deployment to call, as an index, deployment ID, Google project,\n"+
+		"display name or environment (for example: dev)")
+	cobra.CheckErr(callCmd.RegisterFlagCompletionFunc("deployment", completeDeployments))
+}
+
+// selectNeuronDeployment asks the user to select a product deployment of the neuron, for the use described in the
+// question, and returns it along with the neuron deployment.
+func selectNeuronDeployment(cmd *cobra.Command, neuron string, use string) (*pbProducts.ProductDeployment, *pbProducts.NeuronDeployment, error) {
+	clients, err := clientsFromContext(cmd.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	parent := strings.Join(strings.Split(neuron, "/")[:4], "/")
+	res, err := clients.Products.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{Parent: parent})
+	if err != nil {
+		return nil, nil, err
+	}
+	productDeployments := res.GetProductDeployments()
+	if len(productDeployments) == 0 {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "product %s has no deployments", parent)
+	}
+
+	// the list is skipped when the deployment is given with a flag or the answers file.
+	if !answered("deployment") {
+		table := pterm.TableData{{"Index", "Display Name", "Environment", "Deployment Project", "Version", "State"}}
+		for i, depl := range productDeployments {
+			table = append(table, []string{strconv.Itoa(i), depl.GetDisplayName(), depl.GetEnvironment().String(),
+				depl.GetGoogleProjectId(), depl.GetVersion(), depl.GetState().String()})
+		}
+		if err := renderTable(cmd, table); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	input, err := ask("deployment", "Please select the deployment "+use+" (index, ID or environment): ", `^[^,]+$`)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected, err := resolveProductDeployments(productDeployments, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(selected) != 1 {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "%s matches %d deployments, select a single one %s", input, len(selected), use)
+	}
+	deployment := selected[0]
+
+	neuronDeployment, err := clients.Products.GetNeuronDeployment(cmd.Context(), &pbProducts.GetNeuronDeploymentRequest{
+		Name: deployment.GetName() + "/neurons/" + strings.Split(neuron, "/")[5]})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "%s is not deployed to %s (%s)", neuron,
+			deployment.GetDisplayName(), deployment.GetGoogleProjectId())
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	pterm.Debug.Printf("GetNeuronDeployment:\n%s\n", neuronDeployment)
+	return deployment, neuronDeployment, nil
+}
+
+// neuronHost returns the host a neuron is served at in a product deployment, for example
+// v1.events.resources.alis-in-dev-abc.alis.alis.dev for resources-events-v1.
+func neuronHost(neuronID string, googleProjectID string, organisationID string) string {
+	parts := strings.Split(neuronID, "-")
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, ".") + "." + googleProjectID + "." + organisationID + ".alis.dev"
+}
+
+// neuronVersionDescriptor returns the descriptor set stored with the given version of neuron, nil if it has none.
+func neuronVersionDescriptor(ctx context.Context, neuron string, version string) (*descriptorpb.FileDescriptorSet, error) {
+	clients, err := clientsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v, err := searchNeuronVersions(ctx, clients, neuron, func(v *pbProducts.NeuronVersion) bool {
+		return v.GetVersion() == version
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, status.Errorf(codes.NotFound, "version %s of %s not found", version, neuron)
+	}
+	if len(v.GetFileDescriptorSet().GetFile()) == 0 {
+		return nil, nil
+	}
+	return v.GetFileDescriptorSet(), nil
+}
+
+// descriptorResolver resolves the imports of the files of a neuron among those files, and then among the well-known
+// types and the other protos linked into the CLI.
+type descriptorResolver struct {
+	files *protoregistry.Files
+}
+
+func (r descriptorResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r descriptorResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// withImports returns fds along with the files it imports which it does not hold and which are not linked into the
+// CLI, such as google/type/date.proto or the protos shared by the neurons of the organisation.  Those are compiled from
+// the protos of the workspace, when they are missing from there as well a warning is printed and fds is returned as is.
+func withImports(ctx context.Context, organisationID string, fds *descriptorpb.FileDescriptorSet) (*descriptorpb.FileDescriptorSet, error) {
+	held := map[string]bool{}
+	for _, fd := range fds.GetFile() {
+		held[fd.GetName()] = true
+	}
+	var missing []string
+	seen := map[string]bool{}
+	for _, fd := range fds.GetFile() {
+		for _, dep := range fd.GetDependency() {
+			if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); held[dep] || seen[dep] || err == nil {
+				continue
+			}
+			seen[dep] = true
+			missing = append(missing, dep)
+		}
+	}
+	if len(missing) == 0 {
+		return fds, nil
+	}
+
+	dir, err := ioutil.TempDir("", "alis-call")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "descriptor.pb")
+	args := append([]string{"--descriptor_set_out=" + out, "--include_imports"}, protoIncludes(organisationID)...)
+	if _, _, err := run(ctx, newCommand("protoc", append(args, missing...)...)); err != nil {
+		pterm.Warning.Printf("Unable to compile the imports %s from the workspace: %s\n", strings.Join(missing, ", "), err)
+		return fds, nil
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	imports := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, imports); err != nil {
+		return nil, err
+	}
+
+	res := &descriptorpb.FileDescriptorSet{File: append([]*descriptorpb.FileDescriptorProto{}, fds.GetFile()...)}
+	for _, fd := range imports.GetFile() {
+		if !held[fd.GetName()] {
+			res.File = append(res.File, fd)
+		}
+	}
+	return res, nil
+}
+
+// unresolvedType returns the full name of a type which message refers to, itself or through its fields, but which is
+// a placeholder since it could not be resolved, "" if there is none.
+func unresolvedType(message protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) protoreflect.FullName {
+	if message.IsPlaceholder() {
+		return message.FullName()
+	}
+	if seen[message.FullName()] {
+		return ""
+	}
+	seen[message.FullName()] = true
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Enum() != nil && field.Enum().IsPlaceholder() {
+			return field.Enum().FullName()
+		}
+		if field.Message() != nil {
+			if name := unresolvedType(field.Message(), seen); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// descriptorFiles builds the files of fds.  The imports which cannot be resolved, such as google/api/annotations.proto
+// when the CLI does not link it, are left as placeholders.
+func descriptorFiles(fds *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+	resolver := descriptorResolver{files: files}
+	pending := map[string]*descriptorpb.FileDescriptorProto{}
+	for _, fd := range fds.GetFile() {
+		pending[fd.GetName()] = fd
+	}
+
+	// files are built after the files they import.
+	var build func(name string) error
+	build = func(name string) error {
+		fd, ok := pending[name]
+		if !ok {
+			return nil
+		}
+		delete(pending, name)
+		for _, dep := range fd.GetDependency() {
+			if err := build(dep); err != nil {
+				return err
+			}
+		}
+		f, err := protodesc.FileOptions{AllowUnresolvable: true}.New(fd, resolver)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid descriptor of %s: %s", name, err)
+		}
+		return files.RegisterFile(f)
+	}
+	for _, fd := range fds.GetFile() {
+		if err := build(fd.GetName()); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// findMethod returns the method named {Service}/{Method} in files, where the service may include its package.
+func findMethod(files *protoregistry.Files, name string) (protoreflect.MethodDescriptor, error) {
+	var found []protoreflect.MethodDescriptor
+	var available []string
+	files.RangeFiles(func(f protoreflect.FileDescriptor) bool {
+		for i := 0; i < f.Services().Len(); i++ {
+			service := f.Services().Get(i)
+			for j := 0; j < service.Methods().Len(); j++ {
+				method := service.Methods().Get(j)
+				available = append(available, string(service.Name())+"/"+string(method.Name()))
+				if name == string(service.Name())+"/"+string(method.Name()) ||
+					name == string(service.FullName())+"/"+string(method.Name()) {
+					found = append(found, method)
+				}
+			}
+		}
+		return true
+	})
+
+	switch len(found) {
+	case 0:
+		sort.Strings(available)
+		return nil, status.Errorf(codes.NotFound, "method %s not found, use one of: %s", name, strings.Join(available, ", "))
+	case 1:
+		return found[0], nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "%s matches %d methods, include the package of the service", name, len(found))
+	}
+}
+
+// callData returns the request given with --data.
+func callData() ([]byte, error) {
+	switch {
+	case callDataFlag == "-":
+		return ioutil.ReadAll(userInput)
+	case strings.HasPrefix(callDataFlag, "@"):
+		return ioutil.ReadFile(strings.TrimPrefix(callDataFlag, "@"))
+	}
+	return []byte(callDataFlag), nil
+}
+
+// invokeMethod calls method over conn with req, and passes the response, or each of them for a server streaming
+// method, to handle.
+func invokeMethod(ctx context.Context, conn *grpc.ClientConn, method protoreflect.MethodDescriptor, req *dynamicpb.Message,
+	handle func(res *dynamicpb.Message) error) error {
+	fullMethod := "/" + string(method.Parent().FullName()) + "/" + string(method.Name())
+	if method.IsStreamingClient() {
+		return status.Errorf(codes.Unimplemented, "%s streams its requests, which is not supported", method.FullName())
+	}
+
+	if !method.IsStreamingServer() {
+		res := dynamicpb.NewMessage(method.Output())
+		if err := conn.Invoke(ctx, fullMethod, req, res); err != nil {
+			return err
+		}
+		return handle(res)
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, fullMethod)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		res := dynamicpb.NewMessage(method.Output())
+		err := stream.RecvMsg(res)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := handle(res); err != nil {
+			return err
+		}
+	}
+}
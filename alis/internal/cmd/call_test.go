@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// serveEvents serves the EventsService of fds in process, in place of a deployed neuron, and returns the hosts the
+// CLI dialed.  GetEvent returns the named event, with the origin of the request if it has one, and ListEvents streams
+// two pages of events.
+func serveEvents(t *testing.T, fds *descriptorpb.FileDescriptorSet) *[]string {
+	files, err := descriptorFiles(fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := func(name string) *dynamicpb.Message {
+		d, err := files.FindDescriptorByName(protoreflect.FullName("alis.in.resources.events.v1." + name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dynamicpb.NewMessage(d.(protoreflect.MessageDescriptor))
+	}
+	event := func(name string) *dynamicpb.Message {
+		m := message("Event")
+		m.Set(m.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString(name))
+		return m
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		switch method {
+		case "/alis.in.resources.events.v1.EventsService/GetEvent":
+			req := message("GetEventRequest")
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			name := req.Get(req.Descriptor().Fields().ByName("name")).String()
+			if name == "" {
+				return status.Error(codes.NotFound, "event not found")
+			}
+			res := event(name)
+			if origin := req.Descriptor().Fields().ByName("origin"); origin != nil && req.Has(origin) {
+				res.Set(res.Descriptor().Fields().ByName("origin"), req.Get(origin))
+			}
+			return stream.SendMsg(res)
+		case "/alis.in.resources.events.v1.EventsService/ListEvents":
+			if err := stream.RecvMsg(message("ListEventsRequest")); err != nil {
+				return err
+			}
+			for _, name := range []string{"events/1", "events/2"} {
+				res := message("ListEventsResponse")
+				field := res.Descriptor().Fields().ByName("events")
+				list := res.Mutable(field).List()
+				list.Append(protoreflect.ValueOfMessage(event(name)))
+				if err := stream.SendMsg(res); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	var hosts []string
+	oldDialNeuron := dialNeuron
+	dialNeuron = func(ctx context.Context, host string, plaintext bool) (*grpc.ClientConn, error) {
+		hosts = append(hosts, host)
+		return grpc.DialContext(ctx, "bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	t.Cleanup(func() { dialNeuron = oldDialNeuron })
+	return &hosts
+}
+
+func TestCall(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", Environment: pbProducts.ProductDeployment_DEV,
+		GoogleProjectId: "alis-in-dev-abc"})
+	e.backend.AddNeuronDeployment(&pbProducts.NeuronDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc/neurons/resources-events-v1", Version: "1.0.0"})
+
+	file := eventsProto()
+	file.Service[0].Method[1].ServerStreaming = proto.Bool(true)
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/1", Version: "1.0.0", FileDescriptorSet: fds})
+	// the deployed version is found past the versions which were built after it.
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/2", Version: "1.1.0",
+		FileDescriptorSet: &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{Name: proto.String("empty.proto")}}}})
+	hosts := serveEvents(t, fds)
+
+	stdout, _ := e.run("", "call", "alis.in.resources-events-v1", "EventsService/GetEvent", "-d", `{"name": "events/1"}`, "--deployment", "dev")
+	var res map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil || res["name"] != "events/1" {
+		t.Errorf("response = %v, %v\n%s", res, err, stdout)
+	}
+	if got, want := (*hosts)[0], "v1.events.resources.alis-in-dev-abc.alis.alis.dev"; got != want {
+		t.Errorf("host = %s, want %s", got, want)
+	}
+
+	// each message of a server stream is printed, the request is read from the standard input.
+	stdout, _ = e.run(`{"parent": "events"}`, "call", "alis.in.resources-events-v1", "alis.in.resources.events.v1.EventsService/ListEvents",
+		"-d", "-", "--host", "localhost:8080", "--plaintext", "--deployment", "dev")
+	if got := strings.Count(stdout, `"events/`); got != 2 {
+		t.Errorf("got %d events, want 2:\n%s", got, stdout)
+	}
+	if got, want := (*hosts)[1], "localhost:8080"; got != want {
+		t.Errorf("host = %s, want %s", got, want)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"EventsService/GetEvent", "-d", `{"name": ""}`}, exitNotFound},
+		{[]string{"EventsService/GetEvent", "-d", `{"id": "events/1"}`}, exitInvalidArgument},
+		{[]string{"EventsService/WatchEvents"}, exitNotFound},
+	} {
+		args := append([]string{"call", "alis.in.resources-events-v1"}, tt.args...)
+		_, console, err := e.runErr("", append(args, "--deployment", "dev")...)
+		if got := exitCode(err); got != tt.want {
+			t.Errorf("%s: exit code = %d, want %d\n%s", strings.Join(tt.args, " "), got, tt.want, console)
+		}
+	}
+}
+
+func TestCallImportedTypes(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", Environment: pbProducts.ProductDeployment_DEV,
+		GoogleProjectId: "alis-in-dev-abc"})
+	e.backend.AddNeuronDeployment(&pbProducts.NeuronDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc/neurons/resources-events-v1", Version: "1.0.0"})
+
+	// the events and requests have an origin, of a type shared by the neurons of the organisation.
+	shared := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("alis/in/shared/v1/origin.proto"),
+		Package: proto.String("alis.in.shared.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Origin"), Field: []*descriptorpb.FieldDescriptorProto{{
+			Name: proto.String("source"), Number: proto.Int32(1), JsonName: proto.String("source"),
+			Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()}}}},
+	}
+	file := eventsProto()
+	file.Dependency = append(file.Dependency, shared.GetName())
+	for _, message := range file.GetMessageType()[:2] {
+		message.Field = append(message.Field, &descriptorpb.FieldDescriptorProto{
+			Name: proto.String("origin"), Number: proto.Int32(3), JsonName: proto.String("origin"),
+			Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".alis.in.shared.v1.Origin")})
+	}
+	// the descriptor set of the version leaves out the imports, which protoc compiles from the workspace.
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/1", Version: "1.0.0",
+		FileDescriptorSet: &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}})
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{shared, file}}
+	e.protocWrites(fds)
+	serveEvents(t, fds)
+
+	stdout, console := e.run("", "call", "alis.in.resources-events-v1", "EventsService/GetEvent",
+		"-d", `{"name": "events/1", "origin": {"source": "cli"}}`, "--deployment", "dev")
+	var res struct {
+		Name   string
+		Origin struct{ Source string }
+	}
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil || res.Origin.Source != "cli" {
+		t.Errorf("response = %+v, %v\n%s%s", res, err, stdout, console)
+	}
+
+	// without the imports in the workspace either, the call fails rather than drop the origin.
+	writeFile(t, filepath.Join(e.home, "bin", "protoc"), "#!/bin/sh\nexit 1\n")
+	_, console, err := e.runErr("", "call", "alis.in.resources-events-v1", "EventsService/GetEvent",
+		"-d", `{"name": "events/1"}`, "--deployment", "dev")
+	if errorCode(err) != codes.FailedPrecondition || !strings.Contains(console, "alis.in.shared.v1.Origin") {
+		t.Errorf("got %v, want the unresolved origin\n%s", err, console)
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+	pbOperations "go.protobuf.alis.alis.exchange/alis/os/resources/operations/v1"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	pbParsers "go.protobuf.alis.alis.exchange/alis/os/services/parsers/v1"
+)
+
+// The default endpoints of the alis_ OS services.  Each may be overridden using the `endpoints.*` keys in the
+// ~/.alis config file, the ALIS_ENDPOINTS_* environment variables or the `--*-endpoint` flags.
+const (
+	defaultProductsEndpoint   = "resources-products-v1-ntaj7kcaca-ew.a.run.app"
+	defaultOperationsEndpoint = "resources-operations-v1-ntaj7kcaca-ew.a.run.app"
+	defaultParsersEndpoint    = "services-parsers-v1-ntaj7kcaca-ew.a.run.app"
+)
+
+// clientSet bundles the alis_ OS clients used by the commands.
+type clientSet struct {
+	Products   pbProducts.ServiceClient
+	Operations pbOperations.ServiceClient
+	Parsers    pbParsers.ParserServiceClient
+}
+
+// clientSetFunc constructs a clientSet.
+type clientSetFunc func(ctx context.Context) (*clientSet, error)
+
+type clientSetKey struct{}
+
+// lazyClientSet only constructs the clientSet the first time it is requested, which allows commands that
+// do not make use of the alis_ OS services to run without network access or credentials.
+type lazyClientSet struct {
+	once    sync.Once
+	newFunc clientSetFunc
+	clients *clientSet
+	err     error
+}
+
+func (l *lazyClientSet) get(ctx context.Context) (*clientSet, error) {
+	l.once.Do(func() {
+		l.clients, l.err = l.newFunc(ctx)
+	})
+	return l.clients, l.err
+}
+
+// withClientSet returns a copy of ctx carrying the clientSet constructed by newFunc.
+func withClientSet(ctx context.Context, newFunc clientSetFunc) context.Context {
+	return context.WithValue(ctx, clientSetKey{}, &lazyClientSet{newFunc: newFunc})
+}
+
+// clientsFromContext retrieves the clientSet carried by ctx, dialing the services on first use.
+func clientsFromContext(ctx context.Context) (*clientSet, error) {
+	l, ok := ctx.Value(clientSetKey{}).(*lazyClientSet)
+	if !ok {
+		return nil, fmt.Errorf("no alis_ OS clients available in the command context")
+	}
+	return l.get(ctx)
+}
+
+// dialClientSet connects to the alis_ OS services at the configured endpoints.
+func dialClientSet(ctx context.Context) (*clientSet, error) {
+	dial := NewServerConnection
+	if viper.GetBool("insecure") {
+		dial = NewInsecureServerConnection
+	}
+
+	// Initialise alis Products client
+	connProducts, err := dial(ctx, viper.GetString("endpoints.products"))
+	if err != nil {
+		return nil, fmt.Errorf("alis.NewServerConnection: %s", err)
+	}
+
+	// Initialise alis Parsers client
+	connParsers, err := dial(ctx, viper.GetString("endpoints.parsers"))
+	if err != nil {
+		return nil, fmt.Errorf("alis.NewServerConnection: %s", err)
+	}
+
+	// Initialise alis Operations client
+	connOperations, err := dial(ctx, viper.GetString("endpoints.operations"))
+	if err != nil {
+		return nil, fmt.Errorf("alis.NewServerConnection: %s", err)
+	}
+
+	return &clientSet{
+		Products:   pbProducts.NewServiceClient(connProducts),
+		Operations: pbOperations.NewServiceClient(connOperations),
+		Parsers:    pbParsers.NewParserServiceClient(connParsers),
+	}, nil
+}
@@ -0,0 +1,537 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alis-x/cli/alis/internal/fake"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	pbOperations "go.protobuf.alis.alis.exchange/alis/os/resources/operations/v1"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	pbParsers "go.protobuf.alis.alis.exchange/alis/os/services/parsers/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMain(m *testing.M) {
+	pterm.DisableStyling()
+	pollInterval = 0
+	homedir.DisableCache = true
+	os.Exit(m.Run())
+}
+
+// testEnv runs the commands against a fake alis_ OS backend, with a temporary home directory holding the
+// alis.exchange workspace.
+type testEnv struct {
+	t       *testing.T
+	backend *fake.Backend
+	home    string
+	clients *clientSet
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	backend := fake.NewBackend()
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	backend.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	home := t.TempDir()
+	oldHomeDir := homeDir
+	homeDir = home
+	t.Cleanup(func() { homeDir = oldHomeDir })
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("ALIS_NO_UPDATE_CHECK", "true")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// Stand-in for protoc, which writes an empty descriptor set.
+	bin := filepath.Join(home, "bin")
+	writeFile(t, filepath.Join(bin, "protoc"), `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+	--descriptor_set_out=*) : > "${arg#--descriptor_set_out=}" ;;
+	esac
+done
+`)
+	if err := os.Chmod(filepath.Join(bin, "protoc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return &testEnv{
+		t:       t,
+		backend: backend,
+		home:    home,
+		clients: &clientSet{
+			Products:   pbProducts.NewServiceClient(conn),
+			Operations: pbOperations.NewServiceClient(conn),
+			Parsers:    pbParsers.NewParserServiceClient(conn),
+		},
+	}
+}
+
+// run executes the alis command with the given arguments, answering prompts from stdin.  It returns what the
+// command wrote to its standard output and the console view printed by pterm.  The test fails if the command
+// returns an error.
+func (e *testEnv) run(stdin string, args ...string) (string, string) {
+	e.t.Helper()
+
+	stdout, console, err := e.runErr(stdin, args...)
+	if err != nil {
+		e.t.Fatalf("alis %s: %v\n%s", strings.Join(args, " "), err, console)
+	}
+	return stdout, console
+}
+
+// runErr executes the alis command like run, but returns the error of the command, which is also printed to the
+// console view as Execute would.
+func (e *testEnv) runErr(stdin string, args ...string) (string, string, error) {
+	e.t.Helper()
+
+	var stdout, console bytes.Buffer
+	pterm.SetDefaultOutput(&console)
+	defer pterm.SetDefaultOutput(os.Stdout)
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&console)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+	oldUserInput := userInput
+	userInput = bufio.NewReader(strings.NewReader(stdin))
+	defer func() { userInput = oldUserInput }()
+	defer resetFlags(rootCmd)
+
+	ctx := withClientSet(context.Background(), func(ctx context.Context) (*clientSet, error) {
+		return e.clients, nil
+	})
+	rootCmd.SetArgs(args)
+	// cobra only passes the context on to a sub command which has none yet, so set it on the command itself to
+	// not reuse the clients of an earlier test.
+	cmd, _, err := rootCmd.Find(args)
+	if err != nil {
+		cmd = rootCmd
+	}
+	err = cmd.ExecuteContext(ctx)
+	if err != nil {
+		renderError(err)
+	}
+	return stdout.String(), console.String(), err
+}
+
+// resetFlags restores the flags of cmd and its sub commands to their defaults, as flags keep their values between
+// executions of the same command tree.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if f.Changed {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+	outputFlag = ""
+	answers = map[string]string{}
+}
+
+// repo creates a git repository at path in the workspace, tracking a bare remote, with the given files committed
+// to master.
+func (e *testEnv) repo(path string, files map[string]string) string {
+	e.t.Helper()
+
+	dir := filepath.Join(e.home, "alis.exchange", path)
+	remote := filepath.Join(e.home, "remotes", path+".git")
+	e.git("", "init", "--bare", remote)
+	e.git("", "clone", remote, dir)
+	e.git(dir, "checkout", "-b", "master")
+	for name, content := range files {
+		writeFile(e.t, filepath.Join(dir, name), content)
+	}
+	e.git(dir, "add", "-A")
+	e.git(dir, "commit", "-m", "initial commit")
+	e.git(dir, "push", "-u", "origin", "master")
+	return dir
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func (e *testEnv) git(dir string, args ...string) string {
+	e.t.Helper()
+
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		e.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seedNeuron adds the alis.in.resources-events-v1 neuron and its parents to the backend.
+func (e *testEnv) seedNeuron() *pbProducts.Neuron {
+	e.backend.AddOrganisation(&pbProducts.Organisation{Name: "organisations/alis", GoogleProjectId: "alis-org-123"})
+	e.backend.AddProduct(&pbProducts.Product{Name: "organisations/alis/products/in", Version: "1.1.0"})
+	neuron := &pbProducts.Neuron{Name: "organisations/alis/products/in/neurons/resources-events-v1"}
+	e.backend.AddNeuron(neuron)
+	return neuron
+}
+
+func TestBuildNeuron(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	productRepo := e.repo("alis/products/in", map[string]string{
+		"resources/events/v1/Dockerfile": "FROM scratch\n",
+	})
+	protoRepo := e.repo("alis/proto", map[string]string{
+		"alis/in/resources/events/v1/events.proto": "syntax = \"proto3\";\n",
+	})
+
+	_, out := e.run("", "neuron", "build", "alis.in.resources-events-v1")
+
+	versions := e.backend.NeuronVersions(neuron.GetName())
+	if len(versions) != 1 {
+		t.Fatalf("got %d neuron versions, want 1\n%s", len(versions), out)
+	}
+	version := versions[0]
+	if got, want := version.GetVersion(), "1.0.0"; got != want {
+		t.Errorf("version = %q, want %q", got, want)
+	}
+	if got, want := version.GetCommitSha(), e.git(productRepo, "rev-parse", "HEAD"); got != want {
+		t.Errorf("commit sha = %q, want %q", got, want)
+	}
+	if got, want := version.GetProtoCommitSha(), e.git(protoRepo, "rev-parse", "HEAD"); got != want {
+		t.Errorf("proto commit sha = %q, want %q", got, want)
+	}
+	if got := version.GetDockerfilePaths(); len(got) != 1 || got[0] != "." {
+		t.Errorf("dockerfile paths = %q, want [.]", got)
+	}
+
+	// both repositories are tagged with the new version on their remotes.
+	for _, path := range []string{"alis/products/in", "alis/proto"} {
+		tags := e.git(filepath.Join(e.home, "remotes", path+".git"), "tag")
+		if !strings.HasPrefix(tags, "alis.in.resources-events-v1.1.0.0.") {
+			t.Errorf("tags of %s = %q, want a 1.0.0 tag", path, tags)
+		}
+	}
+}
+
+func TestBuildNeuronReleaseCandidate(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/1", Version: "1.2.3"})
+	e.repo("alis/products/in", map[string]string{"resources/events/v1/Dockerfile": "FROM scratch\n"})
+	e.repo("alis/proto", map[string]string{"alis/in/resources/events/v1/events.proto": "syntax = \"proto3\";\n"})
+
+	// versions which do not follow the latest version, or belong to another neuron, are rejected.
+	for _, version := range []string{"1.2.3", "1.2.3-rc.1", "2.0.0"} {
+		_, out, err := e.runErr("", "neuron", "build", "alis.in.resources-events-v1", "--version", version)
+		if err == nil {
+			t.Errorf("--version %s: want an error", version)
+		}
+		if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 1 {
+			t.Fatalf("--version %s: got %d neuron versions, want 1\n%s", version, got, out)
+		}
+	}
+
+	_, out := e.run("", "neuron", "build", "alis.in.resources-events-v1", "--release", "minor", "--pre", "rc")
+	if got, want := e.backend.NeuronVersions(neuron.GetName())[0].GetVersion(), "1.3.0-rc.1"; got != want {
+		t.Errorf("version = %q, want %q\n%s", got, want, out)
+	}
+}
+
+func TestBuildProductBumpsTakenVersion(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	productRepo := e.repo("alis/products/in", map[string]string{"README.md": "# in\n"})
+	e.repo("alis/proto", map[string]string{"alis/in/resources/events/v1/events.proto": "syntax = \"proto3\";\n"})
+	e.git(productRepo, "tag", "alis.in.1.1.1")
+	e.git(productRepo, "push", "origin", "alis.in.1.1.1")
+
+	// both repositories are tagged with the next version, which the product is updated to.
+	_, out := e.run("", "product", "build", "alis.in", "--yes")
+	if got := e.backend.Product("organisations/alis/products/in").GetVersion(); got != "1.1.2" {
+		t.Errorf("version = %q, want 1.1.2\n%s", got, out)
+	}
+	for _, path := range []string{"alis/products/in", "alis/proto"} {
+		if tags := e.git(filepath.Join(e.home, "remotes", path+".git"), "tag"); !strings.Contains(tags, "alis.in.1.1.2") {
+			t.Errorf("tags of %s = %q, want alis.in.1.1.2", path, tags)
+		}
+	}
+}
+
+func TestDeployProduct(t *testing.T) {
+	e := newTestEnv(t)
+	e.backend.OperationPolls = 3
+	e.seedNeuron()
+	for _, id := range []string{"in-dev-abc", "in-prod-def", "in-prod-ghi"} {
+		e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+			Name:            "organisations/alis/products/in/deployments/" + id,
+			GoogleProjectId: id,
+			Version:         "1.0.0",
+		})
+	}
+
+	_, out := e.run("0,1\n", "product", "deploy", "alis.in")
+
+	for id, want := range map[string]string{"in-dev-abc": "1.1.0", "in-prod-def": "1.1.0", "in-prod-ghi": "1.0.0"} {
+		deployment := e.backend.ProductDeployment("organisations/alis/products/in/deployments/" + id)
+		if got := deployment.GetVersion(); got != want {
+			t.Errorf("version of %s = %q, want %q\n%s", id, got, want, out)
+		}
+	}
+	if got := len(e.backend.Operations()); got != 2 {
+		t.Errorf("got %d operations, want 2", got)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", GoogleProjectId: "in-dev-abc", Version: "1.0.0"})
+	productRepo := e.repo("alis/products/in", map[string]string{"resources/events/v1/Dockerfile": "FROM scratch\n"})
+	e.repo("alis/proto", map[string]string{"alis/in/resources/events/v1/events.proto": "syntax = \"proto3\";\n"})
+
+	// prototext does not promise stable spacing.
+	squash := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+	_, out := e.run("", "neuron", "build", "alis.in.resources-events-v1", "--dry-run")
+	for _, want := range []string{
+		"would tag alis.in.resources-events-v1.1.0.0.",
+		"would call CreateNeuronVersion",
+		"commit_sha: \"" + e.git(productRepo, "rev-parse", "HEAD") + "\"",
+		"dockerfile_paths: \".\"",
+		"neuron_version_id: \"1.0.0\"",
+	} {
+		if !strings.Contains(squash(out), want) {
+			t.Errorf("plan does not contain %q:\n%s", want, out)
+		}
+	}
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 0 {
+		t.Errorf("got %d neuron versions, want none", got)
+	}
+	for _, path := range []string{"alis/products/in", "alis/proto"} {
+		if tags := e.git(filepath.Join(e.home, "remotes", path+".git"), "tag"); tags != "" {
+			t.Errorf("tags of %s = %q, want none", path, tags)
+		}
+	}
+
+	_, out = e.run("", "product", "deploy", "alis.in", "--deployments", "in-dev-abc", "--dry-run")
+	for _, want := range []string{"would call UpdateProductDeployment", "version: \"1.1.0\"", "paths: \"version\""} {
+		if !strings.Contains(squash(out), want) {
+			t.Errorf("plan does not contain %q:\n%s", want, out)
+		}
+	}
+	if got := e.backend.ProductDeployment("organisations/alis/products/in/deployments/in-dev-abc").GetVersion(); got != "1.0.0" {
+		t.Errorf("version = %q, want 1.0.0", got)
+	}
+	if got := len(e.backend.Operations()); got != 0 {
+		t.Errorf("got %d operations, want none", got)
+	}
+
+	// the workspace of an organisation is left in place.
+	orgPath := filepath.Join(e.home, "alis.exchange", "alis")
+	_, out = e.run("", "org", "clear", "alis", "--dry-run", "--yes")
+	if !strings.Contains(out, "would remove "+orgPath) {
+		t.Errorf("plan does not contain the removal of %s:\n%s", orgPath, out)
+	}
+	if _, err := os.Stat(productRepo); err != nil {
+		t.Errorf("org clear --dry-run removed the workspace: %v", err)
+	}
+}
+
+func TestListProductsOutput(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.AddProduct(&pbProducts.Product{Name: "organisations/alis/products/fx", Version: "2.0.1", DisplayName: "FX"})
+
+	stdout, console := e.run("", "product", "list", "alis", "--output", "json")
+	var res struct {
+		Products []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"products"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s\n%s", err, stdout, console)
+	}
+	if len(res.Products) != 2 || res.Products[0].Name != "organisations/alis/products/fx" || res.Products[0].Version != "2.0.1" {
+		t.Errorf("products = %+v", res.Products)
+	}
+
+	stdout, _ = e.run("", "product", "list", "alis", "-o", "table")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "Index") || !strings.Contains(lines[1], "2.0.1") {
+		t.Errorf("table output:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "\x1b[") {
+		t.Errorf("table output contains escape sequences:\n%q", stdout)
+	}
+}
+
+func TestProductTreeOutput(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.backend.AddNeuronVersion(&pbProducts.NeuronVersion{Name: neuron.GetName() + "/versions/1.0.0", Version: "1.0.0"})
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{Name: "organisations/alis/products/in/deployments/in-dev-abc", Version: "1.1.0"})
+	e.backend.AddNeuronDeployment(&pbProducts.NeuronDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc/neurons/resources-events-v1", Version: "1.0.0"})
+
+	stdout, _ := e.run("", "product", "tree", "alis.in", "-o", "yaml")
+	for _, want := range []string{
+		"name: organisations/alis/products/in\n",
+		"latestVersion:\n",
+		"version: 1.0.0\n",
+		"name: organisations/alis/products/in/deployments/in-dev-abc/neurons/resources-events-v1\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("yaml output does not contain %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestDeployProductNonInteractive(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", Environment: pbProducts.ProductDeployment_DEV, Version: "1.1.0",
+		Envs: []*pbProducts.Product_Env{{Name: "ALIS_OS_KEEP", Value: "a"}, {Name: "ALIS_OS_DROP", Value: "b"}}})
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-prod-def", Environment: pbProducts.ProductDeployment_PROD, Version: "1.0.0"})
+
+	// a missing answer fails instead of waiting on stdin.
+	_, console, err := e.runErr("", "product", "deploy", "alis.in", "--non-interactive")
+	if err == nil || !strings.Contains(console, "--deployments") {
+		t.Errorf("expected a missing answer error, got:\n%s", console)
+	}
+	if got := len(e.backend.Operations()); got != 0 {
+		t.Fatalf("got %d operations, want 0", got)
+	}
+
+	answers := filepath.Join(e.home, "answers.yaml")
+	writeFile(t, answers, "deployments: dev\nset-env: [ALIS_OS_NEW=c]\n")
+	_, console = e.run("", "product", "deploy", "alis.in", "--non-interactive", "--yes", "--answers", answers,
+		"--unset-env", "ALIS_OS_DROP")
+
+	dev := e.backend.ProductDeployment("organisations/alis/products/in/deployments/in-dev-abc")
+	var envs []string
+	for _, env := range dev.GetEnvs() {
+		envs = append(envs, env.GetName()+"="+env.GetValue())
+	}
+	if got, want := strings.Join(envs, ","), "ALIS_OS_KEEP=a,ALIS_OS_NEW=c"; got != want {
+		t.Errorf("envs = %s, want %s\n%s", got, want, console)
+	}
+	if got := e.backend.ProductDeployment("organisations/alis/products/in/deployments/in-prod-def").GetVersion(); got != "1.0.0" {
+		t.Errorf("prod deployment was updated to %s", got)
+	}
+}
+
+func TestOperationCommands(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.OperationPolls = 2
+	for _, id := range []string{"fx", "pay"} {
+		_, err := e.clients.Products.CreateProduct(context.Background(), &pbProducts.CreateProductRequest{
+			Parent: "organisations/alis", ProductId: id, Product: &pbProducts.Product{Version: "0.0.1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout, console := e.run("", "operation", "wait", "operations/1", "--output", "json")
+	var op struct {
+		Done     bool `json:"done"`
+		Response struct {
+			Type string `json:"@type"`
+			Name string `json:"name"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &op); err != nil {
+		t.Fatalf("invalid json: %v\n%s\n%s", err, stdout, console)
+	}
+	if !op.Done || op.Response.Name != "organisations/alis/products/fx" || !strings.HasSuffix(op.Response.Type, ".Product") {
+		t.Errorf("unexpected operation: %+v", op)
+	}
+
+	// confirmations are answered with --yes, as they have no flag of their own.
+	_, console, err := e.runErr("", "operation", "cancel", "operations/2", "--non-interactive")
+	if errorCode(err) != codes.FailedPrecondition || !strings.Contains(console, "--yes") || strings.Contains(console, "--cancel") {
+		t.Errorf("got %v, want a missing confirmation naming --yes\n%s", err, console)
+	}
+	_, console = e.run("", "operation", "cancel", "operations/2", "--yes")
+	if e.backend.Product("organisations/alis/products/pay") != nil {
+		t.Errorf("cancelled operation created its product\n%s", console)
+	}
+
+	stdout, _ = e.run("", "operation", "list", "--output", "table")
+	want := []string{
+		"Index  Name          Done  Error                Metadata  Response",
+		"0      operations/1  true                                 alis.os.resources.products.v1.Product",
+		"1      operations/2  true  operation cancelled",
+	}
+	for _, line := range want {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("missing line %q in:\n%s", line, stdout)
+		}
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.OperationPolls = 1 << 30
+	_, err := e.clients.Products.CreateProduct(context.Background(), &pbProducts.CreateProductRequest{
+		Parent: "organisations/alis", ProductId: "fx", Product: &pbProducts.Product{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, console, err := e.runErr("", "operation", "wait", "operations/1", "--timeout", "50ms")
+	if errorCode(err) != codes.DeadlineExceeded || !strings.Contains(console, "operations/1 did not complete within 50ms") {
+		t.Errorf("expected a timeout, got:\n%s", console)
+	}
+	if ops := e.backend.Operations(); ops[0].GetDone() {
+		t.Errorf("operation completed")
+	}
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+)
+
+const (
+	// completionTimeout bounds the calls to the alis_ OS made while completing, so a slow connection does not
+	// hang the shell.
+	completionTimeout = 5 * time.Second
+	// completionCacheTTL is how long the resources listed for completion are reused.
+	completionCacheTTL = 2 * time.Minute
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: pterm.Blue("Generates the shell completion script for bash, zsh, fish or powershell"),
+	Long: pterm.Green(
+		`Use this command to enable tab completion of the commands, flags and the organisation, product,
+neuron and deployment IDs they take.  The IDs are listed from the alis_ OS, or from the local
+workspace when offline.
+
+Bash (requires the bash-completion package):
+
+	# current session only
+	source <(alis completion bash)
+	# every new session, on Linux
+	alis completion bash > /etc/bash_completion.d/alis
+	# every new session, on macOS with Homebrew
+	alis completion bash > $(brew --prefix)/etc/bash_completion.d/alis
+
+Zsh:
+
+	# enable completion, if not already done
+	echo "autoload -U compinit; compinit" >> ~/.zshrc
+	alis completion zsh > "${fpath[1]}/_alis"
+
+Fish:
+
+	alis completion fish > ~/.config/fish/completions/alis.fish
+
+PowerShell:
+
+	alis completion powershell >> $PROFILE
+
+Start a new shell for the completion to take effect.`),
+	Example:               pterm.LightYellow("alis completion zsh > \"${fpath[1]}/_alis\""),
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			err = cmd.Root().GenBashCompletionV2(out, true)
+		case "zsh":
+			err = cmd.Root().GenZshCompletion(out)
+		case "fish":
+			err = cmd.Root().GenFishCompletion(out, true)
+		case "powershell":
+			err = cmd.Root().GenPowerShellCompletionWithDesc(out)
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeOrgArg completes the {orgID} argument of a command.
+func completeOrgArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeResourceArg(cmd, args, toComplete, 1)
+}
+
+// completeProductArg completes the {orgID}.{productID} argument of a command.
+func completeProductArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeResourceArg(cmd, args, toComplete, 2)
+}
+
+// completeNeuronArg completes the {orgID}.{productID}.{neuronID} argument of a command.
+func completeNeuronArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeResourceArg(cmd, args, toComplete, 3)
+}
+
+// completeResourceArg completes one part at a time of an argument made up of depth parts, the organisation, product
+// and neuron.  A completed part is followed by a dot, without a space, as long as more parts are expected.
+func completeResourceArg(cmd *cobra.Command, args []string, toComplete string, depth int) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	parts := strings.Split(toComplete, ".")
+	if len(parts) > depth {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	switch len(parts) {
+	case 1:
+		names = completionOrganisations(cmd.Context())
+	case 2:
+		names = completionProducts(cmd.Context(), parts[0])
+	case 3:
+		names = completionNeurons(cmd.Context(), parts[0], parts[1])
+	}
+
+	var res []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+		if len(parts) < depth {
+			name += "."
+		}
+		res = append(res, name)
+	}
+	if len(parts) < depth {
+		return res, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDeployments completes the --deployments flag with the IDs of the deployments of the product of the
+// argument, each of the comma separated values in turn.
+func completeDeployments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	product, ok := completionProduct(args)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	done := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done = toComplete[:i+1]
+	}
+
+	ids, err := cachedCompletions(cmd.Context(), "deployments "+product, func(ctx context.Context, clients *clientSet) ([]string, error) {
+		res, err := clients.Products.ListProductDeployments(ctx, &pbProducts.ListProductDeploymentsRequest{Parent: product})
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, d := range res.GetProductDeployments() {
+			ids = append(ids, lastSegment(d.GetName()))
+		}
+		return ids, nil
+	})
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+	}
+
+	var res []string
+	for _, id := range ids {
+		if strings.HasPrefix(done+id, toComplete) && !strings.Contains(","+done, ","+id+",") {
+			res = append(res, done+id)
+		}
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completionProduct returns the resource name of the product of an argument which is already typed, or of the
+// working directory if there is none.
+func completionProduct(args []string) (string, bool) {
+	var arg string
+	if len(args) > 0 {
+		arg = args[0]
+	} else if dir, err := os.Getwd(); err == nil {
+		arg, _ = productFromDir(dir)
+	}
+	parts := strings.Split(arg, ".")
+	switch {
+	case arg == "":
+		return "", false
+	case regexp.MustCompile(`^[a-z]{2}$`).MatchString(parts[0]):
+		// the short form of a product or neuron, completed with the organisation of the active profile.
+		if viper.GetString("org") == "" {
+			return "", false
+		}
+		parts = append([]string{viper.GetString("org")}, parts...)
+	case len(parts) < 2:
+		return "", false
+	}
+	return "organisations/" + parts[0] + "/products/" + parts[1], true
+}
+
+// completionOrganisations lists the IDs of the organisations, or of those in the workspace when the alis_ OS is
+// out of reach.
+func completionOrganisations(ctx context.Context) []string {
+	ids, err := cachedCompletions(ctx, "organisations", func(ctx context.Context, clients *clientSet) ([]string, error) {
+		res, err := clients.Products.ListOrganisations(ctx, &pbProducts.ListOrganisationsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, o := range res.GetOrganisations() {
+			ids = append(ids, lastSegment(o.GetName()))
+		}
+		return ids, nil
+	})
+	if err == nil {
+		return ids
+	}
+	cobra.CompDebugln(err.Error(), false)
+
+	ws := currentWorkspace()
+	var local []string
+	for _, org := range localDirs(ws.Root, "^[a-z][a-z0-9]{2,7}$") {
+		if len(localDirs(ws.Org(org), "^(products|proto)$")) > 0 {
+			local = append(local, org)
+		}
+	}
+	return local
+}
+
+// completionProducts lists the {orgID}.{productID} names of the products of an organisation, or of those in the
+// workspace when the alis_ OS is out of reach.
+func completionProducts(ctx context.Context, organisationID string) []string {
+	names, err := cachedCompletions(ctx, "products organisations/"+organisationID, func(ctx context.Context, clients *clientSet) ([]string, error) {
+		res, err := clients.Products.ListProducts(ctx, &pbProducts.ListProductsRequest{Parent: "organisations/" + organisationID})
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, p := range res.GetProducts() {
+			names = append(names, organisationID+"."+lastSegment(p.GetName()))
+		}
+		return names, nil
+	})
+	if err == nil {
+		return names
+	}
+	cobra.CompDebugln(err.Error(), false)
+
+	ws := currentWorkspace()
+	products := append(localDirs(filepath.Join(ws.Org(organisationID), "products"), "^[a-z]{2}$"),
+		localDirs(filepath.Join(ws.ProtoRepo(organisationID), organisationID), "^[a-z]{2}$")...)
+	var local []string
+	for _, product := range products {
+		local = append(local, organisationID+"."+product)
+	}
+	return unique(local)
+}
+
+// completionNeurons lists the {orgID}.{productID}.{neuronID} names of the neurons of a product, or of those in the
+// workspace when the alis_ OS is out of reach.
+func completionNeurons(ctx context.Context, organisationID string, productID string) []string {
+	parent := "organisations/" + organisationID + "/products/" + productID
+	names, err := cachedCompletions(ctx, "neurons "+parent, func(ctx context.Context, clients *clientSet) ([]string, error) {
+		res, err := clients.Products.ListNeurons(ctx, &pbProducts.ListNeuronsRequest{Parent: parent})
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, n := range res.GetNeurons() {
+			names = append(names, organisationID+"."+productID+"."+lastSegment(n.GetName()))
+		}
+		return names, nil
+	})
+	if err == nil {
+		return names
+	}
+	cobra.CompDebugln(err.Error(), false)
+
+	// the neurons are laid out as {resources|services}/{name}/{version} in both the product and proto repositories.
+	ws := currentWorkspace()
+	var local []string
+	for _, dir := range []string{ws.ProductRepo(organisationID, productID), ws.ProductProtos(organisationID, productID)} {
+		for _, kind := range localDirs(dir, "^(resources|services)$") {
+			for _, name := range localDirs(filepath.Join(dir, kind), "^[a-z]+$") {
+				for _, version := range localDirs(filepath.Join(dir, kind, name), "^v[0-9]+$") {
+					local = append(local, organisationID+"."+productID+"."+kind+"-"+name+"-"+version)
+				}
+			}
+		}
+	}
+	return unique(local)
+}
+
+// completionCache is the on-disk format of the resources listed for completion.
+type completionCache struct {
+	Time  time.Time `json:"time"`
+	Names []string  `json:"names"`
+}
+
+// cachedCompletions returns the names listed by list, reusing those listed within the last completionCacheTTL for
+// the same key and products endpoint, as the shell asks for completions on every key press.
+func cachedCompletions(ctx context.Context, key string, list func(ctx context.Context, clients *clientSet) ([]string, error)) ([]string, error) {
+	sum := sha256.Sum256([]byte(viper.GetString("endpoints.products") + " " + key))
+	var path string
+	if dir, err := os.UserCacheDir(); err == nil {
+		path = filepath.Join(dir, "alis", "completion", hex.EncodeToString(sum[:8])+".json")
+		var cache completionCache
+		if b, err := ioutil.ReadFile(path); err == nil && json.Unmarshal(b, &cache) == nil && time.Since(cache.Time) < completionCacheTTL {
+			return cache.Names, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+	clients, err := clientsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names, err := list(ctx, clients)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	// the cache is only an optimisation, so failing to write it is ignored.
+	if path != "" {
+		if b, err := json.Marshal(completionCache{Time: time.Now(), Names: names}); err == nil {
+			if os.MkdirAll(filepath.Dir(path), 0700) == nil {
+				_ = ioutil.WriteFile(path, b, 0600)
+			}
+		}
+	}
+	return names, nil
+}
+
+// localDirs returns the names of the directories in dir which match regex.
+func localDirs(dir string, regex string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && regexp.MustCompile(regex).MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// lastSegment returns the resource ID at the end of a resource name.
+func lastSegment(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// unique sorts names and removes the duplicates.
+func unique(names []string) []string {
+	sort.Strings(names)
+	var res []string
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			res = append(res, name)
+		}
+	}
+	return res
+}
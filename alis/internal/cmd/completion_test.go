@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+)
+
+func TestCompletion(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{Name: "organisations/alis/products/in/deployments/in-dev-abc"})
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{Name: "organisations/alis/products/in/deployments/in-prod-def"})
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		// parts still to be completed are followed by a dot, without a space.
+		{[]string{"neuron", "build", ""}, "alis.\n:6\n"},
+		{[]string{"neuron", "build", "alis."}, "alis.in.\n:6\n"},
+		{[]string{"neuron", "build", "alis.in.res"}, "alis.in.resources-events-v1\n:4\n"},
+		{[]string{"product", "deploy", "alis."}, "alis.in\n:4\n"},
+		{[]string{"product", "list", "al"}, "alis\n:4\n"},
+		{[]string{"product", "deploy", "alis.in", "--deployments", "in-dev-abc,"}, "in-dev-abc,in-prod-def\n:6\n"},
+	} {
+		stdout, console := e.run("", append([]string{"__complete"}, tt.args...)...)
+		if !strings.HasPrefix(stdout, tt.want) {
+			t.Errorf("alis %s<TAB> =\n%s\nwant\n%s\n%s", strings.Join(tt.args, " "), stdout, tt.want, console)
+		}
+	}
+}
+
+func TestCompletionOffline(t *testing.T) {
+	e := newTestEnv(t)
+	ws := currentWorkspace()
+	for _, dir := range []string{
+		ws.NeuronDir("alis", "in", "resources-events-v1"),
+		ws.NeuronProtos("alis", "in", "services-parser-v2"),
+		ws.NeuronProtos("alis", "in", "resources-events-v1"),
+		ws.ProductRepo("alis", "fx"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.seedNeuron()
+
+	offline := withClientSet(context.Background(), func(ctx context.Context) (*clientSet, error) {
+		return nil, errors.New("offline")
+	})
+	if got, want := completionOrganisations(offline), []string{"alis"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("organisations = %v, want %v", got, want)
+	}
+	if got, want := completionProducts(offline, "alis"), []string{"alis.fx", "alis.in"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("products = %v, want %v", got, want)
+	}
+	want := []string{"alis.in.resources-events-v1", "alis.in.services-parser-v2"}
+	if got := completionNeurons(offline, "alis", "in"); !reflect.DeepEqual(got, want) {
+		t.Errorf("neurons = %v, want %v", got, want)
+	}
+
+	// once listed, the neurons are reused from the cache.
+	online := withClientSet(context.Background(), func(ctx context.Context) (*clientSet, error) {
+		return e.clients, nil
+	})
+	if got := completionNeurons(online, "alis", "in"); !reflect.DeepEqual(got, []string{"alis.in.resources-events-v1"}) {
+		t.Errorf("neurons = %v, want alis.in.resources-events-v1", got)
+	}
+	e.backend.AddNeuron(&pbProducts.Neuron{Name: "organisations/alis/products/in/neurons/resources-trades-v1"})
+	if got := completionNeurons(online, "alis", "in"); !reflect.DeepEqual(got, []string{"alis.in.resources-events-v1"}) {
+		t.Errorf("neurons = %v, want the cached alis.in.resources-events-v1", got)
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultProfile is the profile used until another one is selected with `alis config use`.
+const defaultProfile = "default"
+
+// configKeys are the settings a profile may hold, along with a check of their values.
+var configKeys = map[string]func(value string) (interface{}, error){
+	"org": func(v string) (interface{}, error) {
+		return v, validateArgument(v, "^[a-z][a-z0-9]{2,7}$")
+	},
+	"product": func(v string) (interface{}, error) {
+		return v, validateArgument(v, "^[a-z]{2}$")
+	},
+	"endpoints.products":   anyString,
+	"endpoints.operations": anyString,
+	"endpoints.parsers":    anyString,
+	"insecure": func(v string) (interface{}, error) {
+		return strconv.ParseBool(v)
+	},
+	"output": func(v string) (interface{}, error) {
+		var o outputFormat
+		return v, o.Set(v)
+	},
+	"workspace": anyString,
+	"no-update-check": func(v string) (interface{}, error) {
+		return strconv.ParseBool(v)
+	},
+}
+
+func anyString(v string) (interface{}, error) { return v, nil }
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: pterm.Blue("Manages the default organisation, product and settings of the CLI"),
+	Long: pterm.Green(
+		`Use this command to keep settings in named profiles of the config file ($HOME/.alis.yaml).
+
+The settings of the active profile apply to every command, with flags and ALIS_* environment
+variables taking precedence.  Once an organisation and product are set, commands accept short
+forms of their arguments, for example "alis neuron build resources-events-v1" instead of
+"alis neuron build {orgID}.{productID}.resources-events-v1".
+
+The available settings are: ` + strings.Join(configKeyNames(), ", ")),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
+	},
+}
+
+// setConfigCmd represents the config set command
+var setConfigCmd = &cobra.Command{
+	Use:     "set",
+	Short:   pterm.Blue("Sets a setting of a profile"),
+	Example: pterm.LightYellow("alis config set org alis\nalis config set product in --profile staging"),
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, ok := configKeys[args[0]]
+		if !ok {
+			return unknownConfigKey(args[0])
+		}
+		value, err := check(args[1])
+		if err != nil {
+			return usageError(err)
+		}
+
+		config, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		profile := activeProfile(config)
+		setNested(config, append([]string{"profiles", profile}, strings.Split(args[0], ".")...), value)
+		if err := writeConfigFile(config); err != nil {
+			return err
+		}
+		pterm.Success.Printf("Set %s to %s in profile %s\n", args[0], args[1], profile)
+		return nil
+	},
+}
+
+// getConfigCmd represents the config get command
+var getConfigCmd = &cobra.Command{
+	Use:     "get",
+	Short:   pterm.Blue("Prints a setting of a profile"),
+	Example: pterm.LightYellow("alis config get org"),
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := configKeys[args[0]]; !ok {
+			return unknownConfigKey(args[0])
+		}
+		config, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		profile := activeProfile(config)
+		value, ok := getNested(config, append([]string{"profiles", profile}, strings.Split(args[0], ".")...))
+		if !ok {
+			return status.Errorf(codes.NotFound, "%s is not set in profile %s", args[0], profile)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+// unsetConfigCmd represents the config unset command
+var unsetConfigCmd = &cobra.Command{
+	Use:     "unset",
+	Short:   pterm.Blue("Removes a setting from a profile"),
+	Example: pterm.LightYellow("alis config unset product"),
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := configKeys[args[0]]; !ok {
+			return unknownConfigKey(args[0])
+		}
+		config, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		profile := activeProfile(config)
+		unsetNested(config, append([]string{"profiles", profile}, strings.Split(args[0], ".")...))
+		if err := writeConfigFile(config); err != nil {
+			return err
+		}
+		pterm.Success.Printf("Unset %s in profile %s\n", args[0], profile)
+		return nil
+	},
+}
+
+// listConfigCmd represents the config list command
+var listConfigCmd = &cobra.Command{
+	Use:     "list",
+	Short:   pterm.Blue("Lists the profiles and their settings"),
+	Example: pterm.LightYellow("alis config list\nalis config list --profile staging"),
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		active := activeProfile(config)
+		profiles, _ := config["profiles"].(map[string]interface{})
+		var names []string
+		for name := range profiles {
+			// --profile shows a single profile.
+			if !cmd.Flags().Changed("profile") || name == active {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		if machineOutput() {
+			res := map[string]interface{}{"activeProfile": active, "profiles": map[string]interface{}{}}
+			for _, name := range names {
+				res["profiles"].(map[string]interface{})[name] = profiles[name]
+			}
+			return printResources(cmd, res)
+		}
+
+		table := pterm.TableData{{"Profile", "Active", "Setting", "Value"}}
+		for _, name := range names {
+			for _, key := range configKeyNames() {
+				value, ok := getNested(config, append([]string{"profiles", name}, strings.Split(key, ".")...))
+				if !ok {
+					continue
+				}
+				isActive := ""
+				if name == active {
+					isActive = "*"
+				}
+				table = append(table, []string{name, isActive, key, fmt.Sprint(value)})
+			}
+		}
+		if len(table) == 1 {
+			pterm.Info.Printf("No settings found in %s\n", configFilePath())
+			return nil
+		}
+		return renderTable(cmd, table)
+	},
+}
+
+// useConfigCmd represents the config use command
+var useConfigCmd = &cobra.Command{
+	Use:     "use",
+	Short:   pterm.Blue("Makes a profile the active one"),
+	Example: pterm.LightYellow("alis config use staging"),
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		config["current-profile"] = args[0]
+		if err := writeConfigFile(config); err != nil {
+			return err
+		}
+		pterm.Success.Printf("Switched to profile %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(setConfigCmd)
+	configCmd.AddCommand(getConfigCmd)
+	configCmd.AddCommand(unsetConfigCmd)
+	configCmd.AddCommand(listConfigCmd)
+	configCmd.AddCommand(useConfigCmd)
+}
+
+// configKeyNames returns the names of the settings in order.
+func configKeyNames() []string {
+	var names []string
+	for name := range configKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func unknownConfigKey(key string) error {
+	return status.Errorf(codes.InvalidArgument, "unknown setting %s, use one of: %s", key, strings.Join(configKeyNames(), ", "))
+}
+
+// configFilePath returns the config file, which is the one passed with `--config`, or $HOME/.alis.yaml by default.
+func configFilePath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return defaultConfigFile(homeDir)
+}
+
+// defaultConfigFile returns the .alis config file in home, which may be in any of the formats supported by viper.
+// It is .alis.yaml if there is none yet.
+func defaultConfigFile(home string) string {
+	for _, ext := range viper.SupportedExts {
+		path := filepath.Join(home, ".alis."+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(home, ".alis.yaml")
+}
+
+// readConfigFile returns the contents of the config file, which is empty if there is none.  The file is read by
+// viper, in the format given by its extension.
+func readConfigFile() (map[string]interface{}, error) {
+	path := configFilePath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return map[string]interface{}{}, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return v.AllSettings(), nil
+}
+
+// writeConfigFile replaces the contents of the config file, keeping its format.
+func writeConfigFile(config map[string]interface{}) error {
+	v := viper.New()
+	v.SetConfigPermissions(0600)
+	for key, value := range config {
+		v.Set(key, value)
+	}
+	return v.WriteConfigAs(configFilePath())
+}
+
+// activeProfile returns the profile selected with `--profile` or ALIS_PROFILE, or with `alis config use`.
+func activeProfile(config map[string]interface{}) string {
+	if profile := viper.GetString("profile"); profile != "" {
+		return profile
+	}
+	if profile, ok := config["current-profile"].(string); ok && profile != "" {
+		return profile
+	}
+	return defaultProfile
+}
+
+func getNested(m map[string]interface{}, path []string) (interface{}, bool) {
+	for i, key := range path {
+		v, ok := m[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(path)-1 {
+			return v, true
+		}
+		if m, ok = v.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func setNested(m map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+func unsetNested(m map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	next, ok := m[path[0]].(map[string]interface{})
+	if !ok {
+		return
+	}
+	unsetNested(next, path[1:])
+	if len(next) == 0 {
+		delete(m, path[0])
+	}
+}
+
+// applyProfile merges the settings of the active profile into the configuration read by viper, where they take
+// precedence over the rest of the config file, but not over flags and environment variables.
+func applyProfile() error {
+	profile := viper.GetString("profile")
+	if profile == "" {
+		profile = viper.GetString("current-profile")
+	}
+	if profile == "" {
+		profile = defaultProfile
+	}
+	settings := viper.GetStringMap("profiles." + profile)
+	if len(settings) == 0 {
+		return nil
+	}
+	return viper.MergeConfigMap(settings)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfigProfiles(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	// the settings read from the config file would otherwise carry over to later tests.
+	t.Cleanup(func() { _ = viper.ReadConfig(bytes.NewReader(nil)) })
+
+	e.run("", "config", "set", "org", "alis")
+	e.run("", "config", "set", "product", "in")
+	e.run("", "config", "set", "output", "json")
+	e.run("", "config", "set", "product", "fx", "--profile", "staging")
+
+	if stdout, console := e.run("", "config", "get", "product"); strings.TrimSpace(stdout) != "in" {
+		t.Errorf("product = %q, want in\n%s", stdout, console)
+	}
+	if stdout, console := e.run("", "config", "get", "product", "--profile", "staging"); strings.TrimSpace(stdout) != "fx" {
+		t.Errorf("product of staging = %q, want fx\n%s", stdout, console)
+	}
+
+	// the short form of the neuron is completed, and the output format applied, from the active profile.
+	stdout, console := e.run("", "neuron", "get", "resources-events-v1")
+	if !strings.Contains(stdout, `"organisations/alis/products/in/neurons/resources-events-v1"`) {
+		t.Errorf("neuron get resources-events-v1:\n%s\n%s", stdout, console)
+	}
+
+	e.run("", "config", "unset", "product")
+	initConfig()
+	if err := validateNeuronArg(getNeuronCmd, []string{"resources-events-v1"}); err == nil {
+		t.Error("resources-events-v1 is accepted without a default product")
+	}
+	args := []string{"in.resources-events-v1"}
+	if err := validateNeuronArg(getNeuronCmd, args); err != nil || args[0] != "alis.in.resources-events-v1" {
+		t.Errorf("in.resources-events-v1 = %s, %v, want alis.in.resources-events-v1", args[0], err)
+	}
+
+	e.run("", "config", "use", "staging")
+	stdout, _ = e.run("", "config", "list", "-o", "table")
+	for _, want := range []string{"default", "staging", "fx"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("config list does not contain %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestConfigSetRejectsInvalidValues(t *testing.T) {
+	e := newTestEnv(t)
+
+	for _, args := range [][]string{{"colour", "blue"}, {"output", "xml"}, {"insecure", "maybe"}, {"product", "INVALID"}} {
+		_, console, err := e.runErr("", append([]string{"config", "set"}, args...)...)
+		if exitCode(err) != exitInvalidArgument || !strings.Contains(console, "ERROR") {
+			t.Errorf("config set %s: want an error\n%s", strings.Join(args, " "), console)
+		}
+	}
+	if config, err := readConfigFile(); err != nil || len(config) != 0 {
+		t.Errorf("config = %v, %v, want it empty", config, err)
+	}
+}
+
+func TestConfigKeepsFileFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		contents string
+	}{
+		{".alis.json", `{"endpoints": {"products": "localhost:8080"}}`},
+		{".alis.toml", "[endpoints]\nproducts = \"localhost:8080\"\n"},
+	} {
+		e := newTestEnv(t)
+		t.Cleanup(func() { _ = viper.ReadConfig(bytes.NewReader(nil)) })
+		path := filepath.Join(e.home, tt.name)
+		writeFile(t, path, tt.contents)
+
+		e.run("", "config", "set", "product", "in")
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the file is still of the format of its extension, with the settings it held.
+		v := viper.New()
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(tt.name), "."))
+		if err := v.ReadConfig(bytes.NewReader(b)); err != nil {
+			t.Fatalf("%s was not kept in its format: %v\n%s", tt.name, err, b)
+		}
+		if v.GetString("endpoints.products") != "localhost:8080" || v.GetString("profiles.default.product") != "in" {
+			t.Errorf("%s lost settings:\n%s", tt.name, b)
+		}
+	}
+}
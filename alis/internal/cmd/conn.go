@@ -5,11 +5,14 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"net"
+
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/credentials/oauth"
 	"google.golang.org/grpc/status"
 )
@@ -22,7 +25,7 @@ type grpcTokenSource struct {
 //
 // The host should be the domain where the Service is hosted,:
 // Format: v1.{neuron}.{resources|services}.{deployment-project}.{domain}.alis.dev
-//
+// The port defaults to 443 if the host does not specify one.
 //
 // Best practise is to create a new connection at global level, which could be used to run many methods.  This avoids
 // unnecessary api calls to retrieve the required ID tokens each time a single method is called.
@@ -35,10 +38,16 @@ func NewServerConnection(ctx context.Context, host string) (*grpc.ClientConn, er
 			"NewTokenSource: %s", err,
 		)
 	}
+	// Default to the https port if none is specified.
+	target := host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		target = host + ":443"
+	}
+
 	// Establishes a connection
 	var opts []grpc.DialOption
 	if host != "" {
-		opts = append(opts, grpc.WithAuthority(host+":443"))
+		opts = append(opts, grpc.WithAuthority(target))
 	}
 
 	systemRoots, err := x509.SystemCertPool()
@@ -52,7 +61,7 @@ func NewServerConnection(ctx context.Context, host string) (*grpc.ClientConn, er
 	opts = append(opts, grpc.WithTransportCredentials(cred))
 	opts = append(opts, grpc.WithPerRPCCredentials(grpcTokenSource{
 		TokenSource: oauth.TokenSource{
-			tokenSource,
+			TokenSource: tokenSource,
 		},
 	}))
 
@@ -60,7 +69,7 @@ func NewServerConnection(ctx context.Context, host string) (*grpc.ClientConn, er
 	//opts = append(opts, grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()))
 	//opts = append(opts, grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
 
-	conn, err := grpc.Dial(host+":443", opts...)
+	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
 		return nil, status.Errorf(
 			codes.Unauthenticated,
@@ -71,6 +80,22 @@ func NewServerConnection(ctx context.Context, host string) (*grpc.ClientConn, er
 	return conn, nil
 }
 
+// NewInsecureServerConnection creates a new plaintext gRPC connection without any per-RPC credentials.
+//
+// This is intended for local stand-ins of the alis_ services, for example during development and in CI.
+// The target should include the port, for example: localhost:8080
+func NewInsecureServerConnection(ctx context.Context, target string) (*grpc.ClientConn, error) {
+	conn, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, status.Errorf(
+			codes.Unavailable,
+			"grpc.Dail: %s", err,
+		)
+	}
+
+	return conn, nil
+}
+
 func IDTokenTokenSource(ctx context.Context) (oauth2.TokenSource, error) {
 
 	// Get the token for the authorized_user (not the service_account since this CLI is use by users and machines)
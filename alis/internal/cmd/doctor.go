@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// doctorTimeout bounds each of the checks of `alis doctor`, some of which reach out to the network.
+const doctorTimeout = 20 * time.Second
+
+// The results of a check of `alis doctor`.
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorFailed  = "failed"
+)
+
+// doctorIDToken requests the id_token used to authenticate with the alis_ OS.  Tests may replace it.
+var doctorIDToken = func(ctx context.Context) error {
+	ts, err := IDTokenTokenSource(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = ts.Token()
+	return err
+}
+
+// doctorResult is the outcome of a single check.
+type doctorResult struct {
+	Check  string
+	Status string
+	// Detail describes what was found, for example the version of a tool.
+	Detail string
+	// Fix tells the user how to resolve a warning or failure.
+	Fix string
+}
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: pterm.Blue("Checks that the tools and credentials the CLI depends on are set up"),
+	Long: pterm.Green(
+		`Use this command to check the prerequisites of the CLI: the tools it runs, the Google
+credentials and git access to the repositories, the Go module settings and the state of
+the local repositories in the workspace.  Each problem found is listed along with its fix.`),
+	Example: pterm.LightYellow("alis doctor\nalis doctor -o json"),
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var results []doctorResult
+		spinner, _ := pterm.DefaultSpinner.Start("Checking your environment...")
+		for _, check := range []func(ctx context.Context) []doctorResult{
+			checkTools,
+			checkCredentials,
+			checkGoPrivate,
+			checkGitAccess,
+			checkWorkspace,
+		} {
+			ctx, cancel := context.WithTimeout(cmd.Context(), doctorTimeout)
+			results = append(results, check(ctx)...)
+			cancel()
+		}
+		spinner.Stop()
+
+		var failed, warnings int
+		for _, r := range results {
+			switch r.Status {
+			case doctorFailed:
+				failed++
+			case doctorWarning:
+				warnings++
+			}
+		}
+		// a failed check fails the command, such that scripts may run it before anything else.
+		var err error
+		if failed > 0 {
+			err = status.Errorf(codes.FailedPrecondition, "%d of %d checks failed and %d need attention", failed, len(results), warnings)
+		}
+
+		if machineOutput() {
+			var res []map[string]interface{}
+			for _, r := range results {
+				res = append(res, map[string]interface{}{"check": r.Check, "status": r.Status, "detail": r.Detail, "fix": r.Fix})
+			}
+			if err := printResources(cmd, res); err != nil {
+				return err
+			}
+			return err
+		}
+
+		table := pterm.TableData{{"Check", "Status", "Details"}}
+		for _, r := range results {
+			s := pterm.Green(r.Status)
+			switch r.Status {
+			case doctorFailed:
+				s = pterm.Red(r.Status)
+			case doctorWarning:
+				s = pterm.Yellow(r.Status)
+			}
+			table = append(table, []string{r.Check, s, r.Detail})
+		}
+		if err := renderTable(cmd, table); err != nil {
+			return err
+		}
+
+		for _, r := range results {
+			if r.Fix != "" {
+				ptermTip.Printf("%s: %s\n", r.Check, r.Fix)
+			}
+		}
+		switch {
+		case failed > 0:
+			return err
+		case warnings > 0:
+			pterm.Warning.Printf("All checks passed, but %d need attention\n", warnings)
+		default:
+			pterm.Success.Println("All checks passed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorTool is a program the CLI runs, and how to install it.
+type doctorTool struct {
+	Name string
+	// Version runs the program to print its version, for example `protoc --version`.  The program is only looked up
+	// if it is empty.
+	Version []string
+	// Path is where the CLI expects the program, instead of looking it up in the PATH.
+	Path string
+	// MinMajor is the lowest major version which is supported.
+	MinMajor int
+	Install  string
+}
+
+// doctorTools are the programs the commands depend on.
+func doctorTools() []doctorTool {
+	return []doctorTool{
+		{Name: "go", Version: []string{"version"}, Install: "install Go, see https://golang.org/doc/install"},
+		{Name: "git", Version: []string{"--version"}, Install: "install git, see https://git-scm.com/downloads"},
+		{Name: "gcloud", Version: []string{"--version"}, Install: "install the Cloud SDK, see https://cloud.google.com/sdk/docs/install"},
+		{Name: "protoc", Version: []string{"--version"}, MinMajor: 3, Install: "install protoc version 3 or later, see https://grpc.io/docs/protoc-installation/"},
+		{Name: "protoc-gen-go", Version: []string{"--version"}, Install: "run `go install google.golang.org/protobuf/cmd/protoc-gen-go@latest`"},
+		{Name: "protoc-gen-go-grpc", Version: []string{"--version"}, Install: "run `go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest`"},
+		{Name: "protoc-gen-go_gapic", Install: "run `go install github.com/googleapis/gapic-generator-go/cmd/protoc-gen-go_gapic@latest`"},
+		{Name: "protoc-gen-doc", Version: []string{"--version"}, Path: filepath.Join(homeDir, "go", "bin", "protoc-gen-doc"),
+			Install: "run `go install github.com/pseudomuto/protoc-gen-doc/cmd/protoc-gen-doc@latest`"},
+		{Name: "grpc_tools", Version: []string{"-m", "grpc_tools.protoc", "--version"}, Path: "python3",
+			Install: "run `python3 -m pip install grpcio-tools`"},
+	}
+}
+
+// checkTools checks that the programs the commands depend on are installed, and reports their versions.
+func checkTools(ctx context.Context) []doctorResult {
+	var results []doctorResult
+	for _, tool := range doctorTools() {
+		name := tool.Path
+		if name == "" {
+			name = tool.Name
+		}
+		r := doctorResult{Check: tool.Name}
+		found, err := exec.LookPath(name)
+		if err != nil {
+			r.Status, r.Detail, r.Fix = doctorFailed, "not found", tool.Install
+			if tool.Path != "" {
+				r.Detail = name + " not found"
+			}
+			results = append(results, r)
+			continue
+		}
+		if len(tool.Version) == 0 {
+			r.Status, r.Detail = doctorOK, found
+			results = append(results, r)
+			continue
+		}
+
+		stdout, stderr, err := run(ctx, newCommand(name, tool.Version...))
+		version := strings.TrimSpace(strings.SplitN(strings.TrimSpace(stdout+stderr), "\n", 2)[0])
+		switch {
+		case err != nil:
+			r.Status, r.Detail, r.Fix = doctorFailed, version, tool.Install
+			if version == "" {
+				r.Detail = err.Error()
+			}
+		case tool.MinMajor > 0 && majorVersion(version) < tool.MinMajor:
+			r.Status, r.Detail, r.Fix = doctorFailed, version, tool.Install
+		default:
+			r.Status, r.Detail = doctorOK, version
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// majorVersion returns the major version within the version output of a program, for example 3 for
+// "libprotoc 3.19.4", or 0 if it has none.
+func majorVersion(version string) int {
+	m := regexp.MustCompile(`(\d+)\.\d+`).FindStringSubmatch(version)
+	if m == nil {
+		return 0
+	}
+	major, _ := strconv.Atoi(m[1])
+	return major
+}
+
+// checkCredentials checks that an id_token is available from the Application Default Credentials, as used to
+// authenticate with the alis_ OS.
+func checkCredentials(ctx context.Context) []doctorResult {
+	r := doctorResult{Check: "application default credentials"}
+	if viper.GetBool("insecure") {
+		r.Status, r.Detail = doctorOK, "not needed, the endpoints are insecure"
+		return []doctorResult{r}
+	}
+	if err := doctorIDToken(ctx); err != nil {
+		r.Status, r.Detail = doctorFailed, err.Error()
+		r.Fix = "run `gcloud auth application-default login` with your alis.exchange account"
+		return []doctorResult{r}
+	}
+	r.Status, r.Detail = doctorOK, "id_token available"
+	return []doctorResult{r}
+}
+
+// checkGoPrivate checks that GOPRIVATE covers the private modules, which would otherwise be fetched from the
+// public Go module proxy and fail.
+func checkGoPrivate(ctx context.Context) []doctorResult {
+	r := doctorResult{Check: "GOPRIVATE"}
+	stdout, _, err := run(ctx, newCommand("go", "env", "GOPRIVATE"))
+	if err != nil {
+		r.Status, r.Detail, r.Fix = doctorFailed, err.Error(), "install Go, see https://golang.org/doc/install"
+		return []doctorResult{r}
+	}
+	goPrivate := strings.TrimSpace(stdout)
+
+	// the modules of the organisations in the workspace are needed to generate and build their code.
+	modules := privateModules(workspaceOrgs(currentWorkspace())...)
+	var missing []string
+	for _, module := range modules {
+		if !matchesGoPrivate(goPrivate, module) {
+			missing = append(missing, module)
+		}
+	}
+	if len(missing) == 0 {
+		r.Status, r.Detail = doctorOK, goPrivate
+		return []doctorResult{r}
+	}
+	r.Status, r.Detail = doctorFailed, "does not cover "+strings.Join(missing, ", ")
+	r.Fix = "run `go env -w GOPRIVATE=" + mergeGoPrivate(goPrivate, modules) + "`"
+	return []doctorResult{r}
+}
+
+// matchesGoPrivate reports whether module is covered by the comma separated glob patterns of GOPRIVATE, which
+// match any prefix of the module path, as documented by `go help private`.
+func matchesGoPrivate(goPrivate string, module string) bool {
+	elems := strings.Split(module, "/")
+	for _, pattern := range strings.Split(goPrivate, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		n := len(strings.Split(pattern, "/"))
+		if n > len(elems) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, strings.Join(elems[:n], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGitAccess checks that git is set up to authenticate with Cloud Source Repositories, either with the
+// .gitcookies from https://source.developers.google.com/auth/start or with the gcloud credential helper.
+func checkGitAccess(ctx context.Context) []doctorResult {
+	r := doctorResult{Check: "git access to Cloud Source Repositories"}
+	if stdout, _, err := run(ctx, newCommand("git", "config", "--get-all", "credential.https://source.developers.google.com.helper")); err == nil && strings.Contains(stdout, "gcloud") {
+		r.Status, r.Detail = doctorOK, "gcloud credential helper"
+		return []doctorResult{r}
+	}
+	if stdout, _, err := run(ctx, newCommand("git", "config", "--get", "http.cookiefile")); err == nil {
+		cookieFile, _ := homedir.Expand(strings.TrimSpace(stdout))
+		if b, err := ioutil.ReadFile(cookieFile); err == nil && strings.Contains(string(b), "source.developers.google.com") {
+			r.Status, r.Detail = doctorOK, cookieFile
+			return []doctorResult{r}
+		}
+	}
+	r.Status, r.Detail = doctorFailed, "no credentials for source.developers.google.com"
+	r.Fix = "open https://source.developers.google.com/auth/start?scopes=https://www.googleapis.com/auth/cloud-platform&state= " +
+		"and run the script it shows, or run `gcloud init` and `git config --global credential.https://source.developers.google.com.helper gcloud.sh`"
+	return []doctorResult{r}
+}
+
+// checkWorkspace checks that the Google protos are in the workspace, and the state of the repositories of each of
+// its organisations.
+func checkWorkspace(ctx context.Context) []doctorResult {
+	ws := currentWorkspace()
+	google := doctorResult{Check: "google protos"}
+	if _, err := os.Stat(filepath.Join(ws.GoogleProtos(), "google", "api", "annotations.proto")); err != nil {
+		google.Status, google.Detail, google.Fix = doctorFailed, ws.GoogleProtos()+" not found", "run `alis org get google`"
+	} else {
+		google.Status, google.Detail = doctorOK, ws.GoogleProtos()
+	}
+	results := []doctorResult{google}
+
+	orgs := workspaceOrgs(ws)
+	if len(orgs) == 0 {
+		return append(results, doctorResult{Check: "workspace", Status: doctorWarning,
+			Detail: "no organisations in " + ws.Root, Fix: "run `alis org get {orgID}`"})
+	}
+
+	for _, org := range orgs {
+		repos := []string{ws.ProtoRepo(org), ws.ProtobufGoRepo(org), ws.ProtobufPythonRepo(org), ws.APIGoRepo(org)}
+		for _, product := range localDirs(filepath.Join(ws.Org(org), "products"), "^[a-z]{2}$") {
+			repos = append(repos, ws.ProductRepo(org, product))
+		}
+		for _, repo := range repos {
+			results = append(results, checkRepo(ctx, ws, org, repo))
+		}
+	}
+	return results
+}
+
+// workspaceOrgs returns the IDs of the organisations cloned in ws, other than google.
+func workspaceOrgs(ws workspace) []string {
+	var orgs []string
+	for _, org := range localDirs(ws.Root, "^[a-z][a-z0-9]{2,7}$") {
+		if org != "google" {
+			orgs = append(orgs, org)
+		}
+	}
+	return orgs
+}
+
+// checkRepo checks that a repository of the workspace is cloned, on a branch and without uncommitted changes.
+func checkRepo(ctx context.Context, ws workspace, organisationID string, repoPath string) doctorResult {
+	rel, err := filepath.Rel(ws.Root, repoPath)
+	if err != nil {
+		rel = repoPath
+	}
+	r := doctorResult{Check: rel}
+	if _, err := os.Stat(repoPath); err != nil {
+		r.Status, r.Detail, r.Fix = doctorFailed, "not cloned", "run `alis org get "+organisationID+"`"
+		return r
+	}
+	repo := gitRepo{Path: repoPath}
+	branch, err := repo.CurrentBranch(ctx)
+	if err != nil {
+		r.Status, r.Detail, r.Fix = doctorWarning, err.Error(), "check out the master branch with `git -C "+repoPath+" checkout master`"
+		return r
+	}
+	changes, err := repo.Changes(ctx, true)
+	if err != nil {
+		r.Status, r.Detail = doctorFailed, err.Error()
+		return r
+	}
+	if len(changes) > 0 {
+		r.Status, r.Detail = doctorWarning, fmt.Sprintf("%s, %d uncommitted change(s)", branch, len(changes))
+		r.Fix = "commit or discard the changes, see `git -C " + repoPath + " status`"
+		return r
+	}
+	r.Status, r.Detail = doctorOK, branch
+	return r
+}
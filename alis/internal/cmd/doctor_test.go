@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestDoctor(t *testing.T) {
+	e := newTestEnv(t)
+	oldIDToken := doctorIDToken
+	doctorIDToken = func(ctx context.Context) error { return errors.New("could not find default credentials") }
+	t.Cleanup(func() { doctorIDToken = oldIDToken })
+	t.Setenv("GOPRIVATE", "go.protobuf.alis.alis.exchange,github.com/alis-x/*")
+
+	bin := filepath.Join(e.home, "bin")
+	writeFile(t, filepath.Join(bin, "protoc"), "#!/bin/sh\necho libprotoc 2.6.1\n")
+	writeFile(t, filepath.Join(bin, "protoc-gen-go"), "#!/bin/sh\necho protoc-gen-go v1.28.0\n")
+	for _, name := range []string{"protoc", "protoc-gen-go"} {
+		if err := os.Chmod(filepath.Join(bin, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws := currentWorkspace()
+	writeFile(t, filepath.Join(ws.GoogleProtos(), "google", "api", "annotations.proto"), "syntax = \"proto3\";\n")
+	proto := e.repo("alis/proto", map[string]string{"README.md": "proto\n"})
+	writeFile(t, filepath.Join(proto, "README.md"), "proto, updated\n")
+
+	// failed checks make the command fail, after printing the results.
+	stdout, console, err := e.runErr("", "doctor", "-o", "json")
+	if errorCode(err) != codes.FailedPrecondition {
+		t.Errorf("got %v, want failed checks", err)
+	}
+	var results []doctorResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("%v\n%s\n%s", err, stdout, console)
+	}
+	got := map[string]doctorResult{}
+	for _, r := range results {
+		got[r.Check] = r
+	}
+
+	for check, want := range map[string]struct{ status, detail, fix string }{
+		"protoc":                          {doctorFailed, "libprotoc 2.6.1", "version 3"},
+		"protoc-gen-go":                   {doctorOK, "protoc-gen-go v1.28.0", ""},
+		"protoc-gen-go-grpc":              {doctorFailed, "not found", "go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest"},
+		"application default credentials": {doctorFailed, "could not find default credentials", "gcloud auth application-default login"},
+		"GOPRIVATE": {doctorFailed, "does not cover go.lib.alis.dev, go.lib.alis.alis.exchange, proto.alis.alis.exchange, cli.alis.dev",
+			"go env -w GOPRIVATE=go.protobuf.alis.alis.exchange,github.com/alis-x/*,go.lib.alis.dev,go.lib.alis.alis.exchange,proto.alis.alis.exchange,cli.alis.dev`"},
+		"google protos":    {doctorOK, ws.GoogleProtos(), ""},
+		"alis/proto":       {doctorWarning, "master, 1 uncommitted change(s)", "git -C " + proto + " status"},
+		"alis/protobuf/go": {doctorFailed, "not cloned", "alis org get alis"},
+	} {
+		r := got[check]
+		if r.Status != want.status || !strings.Contains(r.Detail, want.detail) || !strings.Contains(r.Fix, want.fix) {
+			t.Errorf("%s = %+v, want %s with %q and a fix of %q", check, r, want.status, want.detail, want.fix)
+		}
+	}
+}
+
+func TestMatchesGoPrivate(t *testing.T) {
+	for _, tt := range []struct {
+		goPrivate string
+		module    string
+		want      bool
+	}{
+		{"go.protobuf.alis.alis.exchange", "go.protobuf.alis.alis.exchange", true},
+		{"*.alis.exchange", "go.protobuf.alis.alis.exchange", true},
+		{"github.com/alis-x", "github.com/alis-x/cli/alis", true},
+		{"github.com/alis-x/*,go.lib.alis.dev", "github.com/alis-x/cli/alis", true},
+		{"github.com/alis-x/cli/alis/v2", "github.com/alis-x/cli/alis", false},
+		{"github.com/other", "github.com/alis-x/cli/alis", false},
+		{"", "go.lib.alis.dev", false},
+	} {
+		if got := matchesGoPrivate(tt.goPrivate, tt.module); got != tt.want {
+			t.Errorf("matchesGoPrivate(%q, %q) = %v, want %v", tt.goPrivate, tt.module, got, tt.want)
+		}
+	}
+}
+
+func TestSetGoPrivate(t *testing.T) {
+	fake := &fakeRunner{outputs: map[string]string{"go env GOPRIVATE": "example.com/*,go.protobuf.alis.alis.exchange\n"}}
+	withRunner(t, fake)
+
+	if err := setGoPrivate(context.Background(), "alis"); err != nil {
+		t.Fatal(err)
+	}
+	// the patterns already set are kept, and the modules they cover are not repeated.
+	want := newCommand("go", "env", "-w", "GOPRIVATE=example.com/*,go.protobuf.alis.alis.exchange,github.com/alis-x/cli/alis,"+
+		"go.lib.alis.dev,go.lib.alis.alis.exchange,proto.alis.alis.exchange,cli.alis.dev")
+	if len(fake.commands) != 2 || !reflect.DeepEqual(fake.commands[1], want) {
+		t.Errorf("commands = %v, want %v", fake.commands, want)
+	}
+
+	// nothing is written once GOPRIVATE covers all of them.
+	fake.commands = nil
+	fake.outputs["go env GOPRIVATE"] = strings.TrimPrefix(want.Args[2], "GOPRIVATE=")
+	if err := setGoPrivate(context.Background(), "alis"); err != nil || len(fake.commands) != 1 {
+		t.Errorf("commands = %v, %v, want only the read of GOPRIVATE", fake.commands, err)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// dryRunFlag makes the commands which change repositories, local files or alis_ OS resources print what they would
+// change instead.  Everything is still resolved and validated, so the plan reflects what the command would do.
+var dryRunFlag bool
+
+// ptermDryRun prints the steps a command skips in dry-run mode.
+var ptermDryRun = pterm.PrefixPrinter{
+	Prefix: pterm.Prefix{
+		Text:  " DRY RUN ",
+		Style: pterm.NewStyle(pterm.FgLightWhite, pterm.BgMagenta),
+	},
+	MessageStyle: pterm.NewStyle(pterm.FgLightMagenta),
+}
+
+// dryRun prints a step the command would take, were it not for --dry-run.
+func dryRun(format string, a ...interface{}) {
+	ptermDryRun.Println(fmt.Sprintf(format, a...))
+}
+
+// dryRunRequest prints the request of the alis_ OS method the command would call.
+func dryRunRequest(method string, req proto.Message) {
+	text := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Format(req)
+	dryRun("would call %s with:\n%s", method, strings.TrimSpace(text))
+}
+
+// dryRunDescriptor prints the proto files captured by a FileDescriptorSet.
+func dryRunDescriptor(fds *descriptorpb.FileDescriptorSet) {
+	if len(fds.GetFile()) == 0 {
+		dryRun("the descriptor set is empty")
+		return
+	}
+	var files []string
+	for _, f := range fds.GetFile() {
+		files = append(files, "  "+f.GetName()+" ("+f.GetPackage()+")")
+	}
+	dryRun("the descriptor set holds %d file(s):\n%s", len(files), strings.Join(files, "\n"))
+}
+
+// dryRunDone tells the user that nothing was changed.
+func dryRunDone() {
+	ptermTip.Println("This was a dry run, nothing was changed.  Run the command without --dry-run to apply the above.")
+}
+
+// planCommitTagAndPush performs the checks of commitTagAndPush, without changing the repository, and prints what
+// it would do.  It fails the same way commitTagAndPush would, for example with AlreadyExists if the tag is taken.
+// Returns the hash of the commit which would be tagged, which is the current one unless changes are committed.
+func planCommitTagAndPush(ctx context.Context, repoPath string, commitPaths []string, message string, tag string, add bool, commit bool) (string, error) {
+	repo := gitRepo{Path: repoPath}
+	branch, err := repo.CurrentBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := repo.CheckClean(ctx, commitPaths...); err != nil {
+		return "", err
+	}
+	if tag != "" {
+		exists, err := repo.TagExists(ctx, tag)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", status.Errorf(codes.AlreadyExists, "tag %s already exists in %s", tag, repoPath)
+		}
+	}
+
+	dryRun("would pull %s into %s", branch, repoPath)
+	if commit {
+		changes, err := repo.Changes(ctx, add, commitPaths...)
+		if err != nil {
+			return "", err
+		}
+		if len(changes) > 0 {
+			dryRun("would commit %q with the changes to:\n  %s", message, strings.Join(changes, "\n  "))
+		}
+	}
+	if tag != "" {
+		dryRun("would tag %s and push it along with %s to origin", tag, branch)
+	} else {
+		dryRun("would push %s to origin", branch)
+	}
+
+	sha, err := repo.RevParse(ctx, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// dryRunGenerateProtobufs prints what generating, and optionally publishing, the protocol buffers of a neuron would
+// change.  public selects the public protobuf repository.
+func dryRunGenerateProtobufs(organisationID string, productID string, neuronID string, public bool) {
+	ws := currentWorkspace()
+	neuronPath := neuronPath(organisationID, productID, neuronID)
+	protoPath := ws.NeuronProtos(organisationID, productID, neuronID)
+
+	if genprotoGo {
+		goRepo := ws.ProtobufGoRepo(organisationID)
+		if public {
+			goRepo = ws.PublicProtobufGoRepo(organisationID)
+		}
+		if pushProtocolBuffers {
+			dryRun("would discard the uncommitted changes in %s", goRepo)
+		}
+		dryRun("would replace the contents of %s/%s with the Go protocol buffers of %s", goRepo, neuronPath, protoPath)
+		dryRun("would regenerate %s/descriptor.pb", ws.ProductProtos(organisationID, productID))
+		if pushProtocolBuffers {
+			dryRun("would commit %s/%s and push it to origin", goRepo, neuronPath)
+		}
+	}
+	if genprotoPython {
+		pythonRepo := ws.ProtobufPythonRepo(organisationID)
+		dryRun("would replace the contents of %s/%s with the Python protocol buffers of %s", pythonRepo, neuronPath, protoPath)
+		if pushProtocolBuffers {
+			dryRun("would bump the version in %s/setup.py, publish the package and push %s/%s to origin", pythonRepo, pythonRepo, neuronPath)
+		}
+	}
+}
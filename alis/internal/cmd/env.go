@@ -0,0 +1,579 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var envImportReplaceFlag bool
+
+// neuronEnvCmd represents the neuron env command
+var neuronEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: pterm.Blue("Manages the environment variables of a neuron"),
+	Long: pterm.Green(
+		`Use this command to list, set or unset environment variables one at a time, or to import
+and export them as a .env file, without walking through each of them.
+
+The commands operate on the default environment variables of the neuron, which new neuron
+deployments start from.  Use --deployments to operate on the neuron deployments instead.
+Only variables prefixed with ALIS_OS_ are accepted.`),
+}
+
+// listNeuronEnvCmd represents the neuron env list command
+var listNeuronEnvCmd = &cobra.Command{
+	Use:               "list",
+	Short:             pterm.Blue("Lists the environment variables of a neuron"),
+	Example:           pterm.LightYellow("alis neuron env list {orgID}.{productID}.{neuronID}\nalis neuron env list alis.in.resources-events-v1 --deployments dev,prod"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := neuronEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return listEnvs(cmd, targets)
+	},
+}
+
+// setNeuronEnvCmd represents the neuron env set command
+var setNeuronEnvCmd = &cobra.Command{
+	Use:               "set",
+	Short:             pterm.Blue("Sets one or more environment variables of a neuron"),
+	Example:           pterm.LightYellow("alis neuron env set {orgID}.{productID}.{neuronID} ALIS_OS_NAME=VALUE...\nalis neuron env set alis.in.resources-events-v1 ALIS_OS_LOG_LEVEL=debug --deployments dev"),
+	Args:              cobra.MatchAll(cobra.MinimumNArgs(2), validateNeuronArg),
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := parseEnvAssignments(args[1:], "environment variable")
+		if err != nil {
+			return err
+		}
+		targets, err := neuronEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return updateEnvs(cmd, targets, func(envs [][2]string) [][2]string {
+			return mergeEnvs(envs, set, nil)
+		})
+	},
+}
+
+// unsetNeuronEnvCmd represents the neuron env unset command
+var unsetNeuronEnvCmd = &cobra.Command{
+	Use:               "unset",
+	Short:             pterm.Blue("Removes one or more environment variables of a neuron"),
+	Example:           pterm.LightYellow("alis neuron env unset {orgID}.{productID}.{neuronID} ALIS_OS_NAME...\nalis neuron env unset alis.in.resources-events-v1 ALIS_OS_LOG_LEVEL"),
+	Args:              cobra.MatchAll(cobra.MinimumNArgs(2), validateNeuronArg),
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := neuronEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return updateEnvs(cmd, targets, func(envs [][2]string) [][2]string {
+			return mergeEnvs(envs, nil, args[1:])
+		})
+	},
+}
+
+// importNeuronEnvCmd represents the neuron env import command
+var importNeuronEnvCmd = &cobra.Command{
+	Use:   "import",
+	Short: pterm.Blue("Sets the environment variables of a neuron from a .env file"),
+	Long: pterm.Green(
+		`This method reads NAME=VALUE lines from a .env file, such as the local.env file of a product
+repository, and sets the variables prefixed with ALIS_OS_.  Other variables, comments and
+blank lines are skipped.  Variables which are not in the file are kept, unless --replace is set.`),
+	Example:           pterm.LightYellow("alis neuron env import {orgID}.{productID}.{neuronID} {file}\nalis neuron env import alis.in.resources-events-v1 local.env --deployments dev"),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), validateNeuronArg),
+	ValidArgsFunction: completeEnvFileArg(completeNeuronArg),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := readEnvFile(args[1])
+		if err != nil {
+			return err
+		}
+		targets, err := neuronEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return updateEnvs(cmd, targets, importEnvs(set))
+	},
+}
+
+// exportNeuronEnvCmd represents the neuron env export command
+var exportNeuronEnvCmd = &cobra.Command{
+	Use:               "export",
+	Short:             pterm.Blue("Prints the environment variables of a neuron as a .env file"),
+	Example:           pterm.LightYellow("alis neuron env export {orgID}.{productID}.{neuronID} > neuron.env\nalis neuron env export alis.in.resources-events-v1 --deployments prod"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	PreRun:            exportEnvPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := neuronEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return exportEnvs(cmd, targets)
+	},
+}
+
+// productEnvCmd represents the product env command
+var productEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: pterm.Blue("Manages the environment variables of product deployments"),
+	Long: pterm.Green(
+		`Use this command to list, set or unset environment variables of one or more product
+deployments one at a time, or to import and export them as a .env file, without walking
+through each of them.  Only variables prefixed with ALIS_OS_ are accepted.`),
+}
+
+// listProductEnvCmd represents the product env list command
+var listProductEnvCmd = &cobra.Command{
+	Use:               "list",
+	Short:             pterm.Blue("Lists the environment variables of product deployments"),
+	Example:           pterm.LightYellow("alis product env list {orgID}.{productID}\nalis product env list alis.in --deployments dev,prod"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := productEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return listEnvs(cmd, targets)
+	},
+}
+
+// setProductEnvCmd represents the product env set command
+var setProductEnvCmd = &cobra.Command{
+	Use:               "set",
+	Short:             pterm.Blue("Sets one or more environment variables of product deployments"),
+	Example:           pterm.LightYellow("alis product env set {orgID}.{productID} ALIS_OS_NAME=VALUE...\nalis product env set alis.in ALIS_OS_LOG_LEVEL=debug --deployments dev"),
+	Args:              cobra.MatchAll(cobra.MinimumNArgs(2), validateProductArg),
+	ValidArgsFunction: completeProductArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := parseEnvAssignments(args[1:], "environment variable")
+		if err != nil {
+			return err
+		}
+		targets, err := productEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return updateEnvs(cmd, targets, func(envs [][2]string) [][2]string {
+			return mergeEnvs(envs, set, nil)
+		})
+	},
+}
+
+// unsetProductEnvCmd represents the product env unset command
+var unsetProductEnvCmd = &cobra.Command{
+	Use:               "unset",
+	Short:             pterm.Blue("Removes one or more environment variables of product deployments"),
+	Example:           pterm.LightYellow("alis product env unset {orgID}.{productID} ALIS_OS_NAME...\nalis product env unset alis.in ALIS_OS_LOG_LEVEL --deployments dev"),
+	Args:              cobra.MatchAll(cobra.MinimumNArgs(2), validateProductArg),
+	ValidArgsFunction: completeProductArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := productEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return updateEnvs(cmd, targets, func(envs [][2]string) [][2]string {
+			return mergeEnvs(envs, nil, args[1:])
+		})
+	},
+}
+
+// importProductEnvCmd represents the product env import command
+var importProductEnvCmd = &cobra.Command{
+	Use:   "import",
+	Short: pterm.Blue("Sets the environment variables of product deployments from a .env file"),
+	Long: pterm.Green(
+		`This method reads NAME=VALUE lines from a .env file, such as the local.env file of a product
+repository, and sets the variables prefixed with ALIS_OS_.  Other variables, comments and
+blank lines are skipped.  Variables which are not in the file are kept, unless --replace is set.`),
+	Example:           pterm.LightYellow("alis product env import {orgID}.{productID} {file}\nalis product env import alis.in local.env --deployments dev"),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), validateProductArg),
+	ValidArgsFunction: completeEnvFileArg(completeProductArg),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := readEnvFile(args[1])
+		if err != nil {
+			return err
+		}
+		targets, err := productEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return updateEnvs(cmd, targets, importEnvs(set))
+	},
+}
+
+// exportProductEnvCmd represents the product env export command
+var exportProductEnvCmd = &cobra.Command{
+	Use:               "export",
+	Short:             pterm.Blue("Prints the environment variables of a product deployment as a .env file"),
+	Example:           pterm.LightYellow("alis product env export {orgID}.{productID} > product.env\nalis product env export alis.in --deployments prod"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	PreRun:            exportEnvPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := productEnvTargets(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return exportEnvs(cmd, targets)
+	},
+}
+
+func init() {
+	neuronCmd.AddCommand(neuronEnvCmd)
+	neuronEnvCmd.AddCommand(listNeuronEnvCmd, setNeuronEnvCmd, unsetNeuronEnvCmd, importNeuronEnvCmd, exportNeuronEnvCmd)
+	productCmd.AddCommand(productEnvCmd)
+	productEnvCmd.AddCommand(listProductEnvCmd, setProductEnvCmd, unsetProductEnvCmd, importProductEnvCmd, exportProductEnvCmd)
+
+	// set, unset and import take further arguments, so only list and export may leave out the neuron or product.
+	argFromWorkingDir(listNeuronEnvCmd, neuronFromDir)
+	argFromWorkingDir(exportNeuronEnvCmd, neuronFromDir)
+	argFromWorkingDir(listProductEnvCmd, productFromDir)
+	argFromWorkingDir(exportProductEnvCmd, productFromDir)
+
+	for _, c := range append(neuronEnvCmd.Commands(), productEnvCmd.Commands()...) {
+		addDeploymentAnswerFlags(c)
+	}
+	importNeuronEnvCmd.Flags().BoolVar(&envImportReplaceFlag, "replace", false, pterm.Green("Remove the variables which are not in the file"))
+	importProductEnvCmd.Flags().BoolVar(&envImportReplaceFlag, "replace", false, pterm.Green("Remove the variables which are not in the file"))
+}
+
+// envTarget is a resource whose environment variables are managed with the env commands.
+type envTarget struct {
+	// label identifies the resource in the output, for example the Google project of a deployment.
+	label string
+	envs  [][2]string
+	// request returns the request which replaces the environment variables of the resource with envs.
+	request func(envs [][2]string) proto.Message
+}
+
+// neuronEnvTargets returns the default environment variables of the neuron {orgID}.{productID}.{neuronID}, or those
+// of its deployments when --deployments is set.
+func neuronEnvTargets(cmd *cobra.Command, arg string) ([]envTarget, error) {
+	organisationID = strings.Split(arg, ".")[0]
+	productID = strings.Split(arg, ".")[1]
+	neuronID = strings.Split(arg, ".")[2]
+
+	clients, err := clientsFromContext(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	neuron, err := clients.Products.GetNeuron(cmd.Context(), &pbProducts.GetNeuronRequest{
+		Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
+	if err != nil {
+		return nil, err
+	}
+	pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
+
+	if !cmd.Flags().Changed("deployments") {
+		return []envTarget{{
+			label: neuronID,
+			envs:  neuronEnvPairs(neuron.GetEnvs()),
+			request: func(envs [][2]string) proto.Message {
+				return &pbProducts.UpdateNeuronRequest{
+					Neuron:     &pbProducts.Neuron{Name: neuron.GetName(), Envs: neuronEnvs(envs)},
+					UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"envs"}},
+				}
+			},
+		}}, nil
+	}
+
+	productDeployments, err := selectProductDeployments(cmd.Context(), "organisations/"+organisationID+"/products/"+productID)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, productDeployment := range productDeployments {
+		names = append(names, productDeployment.GetName()+"/neurons/"+neuronID)
+	}
+	neuronDeployments, err := getNeuronDeployments(cmd.Context(), clients, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []envTarget
+	for i, neuronDeployment := range neuronDeployments {
+		if neuronDeployment.GetName() == "" {
+			pterm.Warning.Printf("This neuron has not yet been deployed to %s (%s), skipping it\n",
+				productDeployments[i].GetDisplayName(), productDeployments[i].GetGoogleProjectId())
+			continue
+		}
+		name := neuronDeployment.GetName()
+		targets = append(targets, envTarget{
+			label: productDeployments[i].GetGoogleProjectId(),
+			envs:  neuronEnvPairs(neuronDeployment.GetEnvs()),
+			request: func(envs [][2]string) proto.Message {
+				return &pbProducts.UpdateNeuronDeploymentRequest{
+					NeuronDeployment: &pbProducts.NeuronDeployment{Name: name, Envs: neuronEnvs(envs)},
+					UpdateMask:       &fieldmaskpb.FieldMask{Paths: []string{"envs"}},
+				}
+			},
+		})
+	}
+	if len(targets) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s is not deployed to any of the selected deployments", neuron.GetName())
+	}
+	return targets, nil
+}
+
+// productEnvTargets returns the environment variables of the selected deployments of the product {orgID}.{productID}.
+func productEnvTargets(cmd *cobra.Command, arg string) ([]envTarget, error) {
+	organisationID = strings.Split(arg, ".")[0]
+	productID = strings.Split(arg, ".")[1]
+
+	productDeployments, err := selectProductDeployments(cmd.Context(), "organisations/"+organisationID+"/products/"+productID)
+	if err != nil {
+		return nil, err
+	}
+	var targets []envTarget
+	for _, productDeployment := range productDeployments {
+		name := productDeployment.GetName()
+		var pairs [][2]string
+		for _, env := range productDeployment.GetEnvs() {
+			pairs = append(pairs, [2]string{env.GetName(), env.GetValue()})
+		}
+		targets = append(targets, envTarget{
+			label: productDeployment.GetGoogleProjectId(),
+			envs:  pairs,
+			request: func(envs [][2]string) proto.Message {
+				var productEnvs []*pbProducts.Product_Env
+				for _, env := range envs {
+					productEnvs = append(productEnvs, &pbProducts.Product_Env{Name: env[0], Value: env[1]})
+				}
+				return &pbProducts.UpdateProductDeploymentRequest{
+					ProductDeployment: &pbProducts.ProductDeployment{Name: name, Envs: productEnvs},
+					UpdateMask:        &fieldmaskpb.FieldMask{Paths: []string{"envs"}},
+				}
+			},
+		})
+	}
+	return targets, nil
+}
+
+func neuronEnvPairs(envs []*pbProducts.Neuron_Env) [][2]string {
+	var res [][2]string
+	for _, env := range envs {
+		res = append(res, [2]string{env.GetName(), env.GetValue()})
+	}
+	return res
+}
+
+func neuronEnvs(pairs [][2]string) []*pbProducts.Neuron_Env {
+	var res []*pbProducts.Neuron_Env
+	for _, pair := range pairs {
+		res = append(res, &pbProducts.Neuron_Env{Name: pair[0], Value: pair[1]})
+	}
+	return res
+}
+
+// listEnvs prints the environment variables of targets, with a column for each of them.
+func listEnvs(cmd *cobra.Command, targets []envTarget) error {
+	if machineOutput() {
+		out := []interface{}{}
+		for _, target := range targets {
+			envs := map[string]string{}
+			for _, env := range target.envs {
+				envs[env[0]] = env[1]
+			}
+			out = append(out, map[string]interface{}{"name": target.label, "envs": envs})
+		}
+		return printResources(cmd, out)
+	}
+
+	header := []string{"Env"}
+	values := map[string][]string{}
+	for i, target := range targets {
+		header = append(header, target.label)
+		for _, env := range target.envs {
+			if _, ok := values[env[0]]; !ok {
+				values[env[0]] = make([]string, len(targets))
+			}
+			values[env[0]][i] = env[1]
+		}
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := pterm.TableData{header}
+	for _, name := range names {
+		table = append(table, append([]string{name}, values[name]...))
+	}
+	return renderTable(cmd, table)
+}
+
+// updateEnvs applies edit to the environment variables of each target, and updates those which changed.
+func updateEnvs(cmd *cobra.Command, targets []envTarget, edit func(envs [][2]string) [][2]string) error {
+	clients, err := clientsFromContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		envs := edit(target.envs)
+		if equalEnvPairs(target.envs, envs) {
+			pterm.Info.Printf("The environment variables of %s are unchanged\n", target.label)
+			continue
+		}
+
+		req := target.request(envs)
+		method := strings.TrimSuffix(string(req.ProtoReflect().Descriptor().Name()), "Request")
+		if dryRunFlag {
+			dryRunRequest(method, req)
+			continue
+		}
+		pterm.Info.Printf("Updating the environment variables of %s...\n", target.label)
+		var op *longrunning.Operation
+		switch req := req.(type) {
+		case *pbProducts.UpdateNeuronRequest:
+			op, err = clients.Products.UpdateNeuron(cmd.Context(), req)
+		case *pbProducts.UpdateNeuronDeploymentRequest:
+			op, err = clients.Products.UpdateNeuronDeployment(cmd.Context(), req)
+		case *pbProducts.UpdateProductDeploymentRequest:
+			op, err = clients.Products.UpdateProductDeployment(cmd.Context(), req)
+		default:
+			return status.Errorf(codes.Internal, "unexpected request %s", method)
+		}
+		if err != nil {
+			return err
+		}
+
+		// check if we need to wait for operation to complete.
+		if asyncFlag {
+			pterm.Debug.Printf("GetOperation:\n%s\n", op)
+			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
+		} else {
+			// wait for the long-running operation to complete.
+			err := wait(cmd.Context(), op, "Updating "+target.label, "Updated "+target.label, 300, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if dryRunFlag {
+		dryRunDone()
+	}
+	return nil
+}
+
+// equalEnvPairs reports whether a and b hold the same environment variables, in any order.
+func equalEnvPairs(a [][2]string, b [][2]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := map[string]string{}
+	for _, env := range a {
+		values[env[0]] = env[1]
+	}
+	for _, env := range b {
+		if value, ok := values[env[0]]; !ok || value != env[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// importEnvs returns the edit which sets the variables of a .env file, replacing all others with --replace.
+func importEnvs(set [][2]string) func(envs [][2]string) [][2]string {
+	return func(envs [][2]string) [][2]string {
+		if envImportReplaceFlag {
+			envs = nil
+		}
+		return mergeEnvs(envs, set, nil)
+	}
+}
+
+// readEnvFile reads the ALIS_OS_ prefixed variables of a .env file, for example:
+//
+//	# comments and blank lines are skipped
+//	export ALIS_OS_LOG_LEVEL=debug
+//	ALIS_OS_PROJECT="alis-in-dev-abc"
+//	GOOGLE_APPLICATION_CREDENTIALS=../key.json
+//
+// Variables without the ALIS_OS_ prefix, such as GOOGLE_APPLICATION_CREDENTIALS above, are only used locally and
+// are skipped with a warning.
+func readEnvFile(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var assignments []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, status.Errorf(codes.InvalidArgument, "%s:%d: expected NAME=VALUE", path, n)
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if !strings.HasPrefix(name, "ALIS_OS_") {
+			pterm.Warning.Printf("%s:%d: skipping %s, only variables prefixed with ALIS_OS_ are deployed\n", path, n, name)
+			continue
+		}
+		assignments = append(assignments, name+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return parseEnvAssignments(assignments, "variable in "+path)
+}
+
+// exportEnvPreRun sends everything but the .env file to stderr, such that the output of export may be redirected to
+// a file.
+func exportEnvPreRun(cmd *cobra.Command, args []string) {
+	pterm.SetDefaultOutput(cmd.ErrOrStderr())
+}
+
+// exportEnvs prints the environment variables of a single target as a .env file.
+func exportEnvs(cmd *cobra.Command, targets []envTarget) error {
+	if len(targets) != 1 {
+		return status.Errorf(codes.InvalidArgument, "%d deployments are selected, select a single one to export", len(targets))
+	}
+	for _, env := range targets[0].envs {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", env[0], env[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeEnvFileArg completes the resource argument with complete, and the .env file after it.
+func completeEnvFileArg(complete func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return complete(cmd, args, toComplete)
+		}
+		if len(args) == 1 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
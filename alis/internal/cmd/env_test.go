@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+)
+
+func TestNeuronEnv(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", GoogleProjectId: "in-dev-abc", Environment: pbProducts.ProductDeployment_DEV})
+	deployment := "organisations/alis/products/in/deployments/in-dev-abc/neurons/resources-events-v1"
+	e.backend.AddNeuronDeployment(&pbProducts.NeuronDeployment{Name: deployment, Version: "1.1.0",
+		Envs: []*pbProducts.Neuron_Env{{Name: "ALIS_OS_LOG_LEVEL", Value: "info"}}})
+	envs := func(pairs []*pbProducts.Neuron_Env) string {
+		var res []string
+		for _, env := range pairs {
+			res = append(res, env.GetName()+"="+env.GetValue())
+		}
+		return strings.Join(res, ",")
+	}
+
+	// the defaults of the neuron, without --deployments.
+	e.run("", "neuron", "env", "set", "alis.in.resources-events-v1", "ALIS_OS_A=1", "ALIS_OS_B=2")
+	e.run("", "neuron", "env", "unset", "alis.in.resources-events-v1", "ALIS_OS_A")
+	if got := envs(e.backend.Neuron(neuron.GetName()).GetEnvs()); got != "ALIS_OS_B=2" {
+		t.Errorf("neuron envs = %s, want ALIS_OS_B=2", got)
+	}
+	stdout, _ := e.run("", "neuron", "env", "list", "alis.in.resources-events-v1", "-o", "json")
+	if !strings.Contains(stdout, `"ALIS_OS_B": "2"`) {
+		t.Errorf("list does not show ALIS_OS_B:\n%s", stdout)
+	}
+
+	_, console, err := e.runErr("", "neuron", "env", "set", "alis.in.resources-events-v1", "LOG_LEVEL=debug")
+	if exitCode(err) != exitInvalidArgument || !strings.Contains(console, "expected ALIS_OS_NAME=VALUE") {
+		t.Errorf("a variable without the ALIS_OS_ prefix is not rejected:\n%s", console)
+	}
+
+	// a .env file, like the local.env of a product repository, is imported into the deployment.
+	file := filepath.Join(e.home, "dev.env")
+	writeFile(t, file, "# local development\nALIS_OS_PROJECT=\"alis-in-dev-abc\"\nGOOGLE_APPLICATION_CREDENTIALS=../key.json\n\nexport ALIS_OS_LOG_LEVEL=debug\n")
+	_, console = e.run("", "neuron", "env", "import", "alis.in.resources-events-v1", file, "--deployments", "dev")
+	if got := envs(e.backend.NeuronDeployment(deployment).GetEnvs()); got != "ALIS_OS_LOG_LEVEL=debug,ALIS_OS_PROJECT=alis-in-dev-abc" {
+		t.Errorf("deployment envs = %s\n%s", got, console)
+	}
+	if !strings.Contains(console, "skipping GOOGLE_APPLICATION_CREDENTIALS") {
+		t.Errorf("skipped variables are not reported:\n%s", console)
+	}
+
+	writeFile(t, file, "ALIS_OS_PROJECT=alis-in-dev-abc\n")
+	e.run("", "neuron", "env", "import", "alis.in.resources-events-v1", file, "--deployments", "dev", "--replace")
+	stdout, _ = e.run("", "neuron", "env", "export", "alis.in.resources-events-v1", "--deployments", "dev")
+	if stdout != "ALIS_OS_PROJECT=alis-in-dev-abc\n" {
+		t.Errorf("export = %q, want ALIS_OS_PROJECT only", stdout)
+	}
+
+	// deployments without the neuron are skipped, also when the batch get fails on them.
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-test-ghi", GoogleProjectId: "in-test-ghi"})
+	e.backend.StrictBatchGet = true
+	_, console = e.run("", "neuron", "env", "set", "alis.in.resources-events-v1", "ALIS_OS_C=3", "--deployments", "in-dev-abc,in-test-ghi")
+	if got := envs(e.backend.NeuronDeployment(deployment).GetEnvs()); got != "ALIS_OS_PROJECT=alis-in-dev-abc,ALIS_OS_C=3" {
+		t.Errorf("deployment envs = %s\n%s", got, console)
+	}
+	if !strings.Contains(console, "not yet been deployed to") {
+		t.Errorf("the deployment without the neuron is not skipped:\n%s", console)
+	}
+}
+
+func TestProductEnv(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	name := "organisations/alis/products/in/deployments/in-dev-abc"
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{Name: name, GoogleProjectId: "in-dev-abc",
+		Envs: []*pbProducts.Product_Env{{Name: "ALIS_OS_KEEP", Value: "a"}, {Name: "ALIS_OS_DROP", Value: "b"}}})
+
+	e.run("", "product", "env", "set", "alis.in", "ALIS_OS_NEW=c", "--deployments", "in-dev-abc")
+	e.run("0\n", "product", "env", "unset", "alis.in", "ALIS_OS_DROP")
+	stdout, _ := e.run("", "product", "env", "export", "alis.in", "--deployments", "0")
+	if stdout != "ALIS_OS_KEEP=a\nALIS_OS_NEW=c\n" {
+		t.Errorf("export = %q", stdout)
+	}
+
+	_, console := e.run("", "product", "env", "set", "alis.in", "ALIS_OS_NEW=c", "--deployments", "in-dev-abc", "--dry-run")
+	if !strings.Contains(console, "unchanged") {
+		t.Errorf("setting the same value updates the deployment:\n%s", console)
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The exit codes of the CLI, such that scripts can tell failures apart.  Errors carrying a grpc status code, as
+// returned by the alis_ OS services and most of the CLI itself, map onto these by their code.
+const (
+	// exitFailure is the exit code of any failure without a more specific one.
+	exitFailure = 1
+	// exitInvalidArgument is the exit code of invalid arguments, flags or answers.
+	exitInvalidArgument = 2
+	// exitNotFound is the exit code when a resource, file or version does not exist.
+	exitNotFound = 3
+	// exitOperationFailed is the exit code when a long-running operation completes with an error.
+	exitOperationFailed = 4
+	// exitAborted is the exit code when the user declines to continue, or interrupts the CLI.
+	exitAborted = 5
+	// exitToolMissing is the exit code when a program the CLI depends on, such as git or protoc, is not installed.
+	exitToolMissing = 6
+)
+
+// abortedError is returned when the user declines to continue.
+type abortedError struct {
+	Reason string
+}
+
+func (e *abortedError) Error() string { return e.Reason }
+
+// aborted returns the error of the user declining to continue, for example "did not remove foo".
+func aborted(format string, a ...interface{}) error {
+	return &abortedError{Reason: fmt.Sprintf(format, a...)}
+}
+
+// operationError is returned when a long-running operation completes with an error.
+type operationError struct {
+	Name string
+	Err  error
+}
+
+func (e *operationError) Error() string {
+	return fmt.Sprintf("operation %s failed: %s", e.Name, e.Err)
+}
+
+func (e *operationError) Unwrap() error { return e.Err }
+
+// errorCode returns the grpc status code of err, or of the first error it wraps which has one.
+func errorCode(err error) codes.Code {
+	var s interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &s) {
+		return s.GRPCStatus().Code()
+	}
+	return codes.Unknown
+}
+
+// exitCode returns the exit code of the CLI for err, which is not nil.
+func exitCode(err error) int {
+	var abortedErr *abortedError
+	var operationErr *operationError
+	switch {
+	case errors.As(err, &abortedErr), errors.Is(err, context.Canceled):
+		return exitAborted
+	case errors.Is(err, exec.ErrNotFound):
+		return exitToolMissing
+	case errors.As(err, &operationErr):
+		return exitOperationFailed
+	case errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	}
+	switch errorCode(err) {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return exitInvalidArgument
+	case codes.NotFound:
+		return exitNotFound
+	case codes.Canceled:
+		return exitAborted
+	}
+	// cobra reports unknown commands of the root command before any validator of ours runs.
+	if strings.HasPrefix(err.Error(), "unknown command ") {
+		return exitInvalidArgument
+	}
+	return exitFailure
+}
+
+// renderError prints err, which a command returned, along with a hint of how to resolve it where there is one.
+func renderError(err error) {
+	var abortedErr *abortedError
+	if errors.As(err, &abortedErr) {
+		pterm.Warning.Println(err)
+		return
+	}
+	// the code is conveyed by the exit code, so only the message of a status is shown.
+	if s, ok := status.FromError(err); ok {
+		pterm.Error.Println(s.Message())
+	} else {
+		pterm.Error.Println(err)
+	}
+	switch exitCode(err) {
+	case exitToolMissing:
+		ptermTip.Println("Run `alis doctor` to check the tools the CLI depends on.")
+	case exitInvalidArgument:
+		if strings.HasPrefix(err.Error(), "unknown command ") {
+			ptermTip.Println("Run `alis -h` for the available commands.")
+		}
+	}
+}
+
+// usageError marks err, such as those returned by cobra when parsing the arguments or flags of a command, as an
+// invalid argument unless it carries a grpc status code already.
+func usageError(err error) error {
+	if err == nil || errorCode(err) != codes.Unknown {
+		return err
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+var usageErrorsOnce sync.Once
+
+// usageErrors marks the errors of the Args validators of all commands, including those of cobra such as
+// cobra.ExactArgs, as invalid arguments.  It runs once all commands have been added to the root command.
+func usageErrors() {
+	usageErrorsOnce.Do(func() {
+		var wrap func(cmd *cobra.Command)
+		wrap = func(cmd *cobra.Command) {
+			if validate := cmd.Args; validate != nil {
+				cmd.Args = func(cmd *cobra.Command, args []string) error {
+					return usageError(validate(cmd, args))
+				}
+			}
+			for _, c := range cmd.Commands() {
+				wrap(c)
+			}
+		}
+		wrap(rootCmd)
+	})
+}
+
+// missingCommand is the error of running a command, such as `alis neuron`, which only groups sub commands.
+func missingCommand(cmd *cobra.Command) error {
+	return status.Errorf(codes.InvalidArgument, "a valid command is missing\nplease run '%s -h' for details.", cmd.CommandPath())
+}
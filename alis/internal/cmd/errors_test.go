@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExitCode(t *testing.T) {
+	_, statErr := os.Stat("/does/not/exist")
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("boom"), exitFailure},
+		{status.Error(codes.FailedPrecondition, "version 1.1.0 is FAILED"), exitFailure},
+		{status.Error(codes.InvalidArgument, "invalid version"), exitInvalidArgument},
+		{fmt.Errorf("unknown command \"nope\" for \"alis\""), exitInvalidArgument},
+		{status.Error(codes.NotFound, "neuron not found"), exitNotFound},
+		{statErr, exitNotFound},
+		{&operationError{Name: "operations/1", Err: status.Error(codes.Internal, "build failed")}, exitOperationFailed},
+		{fmt.Errorf("deploy: %w", aborted("did not deploy")), exitAborted},
+		{context.Canceled, exitAborted},
+		{&commandError{Command: "protoc", Err: exec.ErrNotFound}, exitToolMissing},
+	} {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestCommandErrorExitCode(t *testing.T) {
+	e := newTestEnv(t)
+
+	_, console, err := e.runErr("", "neuron", "get", "alis.in.Events")
+	if got := exitCode(err); got != exitInvalidArgument {
+		t.Errorf("exit code of an invalid neuron = %d, want %d\n%s", got, exitInvalidArgument, console)
+	}
+
+	_, console, err = e.runErr("", "neuron", "get", "alis.in.resources-events-v1")
+	if got := exitCode(err); got != exitNotFound {
+		t.Errorf("exit code of a missing neuron = %d, want %d\n%s", got, exitNotFound, console)
+	}
+
+	_, console, err = e.runErr("", "operation")
+	if got := exitCode(err); got != exitInvalidArgument {
+		t.Errorf("exit code of a missing command = %d, want %d\n%s", got, exitInvalidArgument, console)
+	}
+}
@@ -1,20 +1,18 @@
 package cmd
 
 import (
-	"bytes"
-	"context"
 	"fmt"
 	"io/ioutil"
-	"os/exec"
-	"regexp"
-	"strconv"
+	"os"
+	"path/filepath"
 	"strings"
-	"text/template"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
 	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
@@ -23,8 +21,8 @@ var genCmd = &cobra.Command{
 	Use:   "gen",
 	Short: pterm.Blue("Generates code"),
 	Long:  pterm.Green(`Use this command to generate code.`),
-	Run: func(cmd *cobra.Command, args []string) {
-		pterm.Error.Println("a valid command is missing\nplease run 'alis gen -h' for details.")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
 	},
 }
 
@@ -46,34 +44,44 @@ official protobufs.'
 Once local development is done, use the '--push' flag to push the generated protocol buffers to the go.protobuf repository.
 Once the protobufs are pushed, you should remove the 'Replace... ' command in your go.mod file and run 'go mod tidy' to pull
 the latest protobufs from the repo into your gRPC service.`),
-	Example: pterm.LightYellow("alis gen protobuf {orgID}.{productID}.{neuronID}"),
-	Args:    validateNeuronArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis gen protobuf {orgID}.{productID}.{neuronID}"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 		neuronID = strings.Split(args[0], ".")[2]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("Get Organisation:\n%s\n", organisation)
 
 		// Retrieve the neuron resource
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
 			&pbProducts.GetNeuronRequest{
 				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("Get Neuron:\n%s\n", neuron)
 
+		if dryRunFlag {
+			dryRunGenerateProtobufs(organisationID, productID, neuronID, pushPublicProtocolBuffers)
+			dryRunDone()
+			return nil
+		}
+
 		// Generate the protocol buffers for Golang
 		if genprotoGo {
 
@@ -82,90 +90,93 @@ the latest protobufs from the repo into your gRPC service.`),
 				neuronProtoFullPath    string
 				protobufGoRepoPath     string
 
-				cmds string
+				stderr string
 			)
 
 			// set required path variables
 			if pushPublicProtocolBuffers {
-				neuronProtobufFullPath = homeDir + "/alis.exchange/" + organisationID + "/public/protobuf/go/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-				neuronProtoFullPath = homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-				protobufGoRepoPath = homeDir + "/alis.exchange/" + organisationID + "/public/protobuf/go"
+				neuronProtobufFullPath = filepath.Join(currentWorkspace().PublicProtobufGoRepo(organisationID), neuronPath(organisationID, productID, neuronID))
+				neuronProtoFullPath = currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+				protobufGoRepoPath = currentWorkspace().PublicProtobufGoRepo(organisationID)
 				relativeProtoPath := organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
 
 				if pushProtocolBuffers {
-					err := clearUncommittedRepoChanges(protobufGoRepoPath)
+					err := clearUncommittedRepoChanges(cmd.Context(), protobufGoRepoPath)
 					if err != nil {
-						pterm.Error.Println(err)
-						return
+						return err
 					}
 				}
 
-				cmds = "rm -rf " + neuronProtobufFullPath + " && " +
-					"mkdir -p " + neuronProtobufFullPath
-				pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-				out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
-				if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-					pterm.Warning.Print(fmt.Sprintf("removing existing local directory content...\n%s", out))
+				err := resetDir(neuronProtobufFullPath)
+				if err != nil {
+					return err
 				}
 				pterm.Debug.Printf("Cleared the local files in directory: %s\n", neuronProtobufFullPath)
 
-				var relativeProtoPaths string
+				var relativeProtoPaths []string
 				files, err := ioutil.ReadDir(neuronProtoFullPath)
 				for _, f := range files {
 					if strings.HasSuffix(f.Name(), ".proto") {
-						relativeProtoPaths += relativeProtoPath + "/" + f.Name() + " "
+						relativeProtoPaths = append(relativeProtoPaths, relativeProtoPath+"/"+f.Name())
 					}
 				}
 				pterm.Debug.Printf("Relative protopaths: %s\n", relativeProtoPaths)
 
 				descriptorPath, err := generatePublicLocalDescriptorFileFromNeuron(cmd.Context(), neuron.GetName(), neuronProtobufFullPath)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
-				pterm.Debug.Println("Successfully created public scoped descriptor.pb. Destination: %s", *descriptorPath)
+				pterm.Debug.Printf("Successfully created public scoped descriptor.pb. Destination: %s\n", *descriptorPath)
 
 				// Use the public scoped descriptor.pb to generate the Go files
-				cmds = "go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev && " +
-					"protoc --go_out=" + protobufGoRepoPath + " --go_opt=paths=source_relative --go-grpc_out=" + protobufGoRepoPath + " --go-grpc_opt=paths=source_relative -I=$HOME/alis.exchange/google/proto --descriptor_set_in=" + *descriptorPath + " " + relativeProtoPaths + " && " +
-					"rm -f " + *descriptorPath // remove the public scoped descriptor.pb file
-
+				err = setGoPrivate(cmd.Context(), organisationID)
+				if err != nil {
+					return err
+				}
+				protocArgs := []string{"--go_out=" + protobufGoRepoPath, "--go_opt=paths=source_relative",
+					"--go-grpc_out=" + protobufGoRepoPath, "--go-grpc_opt=paths=source_relative",
+					"-I=" + currentWorkspace().GoogleProtos(), "--descriptor_set_in=" + *descriptorPath}
+				_, stderr, err = run(cmd.Context(), newCommand("protoc", append(protocArgs, relativeProtoPaths...)...))
+				// remove the public scoped descriptor.pb file
+				if removeErr := os.Remove(*descriptorPath); err == nil {
+					err = removeErr
+				}
+				if err != nil {
+					return err
+				}
 			} else {
-				neuronProtobufFullPath = homeDir + "/alis.exchange/" + organisationID + "/protobuf/go/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-				neuronProtoFullPath = homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-				protobufGoRepoPath = homeDir + "/alis.exchange/" + organisationID + "/protobuf/go"
+				neuronProtobufFullPath = filepath.Join(currentWorkspace().ProtobufGoRepo(organisationID), neuronPath(organisationID, productID, neuronID))
+				neuronProtoFullPath = currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+				protobufGoRepoPath = currentWorkspace().ProtobufGoRepo(organisationID)
 
 				if pushProtocolBuffers {
-					err := clearUncommittedRepoChanges(protobufGoRepoPath)
+					err := clearUncommittedRepoChanges(cmd.Context(), protobufGoRepoPath)
 					if err != nil {
-						pterm.Error.Println(err)
-						return
+						return err
 					}
 				}
 
-				cmds = "rm -rf " + neuronProtobufFullPath + " && " +
-					"mkdir -p " + neuronProtobufFullPath + " && " +
-					"go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev && " +
-					"protoc --go_out=$HOME/alis.exchange/" + organisationID + "/protobuf/go --go_opt=paths=source_relative --go-grpc_out=$HOME/alis.exchange/" + organisationID + "/protobuf/go --go-grpc_opt=paths=source_relative -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto " + neuronProtoFullPath + "/*.proto"
+				err := resetDir(neuronProtobufFullPath)
+				if err == nil {
+					err = setGoPrivate(cmd.Context(), organisationID)
+				}
+				if err == nil {
+					stderr, err = generateGoProtobufs(cmd.Context(), organisationID, neuronProtoFullPath, protobufGoRepoPath)
+				}
+				if err != nil {
+					return err
+				}
 			}
 
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
-			if err != nil {
-				pterm.Error.Printf(fmt.Sprintf("%s", out))
-				pterm.Error.Println(err)
-				return
-			}
-			if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for go...\n%s", out))
+			if strings.Contains(stderr, "warning") {
+				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for go...\n%s", stderr))
 			}
 			pterm.Success.Printf("Generated protocol buffers for Go.\nProto source: %s\n", neuronProtoFullPath)
 
 			// generate ProductDescriptorFile at product level.
-			err = genProductDescriptorFile("organisations/" + organisationID + "/products/" + productID)
+			err = genProductDescriptorFile(cmd.Context(), "organisations/"+organisationID+"/products/"+productID)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Success.Println("Generated Product Descriptor File")
 
@@ -173,11 +184,10 @@ the latest protobufs from the repo into your gRPC service.`),
 			if pushProtocolBuffers {
 				// commit protocol buffers in go
 				message := fmt.Sprintf("chore(%s): updated by alis_ CLI", neuronID)
-				_, err := commitTagAndPush(cmd.Context(), protobufGoRepoPath, neuronProtobufFullPath,
+				_, err := commitTagAndPush(cmd.Context(), protobufGoRepoPath, []string{neuronProtobufFullPath},
 					message, "", true, true)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 				pterm.Success.Println("Published protocol buffers for Go")
 
@@ -192,102 +202,22 @@ the latest protobufs from the repo into your gRPC service.`),
 
 		// generate protocol buffers for Python
 		if genprotoPython {
-			neuronProtobufFullPath := homeDir + "/alis.exchange/" + organisationID + "/protobuf/python/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-			neuronProtoFullPath := homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-
-			// TODO: create __init__.py using golang file io.
-			cmds := "rm -rf " + neuronProtobufFullPath + " && " +
-				"mkdir -p " + neuronProtobufFullPath + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/__init__.py` + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/` + strings.Split(neuronID, "-")[0] + `/__init__.py` + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/` + strings.Split(neuronID, "-")[0] + `/` + strings.Split(neuronID, "-")[1] + `/__init__.py` + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/` + strings.Split(neuronID, "-")[0] + `/` + strings.Split(neuronID, "-")[1] + `/` + strings.Split(neuronID, "-")[2] + `/__init__.py` + " && " +
-				"python3 -m grpc_tools.protoc --python_out=$HOME/alis.exchange/" + organisationID + "/protobuf/python --grpc_python_out=$HOME/alis.exchange/" + organisationID + "/protobuf/python -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto " + neuronProtoFullPath + "/*.proto"
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+			neuronProtoFullPath := currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+
+			stderr, initFiles, err := generatePythonProtobufs(cmd.Context(), organisationID, productID, neuronID)
 			if err != nil {
-				pterm.Error.Printf(fmt.Sprintf("%s", out))
-				pterm.Error.Println(err)
-				return
+				return err
 			}
-			if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for python...\n%s", out))
+			if strings.Contains(stderr, "warning") {
+				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for python...\n%s", stderr))
 			}
 			pterm.Success.Printf("Generated protocol buffers for Python.\nProto source: %s\n", neuronProtoFullPath)
 
 			// Publish to protobuf repository if not in local mode.
 			if pushProtocolBuffers {
-
-				protobufPythonRepo := fmt.Sprintf("%s/alis.exchange/%s/protobuf/python", homeDir, organisationID)
-
-				// bump setup.py version
-				var tpl bytes.Buffer
-				setupPyTemplate, err := template.ParseFiles(protobufPythonRepo + "/setup.py")
-				if err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				if err = setupPyTemplate.Execute(&tpl, struct{}{}); err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				rel := regexp.MustCompile("version=\"(.*)\",")
-				versionComponents := strings.Split(rel.FindStringSubmatch(tpl.String())[1], ".")
-				minorVersion, err := strconv.Atoi(versionComponents[2])
-				versionComponents[2] = strconv.Itoa(minorVersion + 1)
-				newVersion := rel.ReplaceAllString(tpl.String(), "version=\""+strings.Join(versionComponents, ".")+"\",")
-				out, err = exec.CommandContext(context.Background(), "bash", "-c", "echo "+"'"+newVersion+"'"+" > "+protobufPythonRepo+"/setup.py").CombinedOutput()
-				if err != nil {
-					pterm.Error.Printf(fmt.Sprintf("%s", out))
-					pterm.Error.Println(err)
-					return
-				}
-
-				// publish Python package to artifact registry
-				tpl = bytes.Buffer{}
-				publishTemplate, err := TemplateFs.ReadFile("internal/cmd/neuron/python/publishPython.sh")
-				if err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				t, err := template.New("file").Parse(string(publishTemplate))
+				err = publishPythonProtobufs(cmd.Context(), organisation, productID, neuronID, initFiles)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				if err := t.Execute(&tpl, struct {
-					OrgProjectID   string
-					OrganisationID string
-				}{
-					OrgProjectID:   organisation.GetGoogleProjectId(),
-					OrganisationID: organisationID,
-				}); err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-
-				out, err = exec.CommandContext(context.Background(), "bash", "-c", tpl.String()).CombinedOutput()
-				if err != nil {
-					pterm.Error.Printf(fmt.Sprintf("%s", out))
-					pterm.Error.Println(err)
-					return
-				}
-
-				if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-					pterm.Warning.Print(fmt.Sprintf("Publishing protocol buffers for python...\n%s", out))
-				}
-
-				message := fmt.Sprintf("chore(%s): updated by alis_ CLI", neuronID)
-				commitPath := neuronProtobufFullPath +
-					" " + protobufPythonRepo + "/setup.py" +
-					" " + protobufPythonRepo + "/alis/" + productID + "/__init__.py" +
-					" " + protobufPythonRepo + "/alis/" + productID + "/" + strings.Split(neuronID, "-")[0] + "/__init__.py" +
-					" " + protobufPythonRepo + "/alis/" + productID + "/" + strings.Split(neuronID, "-")[0] + "/" + strings.Split(neuronID, "-")[1] + "/__init__.py"
-
-				_, err = commitTagAndPush(cmd.Context(), protobufPythonRepo, commitPath, message,
-					"", true, true)
-				if err != nil {
-					return
+					return err
 				}
 
 				pterm.Success.Println("Published protocol buffers for Python")
@@ -298,7 +228,7 @@ the latest protobufs from the repo into your gRPC service.`),
 			}
 		}
 
-		return
+		return nil
 	},
 }
 
@@ -309,57 +239,59 @@ var descriptorGenCmd = &cobra.Command{
 	Long: pterm.Green(
 		`This method uses the 'protoc --descriptor_set_out=...' command line to generate a local 'descriptor.pb' file.
 This file is a serialised google.protobuf.FileDescriptorSet object representing all the relevant proto files.`),
-	Example: pterm.LightYellow("alis gen descriptor {orgID}.{productID}.{neuronID}"),
-	Args:    validateOrgOrProductOrNeuron,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis gen descriptor {orgID}.{productID}.{neuronID}"),
+	Args:              validateOrgOrProductOrNeuron,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var name string
 		argParts := strings.Split(args[0], ".")
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// The length of the argument parts determine whether the request is at organisation, product or neuron level.
 		switch len(argParts) {
 		case 1:
 			// Retrieve the organisation resource
 			name = "organisations/" + argParts[0]
-			organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+			organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 				&pbProducts.GetOrganisationRequest{Name: name})
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Debug.Printf("Get Organisation:\n%s\n", organisation)
 		case 2:
 			// Retrieve the product resource
 			name = "organisations/" + argParts[0] + "/products/" + argParts[1]
-			product, err := alisProductsClient.GetProduct(cmd.Context(), &pbProducts.GetProductRequest{Name: name})
+			product, err := clients.Products.GetProduct(cmd.Context(), &pbProducts.GetProductRequest{Name: name})
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Debug.Printf("Get Product:\n%s\n", product)
 		case 3:
 			// Retrieve the neuron resource
 			name = "organisations/" + argParts[0] + "/products/" + argParts[1] + "/neurons/" + argParts[2]
-			neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+			neuron, err := clients.Products.GetNeuron(cmd.Context(),
 				&pbProducts.GetNeuronRequest{
 					Name: name})
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Debug.Printf("Get Neuron:\n%s\n", neuron)
 		}
 
 		// generate ProductDescriptorFile at the relevant resource level.
-		descriptorPath, err := genDescriptorFile(name)
+		descriptorPath, err := genDescriptorFile(cmd.Context(), name)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		pterm.Info.Printf("Generated %s\n", descriptorPath)
 
-		return
+		return nil
 	},
 }
 
@@ -375,27 +307,31 @@ var productDocsGenCmd = &cobra.Command{
 	Long: pterm.Green(
 		`This method uses the 'gendocs' protoc plugin to generate documentation for the
 specified product and publishes it at a URL specified.`),
-	Example: pterm.LightYellow("alis gen docs {orgID}.{productID}"),
-	Args:    validateProductArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis gen docs {orgID}.{productID}"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
@@ -407,8 +343,7 @@ specified product and publishes it at a URL specified.`),
 		ptermTip.Println("The documentation will be generated for all the neuron versions in the deployment.")
 		deployment, err := selectProductDeployment(cmd.Context(), product.GetName())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetDeployment:\n%s\n", deployment)
 		envs = append(envs, &pbProducts.Neuron_Env{
@@ -421,10 +356,9 @@ specified product and publishes it at a URL specified.`),
 		var apiVisibility string
 		if !productsDocsGenPublicFlag && !productsDocsGenCustomFlag {
 
-			scope, err := askUserString("Specify either 'PUBLIC' or 'CUSTOM': ", "^(PUBLIC|CUSTOM)$")
+			scope, err := ask("scope", "Specify either 'PUBLIC' or 'CUSTOM': ", "^(PUBLIC|CUSTOM)$")
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			if scope == "PUBLIC" {
@@ -432,8 +366,7 @@ specified product and publishes it at a URL specified.`),
 			} else if scope == "CUSTOM" {
 				productsDocsGenCustomFlag = true
 			} else {
-				pterm.Error.Println("Invalid scope specified.")
-				return
+				return status.Errorf(codes.InvalidArgument, "invalid scope %s, expected PUBLIC or CUSTOM", scope)
 			}
 		}
 
@@ -445,13 +378,12 @@ specified product and publishes it at a URL specified.`),
 			pterm.Warning.Println("Specifying a PUBLIC restriction scope will make the generated documentation publicly available and " +
 				"generate content on all the proto content that do not contain google.api.visibility options.")
 
-			confirmPublic, err := askUserString("Are you sure you want to generate public documentation? (y/n): ", `^[y|n]$`)
+			confirmPublic, err := confirm("confirm-public", "Are you sure you want to generate public documentation? (y/n): ")
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
-			if confirmPublic == "n" {
+			if !confirmPublic {
 				productsDocsGenPublicFlag = false
 				productsDocsGenCustomFlag = true
 				pterm.Println("")
@@ -463,11 +395,10 @@ specified product and publishes it at a URL specified.`),
 		if productsDocsGenCustomFlag {
 			ptermTip.Println("CUSTOM will only contain documentation where the exact restriction scope is met and access to the" +
 				"documentation will be regulated by a IAM group.")
-			apiVisibility, err = askUserString("Specify the exact custom visibility scopes that should be matched."+
+			apiVisibility, err = ask("visibility", "Specify the exact custom visibility scopes that should be matched."+
 				"Multiple values may be seperated by a comma (Example: INTERNAL, PREVIEW): ", `^[A-Za-z0-9-, ]+$`)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Debug.Println("Custom API visibility scope: ", apiVisibility)
 
@@ -476,8 +407,7 @@ specified product and publishes it at a URL specified.`),
 				Value: apiVisibility,
 			})
 		} else {
-			pterm.Error.Println("Invalid scope specified.")
-			return
+			return status.Error(codes.InvalidArgument, "invalid scope specified")
 		}
 
 		////TODO: Requires definition of resource prior to implementation
@@ -503,8 +433,7 @@ specified product and publishes it at a URL specified.`),
 		pterm.Info.Println("3. Specify where the base URL to host the documentation")
 		dnsConfig, err := selectDnsConfig(cmd.Context())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Println("DNS Config ", dnsConfig)
 		envs = append(envs, &pbProducts.Neuron_Env{
@@ -518,10 +447,9 @@ specified product and publishes it at a URL specified.`),
 		pterm.Println("")
 		pterm.Info.Println("4. Specify the custom URL that the documentation should be hosted on.")
 		ptermTip.Println("Has to end with '" + dnsConfig.baseURL + "' (Example: myproduct." + dnsConfig.baseURL + ")")
-		docsCustomURL, err := askUserString("Specify the custom URL: ", `[a-z.-]\.`+dnsConfig.baseURL)
+		docsCustomURL, err := ask("docs-url", "Specify the custom URL: ", `[a-z.-]\.`+dnsConfig.baseURL)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Println("Custom URL: ", docsCustomURL)
 		envs = append(envs, &pbProducts.Neuron_Env{
@@ -533,32 +461,29 @@ specified product and publishes it at a URL specified.`),
 		// TODO: This should be made way more elegant for outside users.
 		prodDeployment, err := createProductDeployment(cmd.Context(), "organisations/alis/products/ex")
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Println("New product deployment created: ", prodDeployment)
 
 		// Retrieve the latest version
 		neuronID := "resources-docs-v1"
 		neuronName := "organisations/alis/products/ex/neurons/" + neuronID
-		res, err := alisProductsClient.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+		res, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
 			Parent:   neuronName,
 			ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version"}},
 		})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		if len(res.GetNeuronVersions()) == 0 {
-			pterm.Error.Println("there are no versions available, please run `alis neron build ...` to create a version")
-			return
+			return status.Errorf(codes.NotFound, "there are no versions available, please run `alis neuron build ...` to create a version")
 		}
 
 		latestVersion := res.GetNeuronVersions()[0].GetVersion()
 		pterm.Debug.Println("Latest version: ", latestVersion)
 		pterm.Debug.Println("Envs: ", envs)
 
-		op, err = alisProductsClient.CreateNeuronDeployment(cmd.Context(), &pbProducts.CreateNeuronDeploymentRequest{
+		op, err = clients.Products.CreateNeuronDeployment(cmd.Context(), &pbProducts.CreateNeuronDeploymentRequest{
 			Parent: prodDeployment.GetName(),
 			NeuronDeployment: &pbProducts.NeuronDeployment{
 				Name:    neuronName + "/versions/" + latestVersion,
@@ -568,14 +493,13 @@ specified product and publishes it at a URL specified.`),
 			NeuronDeploymentId: neuronID,
 		})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// check if we need to wait for operation to complete.
 		if asyncFlag {
 			pterm.Debug.Printf("GetOperation:\n%s\n", op)
-			pterm.Success.Printf("Launched service in async mode.\n see long-running operation " + op.GetName() + " to monitor state\n")
+			pterm.Success.Printf("Launched service in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
 		} else {
 
 			successMessage := "Documentation has been deployed to: https://" + docsCustomURL + ".\n\n" +
@@ -594,8 +518,7 @@ specified product and publishes it at a URL specified.`),
 			// wait for the long-running operation to complete.
 			err := wait(cmd.Context(), op, "Creating documentation for "+product.GetName(), successMessage, 300, true)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 		}
 
@@ -651,7 +574,7 @@ specified product and publishes it at a URL specified.`),
 		//
 		//pterm.Success.Printf("Generated documentation at %s\n", homeDir+"/alis.exchange/"+organisationID+"/products/"+productID)
 
-		return
+		return nil
 
 	},
 }
@@ -661,6 +584,10 @@ func init() {
 	genCmd.AddCommand(protobufGenCmd)
 	genCmd.AddCommand(descriptorGenCmd)
 	genCmd.AddCommand(productDocsGenCmd)
+
+	// the neuron, or product, may be left out when running from within its directory.
+	argFromWorkingDir(protobufGenCmd, neuronFromDir)
+	argFromWorkingDir(productDocsGenCmd, productFromDir)
 	neuronCmd.SilenceUsage = true
 	neuronCmd.SilenceErrors = true
 
@@ -669,11 +596,21 @@ func init() {
 
 	productDocsGenCmd.Flags().BoolVar(&productsDocsGenCustomFlag, "custom", false, pterm.Green("Set custom visibility scopes for the documentation being generated."))
 	productDocsGenCmd.Flags().BoolVar(&productsDocsGenPublicFlag, "public", false, pterm.Green("Set documentation visibility scope as public."))
+	addAnswerFlag(productDocsGenCmd, "deployment", "The product deployment for which to generate documentation")
+	addAnswerFlag(productDocsGenCmd, "scope", "The visibility scope of the documentation, one of PUBLIC or CUSTOM")
+	addAnswerFlag(productDocsGenCmd, "visibility", "The custom visibility scopes to match, for example INTERNAL,PREVIEW")
+	addAnswerFlag(productDocsGenCmd, "base-url", "The index of the base URL which hosts the documentation")
+	addAnswerFlag(productDocsGenCmd, "docs-url", "The custom URL of the documentation")
+	addAnswerFlag(productDocsGenCmd, "environment", "The environment of the documentation deployment, one of PROD or DEV")
+	addAnswerFlag(productDocsGenCmd, "display-name", "The display name of the documentation deployment")
+	addAnswerFlag(productDocsGenCmd, "owner", "The owner (email) of the documentation deployment")
+	addAnswerFlag(productDocsGenCmd, "billing-account", "The billing account ID of the documentation deployment")
 	rootCmd.AddCommand(&cobra.Command{
 		Use:    "iloveprotos",
 		Hidden: true,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			generateParsedText()
+			return nil
 		},
 	})
 
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gitRepo is a local clone of a git repository.  Its methods run git with a fixed locale and read its machine
+// readable output only, so they do not depend on the wording of git's messages.
+type gitRepo struct {
+	Path string
+}
+
+// git runs git within the repository and returns its trimmed standard output.
+func (r gitRepo) git(ctx context.Context, args ...string) (string, error) {
+	out, err := r.output(ctx, args...)
+	return strings.TrimSpace(out), err
+}
+
+// output runs git within the repository and returns its standard output as it is.
+func (r gitRepo) output(ctx context.Context, args ...string) (string, error) {
+	c := gitCommand(r.Path, args...)
+	c.Env = []string{"LC_ALL=C"}
+	out, _, err := run(ctx, c)
+	return out, err
+}
+
+// CurrentBranch returns the name of the checked out branch.  It fails with FailedPrecondition if no branch is
+// checked out.
+func (r gitRepo) CurrentBranch(ctx context.Context) (string, error) {
+	branch, err := r.git(ctx, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil || branch == "" {
+		return "", status.Errorf(codes.FailedPrecondition, "%s is not on a branch, check out a branch and try again", r.Path)
+	}
+	return branch, nil
+}
+
+// Pull merges the remote branch into the local one.
+func (r gitRepo) Pull(ctx context.Context, branch string) error {
+	_, err := r.git(ctx, "pull", "--no-rebase", "origin", branch)
+	return err
+}
+
+// Changes returns the paths, relative to the top of the repository, which differ from the last commit.  Untracked
+// files are only included if untracked is set.
+func (r gitRepo) Changes(ctx context.Context, untracked bool, paths ...string) ([]string, error) {
+	args := []string{"status", "--porcelain", "-z"}
+	if !untracked {
+		args = append(args, "--untracked-files=no")
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// entries are "XY path", renames and copies are followed by the original path.
+	var changes []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		if len(entries[i]) < 4 {
+			continue
+		}
+		changes = append(changes, entries[i][3:])
+		if entries[i][0] == 'R' || entries[i][0] == 'C' {
+			i++
+		}
+	}
+	return changes, nil
+}
+
+// CheckClean fails with FailedPrecondition if tracked files outside of paths have uncommitted changes.
+func (r gitRepo) CheckClean(ctx context.Context, paths ...string) error {
+	changes, err := r.Changes(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	var within []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(r.Path, p)
+		if err != nil {
+			return err
+		}
+		within = append(within, filepath.ToSlash(rel))
+	}
+
+	var outside []string
+	for _, change := range changes {
+		if !withinPaths(change, within) {
+			outside = append(outside, change)
+		}
+	}
+	if len(outside) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "%s has uncommitted changes outside of %s:\n%s\ncommit or discard them and try again",
+			r.Path, strings.Join(paths, ", "), strings.Join(outside, "\n"))
+	}
+	return nil
+}
+
+// withinPaths reports whether path is one of the paths or lies within one of them.
+func withinPaths(path string, paths []string) bool {
+	for _, p := range paths {
+		if p == "." || path == p || strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// TagExists reports whether tag exists, either locally or on the remote.
+func (r gitRepo) TagExists(ctx context.Context, tag string) (bool, error) {
+	local, err := r.git(ctx, "tag", "--list", tag)
+	if err != nil {
+		return false, err
+	}
+	if local != "" {
+		return true, nil
+	}
+	remote, err := r.git(ctx, "ls-remote", "--tags", "origin", "refs/tags/"+tag)
+	if err != nil {
+		return false, err
+	}
+	return remote != "", nil
+}
+
+// Add stages paths, including new files.
+func (r gitRepo) Add(ctx context.Context, paths ...string) error {
+	_, err := r.git(ctx, append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
+// Commit commits the changes to paths.
+func (r gitRepo) Commit(ctx context.Context, message string, paths ...string) error {
+	_, err := r.git(ctx, append([]string{"commit", "-m", message, "--"}, paths...)...)
+	return err
+}
+
+// Tag tags the last commit.
+func (r gitRepo) Tag(ctx context.Context, tag string) error {
+	_, err := r.git(ctx, "tag", tag)
+	return err
+}
+
+// Push pushes branch to the remote along with the tag, if any.  Either both or neither are pushed.
+func (r gitRepo) Push(ctx context.Context, branch string, tag string) error {
+	args := []string{"push", "--atomic", "origin", "refs/heads/" + branch + ":refs/heads/" + branch}
+	if tag != "" {
+		args = append(args, "refs/tags/"+tag)
+	}
+	_, err := r.git(ctx, args...)
+	return err
+}
+
+// RevParse returns the hash of the commit rev refers to.
+func (r gitRepo) RevParse(ctx context.Context, rev string) (string, error) {
+	sha, err := r.git(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil || sha == "" {
+		return "", status.Errorf(codes.NotFound, "%s does not refer to a commit in %s, run `git -C %s pull` and try again", rev, r.Path, r.Path)
+	}
+	return sha, nil
+}
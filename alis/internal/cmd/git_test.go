@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCommitTagAndPushOnMainBranch(t *testing.T) {
+	e := newTestEnv(t)
+	dir := e.repo("alis/products/in", map[string]string{"README.md": "in\n"})
+	e.git(dir, "branch", "-m", "master", "main")
+	e.git(dir, "push", "-u", "origin", "main")
+	writeFile(t, filepath.Join(dir, "README.md"), "in, updated\n")
+
+	sha, err := commitTagAndPush(context.Background(), dir, []string{dir}, "update(alis.in): 1.0.1", "alis.in.1.0.1", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.git(dir, "ls-remote", "origin", "refs/heads/main"); got != sha+"\trefs/heads/main" {
+		t.Errorf("remote main = %q, want %s", got, sha)
+	}
+	if got := e.git(dir, "ls-remote", "origin", "refs/tags/alis.in.1.0.1"); got == "" {
+		t.Error("tag alis.in.1.0.1 was not pushed")
+	}
+}
+
+func TestCommitTagAndPushTagCollision(t *testing.T) {
+	e := newTestEnv(t)
+	dir := e.repo("alis/products/in", map[string]string{"README.md": "in\n"})
+	head := e.git(dir, "rev-parse", "HEAD")
+	// the tag exists on the remote only.
+	e.git(dir, "push", "origin", "HEAD:refs/tags/alis.in.1.0.1")
+	writeFile(t, filepath.Join(dir, "README.md"), "in, updated\n")
+
+	_, err := commitTagAndPush(context.Background(), dir, []string{dir}, "update(alis.in): 1.0.1", "alis.in.1.0.1", false, true)
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("err = %v, want AlreadyExists", err)
+	}
+	if got := e.git(dir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD = %s, want nothing committed", got)
+	}
+}
+
+func TestCommitTagAndPushDirtyTree(t *testing.T) {
+	e := newTestEnv(t)
+	dir := e.repo("alis/products/in", map[string]string{"README.md": "in\n", "neuron/v1/main.go": "package main\n"})
+	writeFile(t, filepath.Join(dir, "README.md"), "in, updated\n")
+
+	_, err := commitTagAndPush(context.Background(), dir, []string{filepath.Join(dir, "neuron/v1")}, "update(alis.in.neuron-v1): 1.0.1", "alis.in.neuron-v1.1.0.1", false, false)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("err = %v, want FailedPrecondition", err)
+	}
+
+	// changes within the commit paths are fine.
+	e.git(dir, "checkout", "--", "README.md")
+	writeFile(t, filepath.Join(dir, "neuron/v1/main.go"), "package main // updated\n")
+	if _, err := commitTagAndPush(context.Background(), dir, []string{filepath.Join(dir, "neuron/v1")}, "update(alis.in.neuron-v1): 1.0.1", "alis.in.neuron-v1.1.0.1", false, false); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"bytes"
-	"context"
 	"fmt"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -10,10 +8,11 @@ import (
 	"google.golang.org/genproto/googleapis/longrunning"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"io/fs"
 	"os"
-	"os/exec"
-	"regexp"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"text/template"
@@ -26,9 +25,9 @@ var (
 	genprotoGo                 bool
 	setNeuronDeploymentEnvFlag bool
 	setUpdateNeuronEnvFlag     bool
-	setUpdateNeuronStateFlag   bool
 	setDeployNeuronStateFlag   bool
 	publishApiFlag             bool
+	deployVersionFlag          string
 )
 
 type Parameters struct {
@@ -43,9 +42,12 @@ type Parameters struct {
 var neuronCmd = &cobra.Command{
 	Use:   "neuron",
 	Short: pterm.Blue("Manages neurons within your product"),
-	Long:  pterm.Green(`Use this command to update, deploy, create, delete neurons within your product resource.`),
-	Run: func(cmd *cobra.Command, args []string) {
-		pterm.Error.Println("a valid command is missing\nplease run 'alis neuron -h' for details.")
+	Long: pterm.Green(`Use this command to update, deploy, create, delete neurons within your product resource.
+
+From within the directory of a neuron, in its product repository or the proto repository,
+the neuron argument may be left out, for example "alis neuron build".`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
 	},
 }
 
@@ -61,97 +63,99 @@ files to get your started with.  Once you have a first version of your service,
 the changes to the master branch and run the command "alis neuron build ..." `),
 	Example: pterm.LightYellow("alis neuron create {orgID}.{productID}.{neuronID}"),
 	Args:    validateNeuronArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 		neuronID = strings.Split(args[0], ".")[2]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// Check if neuron exists
-		_, err = alisProductsClient.GetNeuron(cmd.Context(), &pbProducts.GetNeuronRequest{
+		_, err = clients.Products.GetNeuron(cmd.Context(), &pbProducts.GetNeuronRequest{
 			Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
 		if err == nil {
-			pterm.Error.Println("neuron already exits.")
-			return
+			return status.Errorf(codes.AlreadyExists, "neuron %s.%s.%s already exists", organisationID, productID, neuronID)
 		}
 
 		envs, err := askUserNeuronEnvs(nil)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// Retrieve the neuron resource
-		op, err := alisProductsClient.CreateNeuron(cmd.Context(),
-			&pbProducts.CreateNeuronRequest{
-				Parent: product.GetName(),
-				Neuron: &pbProducts.Neuron{
-					Type: pbProducts.Neuron_RESOURCE,
-					Envs: envs,
-				},
-				NeuronId: neuronID,
-			})
-		if err != nil {
-			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+		req := &pbProducts.CreateNeuronRequest{
+			Parent: product.GetName(),
+			Neuron: &pbProducts.Neuron{
+				Type: pbProducts.Neuron_RESOURCE,
+				Envs: envs,
+			},
+			NeuronId: neuronID,
 		}
+		if dryRunFlag {
+			dryRunRequest("CreateNeuron", req)
+		} else {
+			op, err := clients.Products.CreateNeuron(cmd.Context(), req)
+			if err != nil {
+				// TODO: handle not found by listing available products.
+				return err
+			}
 
-		// wait for the long-running operation to complete.
-		err = wait(cmd.Context(), op, "Creating "+neuronID, "Created "+neuronID, 300, true)
-		if err != nil {
-			pterm.Error.Println(err)
-			return
-		}
+			// wait for the long-running operation to complete.
+			err = wait(cmd.Context(), op, "Creating "+neuronID, "Created "+neuronID, 300, true)
+			if err != nil {
+				return err
+			}
 
-		// retrieve a copy of the neuron
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
-			&pbProducts.GetNeuronRequest{Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
-		if err != nil {
-			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			// retrieve a copy of the neuron
+			neuron, err := clients.Products.GetNeuron(cmd.Context(),
+				&pbProducts.GetNeuronRequest{Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
+			if err != nil {
+				// TODO: handle not found by listing available products.
+				return err
+			}
+			pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
 		}
-		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
 
 		// push boiler plate code to local environment
 		// Parse the template files.
-		files, err := TemplateFs.ReadDir("templates/go")
+		files, err := fs.ReadDir(TemplateFs, "templates/go")
 		if err != nil {
-			return
+			return err
+		}
+		if !dryRunFlag {
+			pterm.Info.Printf("Created the following files:\n")
 		}
-		pterm.Info.Printf("Created the following files:\n")
 		for i, f := range files {
 
-			fileTemplate, err := TemplateFs.ReadFile("templates/go/" + f.Name())
+			fileTemplate, err := fs.ReadFile(TemplateFs, "templates/go/"+f.Name())
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			t, err := template.New(fmt.Sprintf("%v", i)).Parse(string(fileTemplate))
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// A temporary workaround for the .mod file templates.
@@ -161,21 +165,23 @@ the changes to the master branch and run the command "alis neuron build ..." `),
 			switch {
 			case strings.HasSuffix(filename, ".proto") || strings.HasSuffix(filename, ".tf"):
 				// save in proto repository
-				destDir = fmt.Sprintf("%s/alis.exchange/%s/%s/%s/%s/%s", homeDir, organisationID, "proto", organisationID, productID, strings.ReplaceAll(neuronID, "-", "/"))
+				destDir = currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
 			default:
-				destDir = fmt.Sprintf("%s/alis.exchange/%s/products/%s/%s", homeDir, organisationID, productID, strings.ReplaceAll(neuronID, "-", "/"))
+				destDir = currentWorkspace().NeuronDir(organisationID, productID, neuronID)
+			}
+			if dryRunFlag {
+				dryRun("would create %s/%s", destDir, filename)
+				continue
 			}
 
 			err = os.MkdirAll(destDir, os.FileMode(0777))
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			file, err := os.Create(fmt.Sprintf("%s/%s", destDir, filename))
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// set the parameters, the project defaults to {organisation}-{product}-dev
@@ -188,70 +194,79 @@ the changes to the master branch and run the command "alis neuron build ..." `),
 			}
 			err = t.Execute(file, p)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Printf("%s%s/%s\n", pterm.Cyan(" ● "), destDir, filename)
 		}
+		if dryRunFlag {
+			dryRunDone()
+			return nil
+		}
 		ptermTip.Printf("The above files have been added to your proto and product repositories, but have "+
 			"not yet been committed.\nMake the necessary changes to the files, commit them to the master before running "+
 			"the `alis neuron build %s.%s.%s` command\n", organisationID, productID, neuronID)
+		return nil
 	},
 }
 
 // getNeuronCmd represents the get command
 var getNeuronCmd = &cobra.Command{
-	Use:     "get",
-	Short:   pterm.Blue("Retrieve details on a specified neuron."),
-	Example: pterm.LightYellow("alis neuron list {orgID}.{productID}.{neuronID}"),
-	Args:    validateNeuronArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:               "get",
+	Short:             pterm.Blue("Retrieve details on a specified neuron."),
+	Example:           pterm.LightYellow("alis neuron list {orgID}.{productID}.{neuronID}"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 		neuronID = strings.Split(args[0], ".")[2]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// Retrieve the neuron resource
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
 			&pbProducts.GetNeuronRequest{Name: "organisations/" + organisationID +
 				"/products/" + productID + "/neurons/" + neuronID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
 
 		// Retrieve Product deployments
-		productsDeploymentsRes, err := alisProductsClient.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{
+		productsDeploymentsRes, err := clients.Products.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{
 			Parent: product.GetName(),
 		})
+		if err != nil {
+			return err
+		}
 		productDeployments := productsDeploymentsRes.GetProductDeployments()
 		pterm.Debug.Printf("ListProductDeployments:\n%v found\n", len(productsDeploymentsRes.GetProductDeployments()))
 
 		// Retrieve the latest neuronVersion
-		listNeuronVersionsRes, err := alisProductsClient.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+		listNeuronVersionsRes, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
 			Parent: neuron.GetName(),
 		})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		neuronVersions := listNeuronVersionsRes.GetNeuronVersions()
 		var neuronVersion *pbProducts.NeuronVersion
@@ -259,6 +274,29 @@ var getNeuronCmd = &cobra.Command{
 			neuronVersion = listNeuronVersionsRes.GetNeuronVersions()[0]
 		}
 
+		// Retrieve the neuron deployments
+		var neuronDeploymentNames []string
+		for _, productDeployment := range productDeployments {
+			neuronDeploymentNames = append(neuronDeploymentNames, productDeployment.GetName()+"/neurons/"+neuronID)
+		}
+
+		neuronDeployments, err := getNeuronDeployments(cmd.Context(), clients, neuronDeploymentNames)
+		if err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			deploymentsOut := []interface{}{}
+			for i, neuronDeployment := range neuronDeployments {
+				if neuronDeployment.GetName() != "" {
+					deploymentsOut = append(deploymentsOut, map[string]interface{}{
+						"productDeployment": productDeployments[i], "neuronDeployment": neuronDeployment})
+				}
+			}
+			return printResources(cmd, map[string]interface{}{
+				"neuron": neuron, "versions": neuronVersions, "deployments": deploymentsOut})
+		}
+
 		// Generate table with Neuron details.
 		pterm.DefaultSection.Print("NEURON BUILD:")
 		// Color the state
@@ -275,9 +313,9 @@ var getNeuronCmd = &cobra.Command{
 
 		tableNeuron = append(tableNeuron, row)
 
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableNeuron).Render()
+		err = renderTable(cmd, tableNeuron)
 		if err != nil {
-			return
+			return err
 		}
 
 		// Display links to compare repos:
@@ -292,9 +330,9 @@ var getNeuronCmd = &cobra.Command{
 		for _, e := range neuron.GetEnvs() {
 			table = append(table, []string{e.GetName(), e.GetValue()})
 		}
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
+		err = renderTable(cmd, table)
 		if err != nil {
-			return
+			return err
 		}
 
 		// Display table of the last 7 neuron_versions
@@ -320,9 +358,9 @@ var getNeuronCmd = &cobra.Command{
 				pterm.Gray(fmt.Sprintf("proto:   https://source.cloud.google.com/%s/proto/+/%s", organisation.GetGoogleProjectId(), neuronVersion.GetProtoCommitSha())),
 			})
 		}
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(neuronVersionTable).Render()
+		err = renderTable(cmd, neuronVersionTable)
 		if err != nil {
-			return
+			return err
 		}
 
 		// Generate table with Deployment details.
@@ -330,19 +368,8 @@ var getNeuronCmd = &cobra.Command{
 		header = []string{"Index", "Name", "Neuron Version", "Google Project", "State", "Update Time"}
 		deploymentTable := pterm.TableData{header}
 
-		// Add deployments
-		var neuronDeploymentNames []string
-		for _, productDeployment := range productDeployments {
-			neuronDeploymentNames = append(neuronDeploymentNames, productDeployment.GetName()+"/neurons/"+neuronID)
-		}
-
-		batchGetNeuronDeploymentsRes, err := alisProductsClient.BatchGetNeuronDeployments(cmd.Context(),
-			&pbProducts.BatchGetNeuronDeploymentsRequest{
-				Names: neuronDeploymentNames,
-			})
-
 		allEnvs := map[string]string{} // keep track of all env across all deployments
-		for i, neuronDeployment := range batchGetNeuronDeploymentsRes.GetNeuronDeployments() {
+		for i, neuronDeployment := range neuronDeployments {
 			// only return valid deployments
 			if neuronDeployment.GetName() != "" {
 
@@ -368,14 +395,14 @@ var getNeuronCmd = &cobra.Command{
 			}
 		}
 
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(deploymentTable).Render()
+		err = renderTable(cmd, deploymentTable)
 		if err != nil {
-			return
+			return err
 		}
 
 		// build header for Envs table.
 		header = []string{"Env"}
-		for i, neuronDeployment := range batchGetNeuronDeploymentsRes.GetNeuronDeployments() {
+		for i, neuronDeployment := range neuronDeployments {
 			if neuronDeployment.GetName() != "" {
 				header = append(header, fmt.Sprintf("%v: %s", i, productDeployments[i].GetGoogleProjectId()))
 			}
@@ -384,7 +411,7 @@ var getNeuronCmd = &cobra.Command{
 
 		for env, _ := range allEnvs {
 			row := []string{env}
-			for _, neuronDeployment := range batchGetNeuronDeploymentsRes.GetNeuronDeployments() {
+			for _, neuronDeployment := range neuronDeployments {
 				if neuronDeployment.GetName() != "" {
 					envMap := map[string]string{}
 					for _, env := range neuronDeployment.GetEnvs() {
@@ -396,67 +423,74 @@ var getNeuronCmd = &cobra.Command{
 			table = append(table, row)
 		}
 
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
+		err = renderTable(cmd, table)
 		if err != nil {
-			return
+			return err
 		}
+		return nil
 	},
 }
 
 // listNeuronCmd represents the list command
 var listNeuronCmd = &cobra.Command{
-	Use:     "list",
-	Short:   pterm.Blue("Lists the neurons for a specified product"),
-	Example: pterm.LightYellow("alis neuron list {orgID}.{productID}"),
-	Args:    validateProductArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:               "list",
+	Short:             pterm.Blue("Lists the neurons for a specified product"),
+	Example:           pterm.LightYellow("alis neuron list {orgID}.{productID}"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// Retrieve the neuron resource
-		listNeuronsRes, err := alisProductsClient.ListNeurons(cmd.Context(),
+		listNeuronsRes, err := clients.Products.ListNeurons(cmd.Context(),
 			&pbProducts.ListNeuronsRequest{Parent: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("ListNeurons:\n%v found\n", len(listNeuronsRes.GetNeurons()))
 
-		productsDeploymentsRes, err := alisProductsClient.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{
+		productsDeploymentsRes, err := clients.Products.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{
 			Parent: product.GetName(),
 		})
+		if err != nil {
+			return err
+		}
 		productDeployments := productsDeploymentsRes.GetProductDeployments()
 		pterm.Debug.Printf("GetProductDeployments:\n%v found\n", len(productsDeploymentsRes.GetProductDeployments()))
 
 		pterm.DefaultSection.Printf("Neurons for %s (%s):", product.GetDisplayName(), product.GetGoogleProjectId())
 
 		table := pterm.TableData{{"Index", "Neuron ID", "Version", "Update Time", "State", "Resource Name"}}
+		neuronsOut := []interface{}{}
 		for i, neuron := range listNeuronsRes.GetNeurons() {
 			// Retrieve the latest neuronVersion
-			listNeuronVersionsRes, err := alisProductsClient.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+			listNeuronVersionsRes, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
 				Parent: neuron.GetName(),
 			})
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			neuronVersions := listNeuronVersionsRes.GetNeuronVersions()
 
@@ -483,13 +517,16 @@ var listNeuronCmd = &cobra.Command{
 				neuronDeploymentNames = append(neuronDeploymentNames, productDeployment.GetName()+"/neurons/"+resourceID)
 			}
 
-			batchGetNeuronDeploymentsRes, err := alisProductsClient.BatchGetNeuronDeployments(cmd.Context(),
-				&pbProducts.BatchGetNeuronDeploymentsRequest{
-					Names: neuronDeploymentNames,
-				})
+			neuronDeployments, err := getNeuronDeployments(cmd.Context(), clients, neuronDeploymentNames)
+			if err != nil {
+				return err
+			}
 
-			for i, neuronDeployment := range batchGetNeuronDeploymentsRes.GetNeuronDeployments() {
+			deploymentsOut := []interface{}{}
+			for i, neuronDeployment := range neuronDeployments {
 				if neuronDeployment.GetName() != "" {
+					deploymentsOut = append(deploymentsOut, map[string]interface{}{
+						"productDeployment": productDeployments[i], "neuronDeployment": neuronDeployment})
 
 					version := neuronDeployment.GetVersion()
 					if version != neuronVersion.GetVersion() {
@@ -500,12 +537,19 @@ var listNeuronCmd = &cobra.Command{
 						pterm.Gray(productDeployments[i].GetGoogleProjectId())})
 				}
 			}
+			neuronsOut = append(neuronsOut, map[string]interface{}{
+				"neuron": neuron, "latestVersion": neuronVersion, "deployments": deploymentsOut})
 		}
 
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
+		if machineOutput() {
+			return printResources(cmd, neuronsOut)
+		}
+
+		err = renderTable(cmd, table)
 		if err != nil {
-			return
+			return err
 		}
+		return nil
 	},
 }
 
@@ -520,9 +564,10 @@ specification as defined in your proto.
 
 The neuron artifacts will be generated and pushed to the product artifact registry.  
 This registry then becomes the source for neuron deployments.`),
-	Example: pterm.LightYellow("alis neuron build {orgID}.{productID}.{neuronID}\nalis neuron build alis.in.resources-events-v1"),
-	Args:    validateNeuronArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis neuron build {orgID}.{productID}.{neuronID}\nalis neuron build alis.in.resources-events-v1"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var commitSha string
 		var protoCommitSha string
@@ -532,77 +577,119 @@ This registry then becomes the source for neuron deployments.`),
 		neuronID = strings.Split(args[0], ".")[2]
 
 		// fail the build if there is a `replace` entry in the go.mod file.
-		neuronPath := fmt.Sprintf("%s/alis.exchange/%s/products/%s/%s", homeDir, organisationID, productID, strings.ReplaceAll(neuronID, "-", "/"))
+		neuronPath := currentWorkspace().NeuronDir(organisationID, productID, neuronID)
 		goMod, err := getGoMod(cmd.Context(), neuronPath)
 		// don't fail when err != nil - i.e. there is not goMod file.
 		if err == nil && goMod.Replace != nil {
-			pterm.Error.Printf("When building a new NeuronVersion, `replace` entries are not allowed in your go.mod (%s/go.mod) file\nPlease remove / comment out the following before running `alis neuron build %s.%s.%s`\n", neuronPath, organisationID, productID, neuronID)
 			for _, e := range goMod.Replace {
 				pterm.Printf(" %s %s 👉 %s\n", pterm.Red("●"), e.Old.Path, e.New.Path)
 			}
-			return
+			return status.Errorf(codes.FailedPrecondition, "When building a new NeuronVersion, `replace` entries are not allowed in your go.mod (%s/go.mod) file\nPlease remove / comment out the above before running `alis neuron build %s.%s.%s`", neuronPath, organisationID, productID, neuronID)
+		}
+
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
 		}
 
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// Retrieve the neuron resource
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
 			&pbProducts.GetNeuronRequest{
 				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
 
 		// generate a FileDescriptorSet from the current protos.
 		// TODO: move this potentially to Build Triggers.
-		fds, err := getNeuronDescriptor(neuron.GetName())
+		fds, err := getNeuronDescriptor(cmd.Context(), neuron.GetName())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
+		}
+		// without protos neither the lint nor the breaking changes can be checked, which only skipping both allows.
+		if fds == nil && !(skipLintFlag && allowBreakingFlag) {
+			return status.Errorf(codes.NotFound, "no protos found for %s in %s, run the command with --skip-lint and "+
+				"--allow-breaking to build the version without them", args[0], currentWorkspace().NeuronProtos(organisationID, productID, neuronID))
+		}
+
+		// protos which do not follow the conventions never make it into a NeuronVersion.
+		if fds != nil && !skipLintFlag {
+			issues := lintNeuronDescriptor(fds, organisationID, productID, neuronID)
+			if len(issues) > 0 {
+				if err := renderLintIssues(cmd, issues); err != nil {
+					return err
+				}
+				ptermTip.Println("Fix the above issues, or run the command with --skip-lint to build the version anyway.")
+				return lintError(issues, args[0])
+			}
+		}
+
+		// the clients of the latest built version keep working with the new one, breaking changes need a new neuron.
+		if fds != nil && !allowBreakingFlag {
+			latest, err := latestBuiltVersion(cmd.Context(), clients, neuron.GetName())
+			if err != nil {
+				return err
+			}
+			var changes []breakingChange
+			if latest != nil {
+				changes = breakingChanges(latest.GetFileDescriptorSet(), fds)
+			}
+			if len(changes) > 0 {
+				if err := renderBreakingChanges(cmd, changes); err != nil {
+					return err
+				}
+				ptermTip.Printf("Make the above changes in a new neuron, for example `alis neuron create %s.%s.%s`, or run "+
+					"the command with --allow-breaking to build the version anyway.\n", organisationID, productID, nextMajorNeuronID(neuronID))
+				return breakingError(changes, args[0], latest.GetVersion())
+			}
 		}
 
 		// Retrieve the latest version
-		res, err := alisProductsClient.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+		res, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
 			Parent:   neuron.GetName(),
 			ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version"}},
 		})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// Retrieve the latest version
 		var latestVersion string
 		var newVersion string
+		majorVersion := strings.Split(neuronID, "-")[2][1:]
 		if len(res.GetNeuronVersions()) > 0 {
 			latestVersion = res.GetNeuronVersions()[0].GetVersion()
-			newVersion, err = bumpVersion(latestVersion, releaseType)
-			if err != nil {
-				pterm.Error.Println(err)
-				return
-			}
+			newVersion, err = releaseVersion(latestVersion)
+		} else {
+			newVersion, err = initialVersion(majorVersion + ".0.0")
+		}
+		if err != nil {
+			return err
+		}
+		// the major version is part of the neuron ID, for example resources-events-v1.
+		if !strings.HasPrefix(newVersion, majorVersion+".") {
+			return status.Errorf(codes.FailedPrecondition, "neuron %s only takes %s.x.x versions, create a new neuron for version %s", neuronID, majorVersion, newVersion)
+		}
+		if latestVersion != "" {
 			pterm.Info.Printf("Updating from version " + latestVersion + " to version " + newVersion + "...\n")
 		} else {
-			majorVersion := strings.Split(neuronID, "-")[2][1:]
-			newVersion = majorVersion + ".0.0"
 			pterm.Info.Printf("Creating initial version " + newVersion + "...\n")
 		}
 
@@ -612,45 +699,38 @@ This registry then becomes the source for neuron deployments.`),
 			tag := fmt.Sprintf("%s.%s.%s.%s.%s", organisationID, productID, neuronID, newVersion, rnd)
 
 			// tag product repository
-			repoPath := fmt.Sprintf("%s/alis.exchange/%s/products/%s", homeDir, organisationID, productID)
-			commitPath := fmt.Sprintf("%s/alis.exchange/%s/products/%s/%s", homeDir, organisationID, productID, strings.ReplaceAll(neuronID, "-", "/"))
+			repoPath := currentWorkspace().ProductRepo(organisationID, productID)
+			commitPath := currentWorkspace().NeuronDir(organisationID, productID, neuronID)
 			message := fmt.Sprintf("update(%s.%s.%s): %s", organisationID, productID, neuronID, newVersion)
-			commitSha, err = commitTagAndPush(cmd.Context(), repoPath, commitPath, message, tag, false, false)
+			commitSha, err = commitTagAndPush(cmd.Context(), repoPath, []string{commitPath}, message, tag, false, false)
 			// handle the case when the version already exists
 			// ask whether the user would like to bump to the next version
 			if status.Code(err) == codes.AlreadyExists {
-				newVersion, err = bumpVersion(newVersion, "patch")
+				newVersion, err = nextVersion(newVersion)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
-				input, err := askUserString(fmt.Sprintf("Bump to version %s and continue (y|n)?: ", newVersion), `^[y|n]$`)
+				bump, err := confirm("bump-version", fmt.Sprintf("Bump to version %s and continue (y|n)?: ", newVersion))
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
-				if input == "y" {
-					tag = fmt.Sprintf("%s.%s.%s.%s", organisationID, productID, neuronID, newVersion)
-					commitSha, err = commitTagAndPush(cmd.Context(), repoPath, commitPath, message, tag, false, false)
-					break
-				} else {
-					pterm.Warning.Println("Aborting operation")
-					return
+				if !bump {
+					return aborted("Aborting operation, version %s was not built", newVersion)
 				}
+				// tag both repositories with the next version.
+				continue
 			}
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// tag proto repository
-			repoPath = fmt.Sprintf("%s/alis.exchange/%s/proto", homeDir, organisationID)
-			commitPath = fmt.Sprintf("%s/alis.exchange/%s/proto/%s/%s/%s", homeDir, organisationID, organisationID, productID, strings.ReplaceAll(neuronID, "-", "/"))
+			repoPath = currentWorkspace().ProtoRepo(organisationID)
+			commitPath = currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
 			message = fmt.Sprintf("update(%s.%s.%s): %s", organisationID, productID, neuronID, newVersion)
-			protoCommitSha, err = commitTagAndPush(cmd.Context(), repoPath, commitPath, message, tag, true, false)
+			protoCommitSha, err = commitTagAndPush(cmd.Context(), repoPath, []string{commitPath}, message, tag, true, false)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			break
@@ -658,21 +738,23 @@ This registry then becomes the source for neuron deployments.`),
 
 		// request Env updates from user.
 		envs := neuron.GetEnvs()
-		if setUpdateNeuronEnvFlag {
+		if setUpdateNeuronEnvFlag || envEditsProvided() {
 			envs, err = askUserNeuronEnvs(envs)
+			if err != nil {
+				return err
+			}
 		}
 
 		// retrieve available Dockerfiles
 		neuronArg := fmt.Sprintf("%s.%s.%s", organisationID, productID, strings.ReplaceAll(neuronID, "-", "."))
 		dockerFilePaths, err := findNeuronDockerFilePaths(neuronArg)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Info.Printf("Found %v Dockerfile(s) in the neuron.\n", len(dockerFilePaths))
 
 		// Create a new neuron
-		op, err := alisProductsClient.CreateNeuronVersion(cmd.Context(), &pbProducts.CreateNeuronVersionRequest{
+		req := &pbProducts.CreateNeuronVersionRequest{
 			Parent: neuron.GetName(),
 			NeuronVersion: &pbProducts.NeuronVersion{
 				CommitSha:         commitSha,
@@ -681,24 +763,33 @@ This registry then becomes the source for neuron deployments.`),
 				FileDescriptorSet: fds,
 			},
 			NeuronVersionId: newVersion,
-		})
+		}
+		if dryRunFlag {
+			// the descriptor set is summarised, rather than printed in full.
+			plan := proto.Clone(req).(*pbProducts.CreateNeuronVersionRequest)
+			plan.GetNeuronVersion().FileDescriptorSet = nil
+			dryRunRequest("CreateNeuronVersion", plan)
+			dryRunDescriptor(fds)
+			dryRunDone()
+			return nil
+		}
+		op, err := clients.Products.CreateNeuronVersion(cmd.Context(), req)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// check if we need to wait for operation to complete.
 		if asyncFlag {
 			pterm.Debug.Printf("GetOperation:\n%s\n", op)
-			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state\n")
+			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
 		} else {
 			// wait for the long-running operation to complete.
 			err := wait(cmd.Context(), op, "Updating "+neuron.GetName(), "Updated "+neuron.GetName(), 300, true)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 		}
+		return nil
 	},
 }
 
@@ -707,106 +798,124 @@ var deployNeuronCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: pterm.Blue("Deploy a specified neuron to a chosen environment"),
 	Long: pterm.Green(
-		`This method retrieves the latest version of the neuron and
+		`This method retrieves the latest version of the neuron, or the one given with --version, and
 deploys it to one or more environments`),
-	Args:    validateNeuronArg,
-	Example: pterm.LightYellow("alis neuron deploy {orgID}.{productID}.{neuronID}\nalis neuron deploy alis.in.resources-events-v1"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	Example:           pterm.LightYellow("alis neuron deploy {orgID}.{productID}.{neuronID}\nalis neuron deploy alis.in.resources-events-v1 --version 1.2.0"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var op *longrunning.Operation
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 		neuronID = strings.Split(args[0], ".")[2]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// Retrieve the neuron resource
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
 			&pbProducts.GetNeuronRequest{
 				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
 
 		// ask the user to select a product deployment
 		productDeployments, err := selectProductDeployments(cmd.Context(), product.GetName())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
-		// Retrieve the latest version
-		res, err := alisProductsClient.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+		// Retrieve the latest version, or the one selected with --version
+		res, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
 			Parent:   neuron.GetName(),
-			ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version"}},
+			ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version", "state"}},
 		})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		if len(res.GetNeuronVersions()) == 0 {
-			pterm.Error.Println("there are no versions available, please run `alis neron build ...` to create a version")
-			return
+			return status.Errorf(codes.NotFound, "there are no versions available, please run `alis neuron build ...` to create a version")
 		}
 
-		latestVersion := res.GetNeuronVersions()[0].GetVersion()
+		deployVersion := res.GetNeuronVersions()[0].GetVersion()
+		if deployVersionFlag != "" {
+			version, err := findNeuronVersion(res.GetNeuronVersions(), deployVersionFlag)
+			if err != nil {
+				return err
+			}
+			deployVersion = version.GetVersion()
+		}
 
 		for _, productDeployment := range productDeployments {
 			pterm.DefaultSection.Printf("Deploying %s (%s)", productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId())
-			neuronDeployment, err := alisProductsClient.GetNeuronDeployment(cmd.Context(),
+			neuronDeployment, err := clients.Products.GetNeuronDeployment(cmd.Context(),
 				&pbProducts.GetNeuronDeploymentRequest{
 					Name: productDeployment.GetName() + "/neurons/" + neuronID})
 			if status.Code(err) == codes.NotFound {
 				pterm.Warning.Printf("This neuron has not yet been deployed to %s (%s)\n",
 					productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId())
 
-				input, err := askUserString("Would you like to create a new NeuronDeployment resource? (y|n): ", `^[y|n]$`)
-				if input == "n" {
-					pterm.Warning.Printf("selected 'n', aborting operation.")
-					return
+				create, err := confirm("create-neuron-deployment", "Would you like to create a new NeuronDeployment resource? (y|n): ")
+				if err != nil {
+					return err
+				}
+				if !create {
+					return aborted("selected 'n', aborting operation.")
 				}
 
 				// set envs
 				envs := neuron.GetEnvs()
 				envs, err = askUserNeuronEnvs(envs)
+				if err != nil {
+					return err
+				}
 
 				// Create a new NeuronDeployment resource
-				op, err = alisProductsClient.CreateNeuronDeployment(cmd.Context(), &pbProducts.CreateNeuronDeploymentRequest{
+				req := &pbProducts.CreateNeuronDeploymentRequest{
 					Parent: productDeployment.GetName(),
 					NeuronDeployment: &pbProducts.NeuronDeployment{
 						Envs:    envs,
-						Version: latestVersion,
+						Version: deployVersion,
 					},
 					NeuronDeploymentId: neuronID,
-				})
+				}
+				if dryRunFlag {
+					dryRunRequest("CreateNeuronDeployment", req)
+					continue
+				}
+				op, err = clients.Products.CreateNeuronDeployment(cmd.Context(), req)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 			} else if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			} else if setDeployNeuronStateFlag {
 				// Updating the state of the deployment
 				state, err := askUserNeuronDeploymentState(neuronDeployment.GetState())
-				op, err = alisProductsClient.UpdateNeuronDeployment(cmd.Context(), &pbProducts.UpdateNeuronDeploymentRequest{
+				if err != nil {
+					return err
+				}
+				req := &pbProducts.UpdateNeuronDeploymentRequest{
 					NeuronDeployment: &pbProducts.NeuronDeployment{
 						Name:  neuronDeployment.GetName(),
 						State: state,
@@ -814,47 +923,57 @@ deploys it to one or more environments`),
 					UpdateMask: &fieldmaskpb.FieldMask{
 						Paths: []string{"state"},
 					},
-				})
+				}
+				if dryRunFlag {
+					dryRunRequest("UpdateNeuronDeployment", req)
+					continue
+				}
+				op, err = clients.Products.UpdateNeuronDeployment(cmd.Context(), req)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 			} else {
 				// Update envs if '-e' flag was set.
 				envs := neuronDeployment.GetEnvs()
-				if setNeuronDeploymentEnvFlag {
+				if setNeuronDeploymentEnvFlag || envEditsProvided() {
 					envs, err = askUserNeuronEnvs(neuronDeployment.GetEnvs())
+					if err != nil {
+						return err
+					}
 				}
 
 				pterm.Info.Printf("Updating deployment: %s | v%s ...\n",
-					productDeployment.GetGoogleProjectId(), latestVersion)
+					productDeployment.GetGoogleProjectId(), deployVersion)
 
-				op, err = alisProductsClient.UpdateNeuronDeployment(cmd.Context(), &pbProducts.UpdateNeuronDeploymentRequest{
+				req := &pbProducts.UpdateNeuronDeploymentRequest{
 					NeuronDeployment: &pbProducts.NeuronDeployment{
 						Name:    neuronDeployment.GetName(),
-						Version: latestVersion,
+						Version: deployVersion,
 						Envs:    envs,
 					},
 					UpdateMask: &fieldmaskpb.FieldMask{
 						Paths: []string{"version", "envs"},
 					},
-				})
+				}
+				if dryRunFlag {
+					dryRunRequest("UpdateNeuronDeployment", req)
+					continue
+				}
+				op, err = clients.Products.UpdateNeuronDeployment(cmd.Context(), req)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 			}
 
 			// check if we need to wait for operation to complete.
 			if asyncFlag {
 				pterm.Debug.Printf("GetOperation:\n%s\n", op)
-				pterm.Success.Printf("Launched service in async mode.\n see long-running operation " + op.GetName() + " to monitor state\n")
+				pterm.Success.Printf("Launched service in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
 			} else {
 				// wait for the long-running operation to complete.
 				err := wait(cmd.Context(), op, "Updating "+productDeployment.GetName(), "Updated "+productDeployment.GetName(), 300, true)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 			}
 
@@ -865,6 +984,10 @@ deploys it to one or more environments`),
 			//		Name: productDeployment.GetName() + "/neurons/" + neuronID})
 			//pterm.Info.Printf("Terraform Visualisation:\n%s\n", neuronDeployment.GetInfrastructureUri())
 		}
+		if dryRunFlag {
+			dryRunDone()
+		}
+		return nil
 	},
 }
 
@@ -882,80 +1005,84 @@ official protobufs.'
 Once local development is done, use the '--push' flag to push the generated protocol buffers to the go.protobuf repository.
 Once the protobufs are pushed, you should remove the 'Replace... ' command in your go.mod file and run 'go mod tidy' to pull
 the latest protobufs from the repo into your gRPC service.`),
-	Example: pterm.LightYellow("alis neuron genproto {orgID}.{productID}.{neuronID}"),
-	Args:    validateNeuronArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis neuron genproto {orgID}.{productID}.{neuronID}"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 		neuronID = strings.Split(args[0], ".")[2]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("Get Organisation:\n%s\n", organisation)
 
 		// Retrieve the neuron resource
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
 			&pbProducts.GetNeuronRequest{
 				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("Get Neuron:\n%s\n", neuron)
 
+		if dryRunFlag {
+			dryRunGenerateProtobufs(organisationID, productID, neuronID, false)
+			dryRunDone()
+			return nil
+		}
+
 		// Generate the protocol buffers for Golang
 		if genprotoGo {
 			// set required path variables
-			neuronProtobufFullPath := homeDir + "/alis.exchange/" + organisationID + "/protobuf/go/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-			neuronProtoFullPath := homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-			protobufGoRepoPath := homeDir + "/alis.exchange/" + organisationID + "/protobuf/go"
+			neuronProtobufFullPath := filepath.Join(currentWorkspace().ProtobufGoRepo(organisationID), neuronPath(organisationID, productID, neuronID))
+			neuronProtoFullPath := currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+			protobufGoRepoPath := currentWorkspace().ProtobufGoRepo(organisationID)
 
 			// Clear any uncommitted changes to the repository
 			// This ensures that we are able to push protobuf changes generated in the next section in all scenarios
 			// When working on multiple neurons at the same time, there could be other uncommitted changes which will
 			// cause a merge conflict when committing the new protocol buffers in the push section below.
 			if pushProtocolBuffers {
-				cmds := "git -C " + protobufGoRepoPath + " reset --hard"
-				pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-				out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+				err := clearUncommittedRepoChanges(cmd.Context(), protobufGoRepoPath)
 				if err != nil {
-					pterm.Error.Printf(fmt.Sprintf("%s", out))
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 			}
 
 			// Clear all files in the relevant neuron folder.
 			// TODO: refactor the use of GOPRIVATE envs.
-			cmds := "rm -rf " + neuronProtobufFullPath + " && " +
-				"mkdir -p " + neuronProtobufFullPath + " && " +
-				"go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev && " +
-				"protoc --go_out=$HOME/alis.exchange/" + organisationID + "/protobuf/go --go_opt=paths=source_relative --go-grpc_out=$HOME/alis.exchange/" + organisationID + "/protobuf/go --go-grpc_opt=paths=source_relative -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto " + neuronProtoFullPath + "/*.proto"
-
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+			err := resetDir(neuronProtobufFullPath)
+			if err == nil {
+				err = setGoPrivate(cmd.Context(), organisationID)
+			}
+			var stderr string
+			if err == nil {
+				stderr, err = generateGoProtobufs(cmd.Context(), organisationID, neuronProtoFullPath, protobufGoRepoPath)
+			}
 			if err != nil {
-				pterm.Error.Printf(fmt.Sprintf("%s", out))
-				pterm.Error.Println(err)
-				return
+				return err
 			}
-			if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for go...\n%s", out))
+			if strings.Contains(stderr, "warning") {
+				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for go...\n%s", stderr))
 			}
 			pterm.Success.Printf("Generated protocol buffers for Go.\nProto source: %s\n", neuronProtoFullPath)
 
 			// generate ProductDescriptorFile at product level.
-			err = genProductDescriptorFile("organisations/" + organisationID + "/products/" + productID)
+			err = genProductDescriptorFile(cmd.Context(), "organisations/"+organisationID+"/products/"+productID)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			pterm.Success.Println("Generated Product Descriptor File")
@@ -964,11 +1091,10 @@ the latest protobufs from the repo into your gRPC service.`),
 			if pushProtocolBuffers {
 				// commit protocol buffers in go
 				message := fmt.Sprintf("chore(%s): updated by alis_ CLI", neuronID)
-				_, err := commitTagAndPush(cmd.Context(), protobufGoRepoPath, neuronProtobufFullPath,
+				_, err := commitTagAndPush(cmd.Context(), protobufGoRepoPath, []string{neuronProtobufFullPath},
 					message, "", true, true)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 				pterm.Success.Println("Published protocol buffers for Go")
 
@@ -983,102 +1109,22 @@ the latest protobufs from the repo into your gRPC service.`),
 
 		// generate protocol buffers for Python
 		if genprotoPython {
-			neuronProtobufFullPath := homeDir + "/alis.exchange/" + organisationID + "/protobuf/python/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-			neuronProtoFullPath := homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-
-			// TODO: create __init__.py using golang file io.
-			cmds := "rm -rf " + neuronProtobufFullPath + " && " +
-				"mkdir -p " + neuronProtobufFullPath + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/__init__.py` + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/` + strings.Split(neuronID, "-")[0] + `/__init__.py` + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/` + strings.Split(neuronID, "-")[0] + `/` + strings.Split(neuronID, "-")[1] + `/__init__.py` + " && " +
-				`echo "__import__('pkg_resources').declare_namespace(__name__)" > $HOME/alis.exchange/` + organisationID + `/protobuf/python/` + organisationID + `/` + productID + `/` + strings.Split(neuronID, "-")[0] + `/` + strings.Split(neuronID, "-")[1] + `/` + strings.Split(neuronID, "-")[2] + `/__init__.py` + " && " +
-				"python3 -m grpc_tools.protoc --python_out=$HOME/alis.exchange/" + organisationID + "/protobuf/python --grpc_python_out=$HOME/alis.exchange/" + organisationID + "/protobuf/python -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto " + neuronProtoFullPath + "/*.proto"
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+			neuronProtoFullPath := currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+
+			stderr, initFiles, err := generatePythonProtobufs(cmd.Context(), organisationID, productID, neuronID)
 			if err != nil {
-				pterm.Error.Printf(fmt.Sprintf("%s", out))
-				pterm.Error.Println(err)
-				return
+				return err
 			}
-			if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for python...\n%s", out))
+			if strings.Contains(stderr, "warning") {
+				pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers for python...\n%s", stderr))
 			}
 			pterm.Success.Printf("Generated protocol buffers for Python.\nProto source: %s\n", neuronProtoFullPath)
 
 			// Publish to protobuf repository if not in local mode.
 			if pushProtocolBuffers {
-
-				protobufPythonRepo := fmt.Sprintf("%s/alis.exchange/%s/protobuf/python", homeDir, organisationID)
-
-				// bump setup.py version
-				var tpl bytes.Buffer
-				setupPyTemplate, err := template.ParseFiles(protobufPythonRepo + "/setup.py")
-				if err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				if err = setupPyTemplate.Execute(&tpl, struct{}{}); err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				rel := regexp.MustCompile("version=\"(.*)\",")
-				versionComponents := strings.Split(rel.FindStringSubmatch(tpl.String())[1], ".")
-				minorVersion, err := strconv.Atoi(versionComponents[2])
-				versionComponents[2] = strconv.Itoa(minorVersion + 1)
-				newVersion := rel.ReplaceAllString(tpl.String(), "version=\""+strings.Join(versionComponents, ".")+"\",")
-				out, err = exec.CommandContext(context.Background(), "bash", "-c", "echo "+"'"+newVersion+"'"+" > "+protobufPythonRepo+"/setup.py").CombinedOutput()
+				err = publishPythonProtobufs(cmd.Context(), organisation, productID, neuronID, initFiles)
 				if err != nil {
-					pterm.Error.Printf(fmt.Sprintf("%s", out))
-					pterm.Error.Println(err)
-					return
-				}
-
-				// publish Python package to artifact registry
-				tpl = bytes.Buffer{}
-				publishTemplate, err := TemplateFs.ReadFile("internal/cmd/neuron/python/publishPython.sh")
-				if err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				t, err := template.New("file").Parse(string(publishTemplate))
-				if err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-				if err := t.Execute(&tpl, struct {
-					OrgProjectID   string
-					OrganisationID string
-				}{
-					OrgProjectID:   organisation.GetGoogleProjectId(),
-					OrganisationID: organisationID,
-				}); err != nil {
-					pterm.Error.Println(err)
-					return
-				}
-
-				out, err = exec.CommandContext(context.Background(), "bash", "-c", tpl.String()).CombinedOutput()
-				if err != nil {
-					pterm.Error.Printf(fmt.Sprintf("%s", out))
-					pterm.Error.Println(err)
-					return
-				}
-
-				if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-					pterm.Warning.Print(fmt.Sprintf("Publishing protocol buffers for python...\n%s", out))
-				}
-
-				message := fmt.Sprintf("chore(%s): updated by alis_ CLI", neuronID)
-				commitPath := neuronProtobufFullPath +
-					" " + protobufPythonRepo + "/setup.py" +
-					" " + protobufPythonRepo + "/alis/" + productID + "/__init__.py" +
-					" " + protobufPythonRepo + "/alis/" + productID + "/" + strings.Split(neuronID, "-")[0] + "/__init__.py" +
-					" " + protobufPythonRepo + "/alis/" + productID + "/" + strings.Split(neuronID, "-")[0] + "/" + strings.Split(neuronID, "-")[1] + "/__init__.py"
-
-				_, err = commitTagAndPush(cmd.Context(), protobufPythonRepo, commitPath, message,
-					"", true, true)
-				if err != nil {
-					return
+					return err
 				}
 
 				pterm.Success.Println("Published protocol buffers for Python")
@@ -1089,7 +1135,7 @@ the latest protobufs from the repo into your gRPC service.`),
 			}
 		}
 
-		return
+		return nil
 	},
 }
 
@@ -1100,64 +1146,80 @@ var genApiNeuronCmd = &cobra.Command{
 	Long: pterm.Green(
 		`This method uses the 'gapi-go' command line to generate protocol buffers 
 for the specified neuron`),
-	Example: pterm.LightYellow("alis neuron genapi {orgID}.{productID}.{neuronID}"),
-	Args:    validateNeuronArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis neuron genapi {orgID}.{productID}.{neuronID}"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 		neuronID = strings.Split(args[0], ".")[2]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the neuron resource
-		neuron, err := alisProductsClient.GetNeuron(cmd.Context(),
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
 			&pbProducts.GetNeuronRequest{
 				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
 
 		// Generate the api client libraries buffers.
-		neuronAPIFullPath := homeDir + "/alis.exchange/" + organisationID + "/api/go/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-		neuronProtoFullPath := homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-		cmds := "rm -rf " + neuronAPIFullPath + " && " +
-			"mkdir -p " + neuronAPIFullPath + " && " +
-			"go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev &&" +
-			"protoc --go_gapic_out=$HOME/alis.exchange/" + organisationID + "/api/go --go_gapic_opt='go-gapic-package=" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/") + ";" + strings.Split(neuronID, "-")[2] + "' -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto " + neuronProtoFullPath + "/*.proto"
-
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+		neuronAPIFullPath := filepath.Join(currentWorkspace().APIGoRepo(organisationID), neuronPath(organisationID, productID, neuronID))
+		neuronProtoFullPath := currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+		if dryRunFlag {
+			dryRun("would replace the contents of %s with the Go API client libraries of %s", neuronAPIFullPath, neuronProtoFullPath)
+			if publishApiFlag {
+				dryRun("would commit %s and push it to origin", neuronAPIFullPath)
+			}
+			dryRunDone()
+			return nil
+		}
+		err = resetDir(neuronAPIFullPath)
+		if err == nil {
+			err = setGoPrivate(cmd.Context(), organisationID)
+		}
+		var files []string
+		if err == nil {
+			files, err = protoFiles(neuronProtoFullPath)
+		}
 		if err != nil {
-			pterm.Error.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-		if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-			pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers...\n%s", out))
-		} else {
-			pterm.Debug.Print(fmt.Sprintf("%s\n", out))
+		protocArgs := append([]string{"--go_gapic_out=" + currentWorkspace().APIGoRepo(organisationID),
+			"--go_gapic_opt=go-gapic-package=" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/") + ";" + strings.Split(neuronID, "-")[2]},
+			protoIncludes(organisationID)...)
+		_, stderr, err := run(cmd.Context(), newCommand("protoc", append(protocArgs, files...)...))
+		if err != nil {
+			return err
+		}
+		if strings.Contains(stderr, "warning") {
+			pterm.Warning.Print(fmt.Sprintf("Generating protocol buffers...\n%s", stderr))
 		}
 
 		// Publish to api libraries
 		if publishApiFlag {
 			// commit protocol buffers in go
-			apiGoRepo := fmt.Sprintf("%s/alis.exchange/%s/api/go", homeDir, organisationID)
+			apiGoRepo := currentWorkspace().APIGoRepo(organisationID)
 			message := fmt.Sprintf("chore(%s): updated by alis_ CLI", neuronID)
-			_, err = commitTagAndPush(cmd.Context(), apiGoRepo, neuronAPIFullPath,
+			_, err = commitTagAndPush(cmd.Context(), apiGoRepo, []string{neuronAPIFullPath},
 				message, "", true, true)
 			if err != nil {
-				return
+				return err
 			}
 			ptermTip.Printf("Now that your protobuf if updated, please ensure that you update your \n" +
 				"go.mod file to reflect this new version of your protobuf.\n")
@@ -1166,7 +1228,7 @@ for the specified neuron`),
 				"publish them use the `-p` or `--publish` flag to publish them to the \n" +
 				"protobuf libraries.\n")
 		}
-		return
+		return nil
 	},
 }
 
@@ -1182,11 +1244,29 @@ func init() {
 	neuronCmd.SilenceUsage = true
 	neuronCmd.SilenceErrors = true
 
+	// the neuron, or product, may be left out when running from within its directory.
+	argFromWorkingDir(getNeuronCmd, neuronFromDir)
+	argFromWorkingDir(listNeuronCmd, productFromDir)
+	argFromWorkingDir(buildNeuronCmd, neuronFromDir)
+	argFromWorkingDir(deployNeuronCmd, neuronFromDir)
+	argFromWorkingDir(genprotoNeuronCmd, neuronFromDir)
+	argFromWorkingDir(genApiNeuronCmd, neuronFromDir)
+
 	deployNeuronCmd.Flags().BoolVarP(&setNeuronDeploymentEnvFlag, "env", "e", false, pterm.Green("Set or update the ENV variables."))
 	deployNeuronCmd.Flags().BoolVarP(&setDeployNeuronStateFlag, "state", "s", false, pterm.Green("Update the state of the neuron.."))
+	deployNeuronCmd.Flags().StringVar(&deployVersionFlag, "version", "", pterm.Green("The version to deploy instead of the latest one, for example 1.2.0"))
 
+	addReleaseFlags(buildNeuronCmd)
 	buildNeuronCmd.Flags().BoolVarP(&setUpdateNeuronEnvFlag, "env", "e", false, pterm.Green("Set or update the ENV variables."))
-	buildNeuronCmd.Flags().BoolVarP(&setUpdateNeuronStateFlag, "state", "s", false, pterm.Green("Update the state of the neuron."))
+	buildNeuronCmd.Flags().BoolVar(&allowBreakingFlag, "allow-breaking", false, pterm.Green("Build the version even if its protos break the latest version, see `alis proto breaking`"))
+	buildNeuronCmd.Flags().BoolVar(&skipLintFlag, "skip-lint", false, pterm.Green("Build the version even if its protos do not pass `alis proto lint`"))
+
+	// answers to the prompts, for use in CI pipelines.
+	addEnvAnswerFlags(createNeuronCmd)
+	addEnvAnswerFlags(buildNeuronCmd)
+	addEnvAnswerFlags(deployNeuronCmd)
+	addDeploymentAnswerFlags(deployNeuronCmd)
+	addAnswerFlag(deployNeuronCmd, "deployment-state", "The index of the new state of the neuron deployments, used with --state")
 
 	genprotoNeuronCmd.Flags().BoolVarP(&pushProtocolBuffers, "publish", "p", false, pterm.Green("Generate the protocol buffers and push them to the protobuf repository"))
 
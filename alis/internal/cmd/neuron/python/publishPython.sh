@@ -1,5 +1,5 @@
+# runs within the protobuf/python repository of the organisation
 # setup pip configurations for uploads
-cd $HOME/alis.exchange/{{.OrganisationID}}/protobuf/python || exit
 mkdir $HOME/.config/pip
 echo "[global] index-url = https://europe-west1-python.pkg.dev/{{.OrgProjectID}}/protobuf-python/simple/" > $HOME/.config/pip/pip.conf
 
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	pbOperations "go.protobuf.alis.alis.exchange/alis/os/resources/operations/v1"
+	"google.golang.org/genproto/googleapis/longrunning"
+)
+
+var operationFilterFlag string
+
+// operationCmd represents the operation command
+var operationCmd = &cobra.Command{
+	Use:     "operation",
+	Aliases: []string{"op"},
+	Short:   pterm.Blue("Manages long-running operations."),
+	Long: pterm.Green(`Use this command to monitor the long-running operations launched by the other commands,
+for example when running them with the --async flag.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(operationCmd)
+	operationCmd.SilenceUsage = true
+	operationCmd.SilenceErrors = true
+	operationCmd.AddCommand(getOperationCmd)
+	operationCmd.AddCommand(waitOperationCmd)
+	operationCmd.AddCommand(listOperationCmd)
+	operationCmd.AddCommand(cancelOperationCmd)
+
+	listOperationCmd.Flags().StringVar(&operationFilterFlag, "filter", "", pterm.Green("Only list the operations matching the filter"))
+}
+
+// getOperationCmd represents the get command
+var getOperationCmd = &cobra.Command{
+	Use:   "get",
+	Short: pterm.Blue("Retrieves a long-running operation"),
+	Long: pterm.Green(
+		`This method shows the state of the specified long-running operation, its metadata and,
+once done, the error or the resource it produced.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		op, err := clients.Operations.GetOperation(cmd.Context(), &pbOperations.GetOperationRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+		pterm.Debug.Printf("GetOperation:\n%s\n", op)
+
+		err = printOperation(cmd, op)
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+	Args:    cobra.ExactArgs(1),
+	Example: pterm.LightYellow("alis operation get {operationName}"),
+}
+
+// waitOperationCmd represents the wait command
+var waitOperationCmd = &cobra.Command{
+	Use:   "wait",
+	Short: pterm.Blue("Waits for a long-running operation to complete"),
+	Long: pterm.Green(
+		`This method waits for the specified long-running operation to complete, as the command which
+launched it would have done without the --async flag, and shows the resource it produced.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		op, err := clients.Operations.GetOperation(cmd.Context(), &pbOperations.GetOperationRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+
+		// wait for the long-running operation to complete.
+		op, err = waitOperation(cmd.Context(), op, "Waiting for "+op.GetName(), op.GetName()+" is done", 30*time.Minute, true)
+		if err != nil {
+			return err
+		}
+		pterm.Debug.Printf("GetOperation:\n%s\n", op)
+
+		err = printOperation(cmd, op)
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+	Args:    cobra.ExactArgs(1),
+	Example: pterm.LightYellow("alis operation wait {operationName}"),
+}
+
+// listOperationCmd represents the list command
+var listOperationCmd = &cobra.Command{
+	Use:   "list",
+	Short: pterm.Blue("Lists long-running operations"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		req := &pbOperations.ListOperationsRequest{Filter: operationFilterFlag}
+		if len(args) > 0 {
+			req.Name = args[0]
+		}
+		operations, err := clients.Operations.ListOperations(cmd.Context(), req)
+		if err != nil {
+			return err
+		}
+		pterm.Debug.Printf("ListOperations:\n%s\n", operations.GetOperations())
+
+		if machineOutput() {
+			return printResources(cmd, operations)
+		}
+
+		table := pterm.TableData{{"Index", "Name", "Done", "Error", "Metadata", "Response"}}
+		for i, op := range operations.GetOperations() {
+			table = append(table, []string{
+				strconv.Itoa(i), op.GetName(), strconv.FormatBool(op.GetDone()), op.GetError().GetMessage(),
+				string(op.GetMetadata().MessageName()), string(op.GetResponse().MessageName())})
+		}
+
+		err = renderTable(cmd, table)
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+	Args:    cobra.MaximumNArgs(1),
+	Example: pterm.LightYellow("alis operation list\nalis operation list --filter done=false"),
+}
+
+// cancelOperationCmd represents the cancel command
+var cancelOperationCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: pterm.Blue("Cancels a long-running operation"),
+	Long: pterm.Green(
+		`This method requests the cancellation of the specified long-running operation.  Cancellation
+is best effort; use 'alis operation get' to check whether the operation was cancelled.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		sure, err := confirm("cancel", "Are you sure you want to cancel "+args[0]+"? (y/n): ")
+		if err != nil {
+			return err
+		}
+		if !sure {
+			return aborted("Aborted.\nDid not cancel %s", args[0])
+		}
+
+		_, err = clients.Operations.CancelOperation(cmd.Context(), &pbOperations.CancelOperationRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+		pterm.Success.Printf("Requested the cancellation of %s\n", args[0])
+		return nil
+	},
+	Args:    cobra.ExactArgs(1),
+	Example: pterm.LightYellow("alis operation cancel {operationName}"),
+}
+
+// printOperation prints the state of op, along with its metadata and response decoded into the concrete
+// alis_ OS resources.
+func printOperation(cmd *cobra.Command, op *longrunning.Operation) error {
+	if machineOutput() {
+		return printResources(cmd, op)
+	}
+
+	table := pterm.TableData{{"Name", "Done", "Error"}}
+	table = append(table, []string{op.GetName(), strconv.FormatBool(op.GetDone()), op.GetError().GetMessage()})
+	err := renderTable(cmd, table)
+	if err != nil {
+		return err
+	}
+
+	if op.GetMetadata() != nil {
+		metadata, err := op.GetMetadata().UnmarshalNew()
+		if err != nil {
+			return err
+		}
+		pterm.Info.Printf("Metadata (%s):\n%s\n", op.GetMetadata().MessageName(), metadata)
+	}
+	if op.GetResponse() != nil {
+		response, err := op.GetResponse().UnmarshalNew()
+		if err != nil {
+			return err
+		}
+		pterm.Info.Printf("Response (%s):\n%s\n", op.GetResponse().MessageName(), response)
+	}
+	return nil
+}
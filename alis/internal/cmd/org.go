@@ -1,11 +1,10 @@
 package cmd
 
 import (
-	"fmt"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
-	"os/exec"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -16,8 +15,8 @@ var orgCmd = &cobra.Command{
 	Use:   "org",
 	Short: pterm.Blue("Manages organisations."),
 	Long:  pterm.Green("Use this command to manage an organisation."),
-	Run: func(cmd *cobra.Command, args []string) {
-		pterm.Error.Println("a valid command is missing\nplease run 'alis org -h' for details.")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
 	},
 	//Example: pterm.LightYellow("alis org ali"),
 }
@@ -30,6 +29,10 @@ func init() {
 	orgCmd.AddCommand(getOrgCmd)
 	orgCmd.AddCommand(listOrgCmd)
 	orgCmd.AddCommand(clearOrgCmd)
+
+	addAnswerFlag(createOrgCmd, "domain", "The service domain of the organisation, for example alis.services")
+	addAnswerFlag(createOrgCmd, "folder-id", "The ID of the folder in which to create the organisation's projects")
+	addAnswerFlag(createOrgCmd, "billing-account", "The billing account ID of the organisation")
 }
 
 // createOrgCmd represents the create command
@@ -39,7 +42,7 @@ var createOrgCmd = &cobra.Command{
 	Long:    pterm.Green(`Creates a new organisation.`),
 	Args:    validateOrgArg,
 	Example: pterm.LightYellow("alis org create mycompany"),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = args[0]
 
 		// Prerequisites for creating an organisation
@@ -61,34 +64,35 @@ var createOrgCmd = &cobra.Command{
 			{Level: 2, Text: "Project Creator", TextStyle: pterm.NewStyle(pterm.FgLightWhite), Bullet: "-", BulletStyle: pterm.NewStyle(pterm.FgLightYellow)},
 		}).Render()
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// request domain
-		domain, err := askUserString("Service domain (for example, alis.services, rezco.services): ", `(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9][a-z0-9-]{0,61}[a-z0-9]`)
+		domain, err := ask("domain", "Service domain (for example, alis.services, rezco.services): ", `(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9][a-z0-9-]{0,61}[a-z0-9]`)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// request domain
 		ptermTip.Println("Link to Folders : https://console.cloud.google.com/cloud-resource-manager")
-		folderID, err := askUserString("Folder ID (for example: 123456789123): ", `^\d+$`)
+		folderID, err := ask("folder-id", "Folder ID (for example: 123456789123): ", `^\d+$`)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		ptermTip.Println("Link to billing account: https://console.cloud.google.com/billing")
-		billingAccountID, err := askUserString("Organisation Billing Account ID: ", `^[A-Z0-9]{6}-[A-Z0-9]{6}-[A-Z0-9]{6}$`)
+		billingAccountID, err := ask("billing-account", "Organisation Billing Account ID: ", `^[A-Z0-9]{6}-[A-Z0-9]{6}-[A-Z0-9]{6}$`)
+		if err != nil {
+			return err
+		}
+
+		clients, err := clientsFromContext(cmd.Context())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// Create a new product resource
-		op, err := alisProductsClient.CreateOrganisation(cmd.Context(), &pbProducts.CreateOrganisationRequest{
+		req := &pbProducts.CreateOrganisationRequest{
 			Organisation: &pbProducts.Organisation{
 				DisplayName:    strings.ToTitle(organisationID),
 				State:          pbProducts.Organisation_DEV,
@@ -99,18 +103,23 @@ var createOrgCmd = &cobra.Command{
 				Folder:         "folders/" + folderID,
 			},
 			OrganisationId: organisationID,
-		})
+		}
+		if dryRunFlag {
+			dryRunRequest("CreateOrganisation", req)
+			dryRunDone()
+			return nil
+		}
+		op, err := clients.Products.CreateOrganisation(cmd.Context(), req)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// wait for the long-running operation to complete.
 		err = wait(cmd.Context(), op, "Creating "+organisationID, "Created "+organisationID, 300, true)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
+		return nil
 	},
 }
 
@@ -124,95 +133,82 @@ var getOrgCmd = &cobra.Command{
 'google' is a special type of organisation you could pull to gain local access to 
 its common protocol buffers.  If you are following the Google API design guidelines,
 you most likely will have to run the command: "alis org get google"`),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = args[0]
+		ws := currentWorkspace()
 
 		// Google is a special organisation for which we need to perform a custom proto pull.
 		if organisationID == "google" {
 			// update google common protos.
-			spinner, _ := pterm.DefaultSpinner.Start("Updating " + homeDir + "/google/proto... ")
-			cmds := "git -C $HOME/alis.exchange/google/proto pull --no-rebase || git clone https://github.com/googleapis/googleapis.git $HOME/alis.exchange/google/proto"
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+			googleProtoPath := ws.GoogleProtos()
+			spinner, _ := pterm.DefaultSpinner.Start("Updating " + googleProtoPath + "... ")
+			err := pullOrClone(cmd.Context(), googleProtoPath, newCommand("git", "clone", "https://github.com/googleapis/googleapis.git", googleProtoPath))
 			if err != nil {
-				pterm.Debug.Printf(fmt.Sprintf("%s", out))
-				pterm.Error.Println(err)
-				return
+				return err
 			}
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
-			spinner.Success("Updated " + homeDir + "/alis.exchange/google/proto. ")
-			return
+			spinner.Success("Updated " + googleProtoPath + ". ")
+			return nil
+		}
+
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
 		}
 
 		// Retrieve the organisation resource
-		res, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		res, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", res)
 
 		// Clone the proto repository
-		spinner, _ := pterm.DefaultSpinner.Start("Updating " + homeDir + "/alis.exchange/" + organisationID + "/proto... ")
-		cmds := "git -C $HOME/alis.exchange/" + organisationID + "/proto pull --no-rebase || gcloud source repos clone proto $HOME/alis.exchange/" + organisationID + "/proto --project=" + res.GetGoogleProjectId()
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		repoPath := ws.ProtoRepo(organisationID)
+		spinner, _ := pterm.DefaultSpinner.Start("Updating " + repoPath + "... ")
+		err = pullOrClone(cmd.Context(), repoPath, newCommand("gcloud", "source", "repos", "clone", "proto", repoPath, "--project="+res.GetGoogleProjectId()))
 		if err != nil {
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-		pterm.Debug.Printf(fmt.Sprintf("%s", out))
 
-		spinner.Success("Updated repository " + homeDir + "/alis.exchange/" + organisationID + "/proto. ")
+		spinner.Success("Updated repository " + repoPath + ". ")
 
 		// Clone the protobuf-go repository
-		spinner, _ = pterm.DefaultSpinner.Start("Updating " + homeDir + "/alis.exchange/" + organisationID + "/protobuf/go... ")
-		cmds = "git -C $HOME/alis.exchange/" + organisationID + "/protobuf/go pull --no-rebase || gcloud source repos clone protobuf-go $HOME/alis.exchange/" + organisationID + "/protobuf/go --project=" + res.GetGoogleProjectId()
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		repoPath = ws.ProtobufGoRepo(organisationID)
+		spinner, _ = pterm.DefaultSpinner.Start("Updating " + repoPath + "... ")
+		err = pullOrClone(cmd.Context(), repoPath, newCommand("gcloud", "source", "repos", "clone", "protobuf-go", repoPath, "--project="+res.GetGoogleProjectId()))
 		if err != nil {
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-		pterm.Debug.Printf(fmt.Sprintf("%s", out))
 
-		spinner.Success("Updated repository " + homeDir + "/alis.exchange/" + organisationID + "/protobuf/go. ")
+		spinner.Success("Updated repository " + repoPath + ". ")
 
 		// Clone the api-go repository
-		spinner, _ = pterm.DefaultSpinner.Start("Updating " + homeDir + "/alis.exchange/" + organisationID + "/api/go... ")
-		cmds = "git -C $HOME/alis.exchange/" + organisationID + "/api/go pull --no-rebase || gcloud source repos clone api-go $HOME/alis.exchange/" + organisationID + "/api/go --project=" + res.GetGoogleProjectId()
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		repoPath = ws.APIGoRepo(organisationID)
+		spinner, _ = pterm.DefaultSpinner.Start("Updating " + repoPath + "... ")
+		err = pullOrClone(cmd.Context(), repoPath, newCommand("gcloud", "source", "repos", "clone", "api-go", repoPath, "--project="+res.GetGoogleProjectId()))
 		if err != nil {
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-		pterm.Debug.Printf(fmt.Sprintf("%s", out))
 
-		spinner.Success("Updated repository " + homeDir + "/alis.exchange/" + organisationID + "/api/go. ")
+		spinner.Success("Updated repository " + repoPath + ". ")
 
 		// Clone the protobuf-python repository
-		spinner, _ = pterm.DefaultSpinner.Start("Updating " + homeDir + "/alis.exchange/" + organisationID + "/protobuf/python... ")
-		cmds = "git -C $HOME/alis.exchange/" + organisationID + "/protobuf/python pull --no-rebase || gcloud source repos clone protobuf-python $HOME/alis.exchange/" + organisationID + "/protobuf/python --project=" + res.GetGoogleProjectId()
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		repoPath = ws.ProtobufPythonRepo(organisationID)
+		spinner, _ = pterm.DefaultSpinner.Start("Updating " + repoPath + "... ")
+		err = pullOrClone(cmd.Context(), repoPath, newCommand("gcloud", "source", "repos", "clone", "protobuf-python", repoPath, "--project="+res.GetGoogleProjectId()))
 		if err != nil {
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-		pterm.Debug.Printf(fmt.Sprintf("%s", out))
 
-		spinner.Success("Updated repository " + homeDir + "/alis.exchange/" + organisationID + "/protobuf/python. ")
+		spinner.Success("Updated repository " + repoPath + ". ")
 
 		ptermTip.Println("Are you making use of Google protocol buffers?\nRun `alis org get google` to download a local copy\nof of their common protocol buffers as well.")
+		return nil
 	},
-	Args:    validateOrgArg,
-	Example: pterm.LightYellow("alis org get {organisationID}"),
+	Args:              validateOrgArg,
+	ValidArgsFunction: completeOrgArg,
+	Example:           pterm.LightYellow("alis org get {organisationID}"),
 }
 
 // clearOrgCmd represents the clear command
@@ -226,44 +222,51 @@ This will remove all underlying products, protos, protobufs etc..
 
 Please clear organisations not actively working on - its not great to leave 
 these lying around in your local development environment.`),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
-		orgPath := homeDir + "/alis.exchange/" + organisationID
+		orgPath := currentWorkspace().Org(organisationID)
+		if dryRunFlag {
+			dryRun("would remove %s", orgPath)
+			dryRunDone()
+			return nil
+		}
 		pterm.Warning.Printf("Removing product '%s' from your local environment.\nFolder location: %s\n", organisationID, orgPath)
 		pterm.Warning.Printf("Please also ensure you close any IDEs (pointing to the \norganisation resources (protos, etc) or any underlying \nproducts) you may have open.\n")
-		userInput, err := askUserString("Are you sure? (y/n): ", `^[y|n]$`)
+		sure, err := confirm("clear", "Are you sure? (y/n): ")
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
-		if userInput == "y" {
-			cmds := "rm -rf " + orgPath
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		if sure {
+			err := os.RemoveAll(orgPath)
 			if err != nil {
-				pterm.Error.Printf(fmt.Sprintf("%s", out))
-				return
+				return err
 			}
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
 			pterm.Success.Printf("Removed product `%s` from your local environment.\nFolder removed: %s\n", organisationID, orgPath)
 		} else {
-			pterm.Warning.Printf("Aborted operation.\n Did not remove %s\n", orgPath)
+			return aborted("Aborted operation.\n Did not remove %s", orgPath)
 		}
 
+		return nil
+
 	},
-	Args:    validateOrgArg,
-	Example: pterm.LightYellow("alis org clear {orgID}"),
+	Args:              validateOrgArg,
+	ValidArgsFunction: completeOrgArg,
+	Example:           pterm.LightYellow("alis org clear {orgID}"),
 }
 
 // listOrgCmd represents the list command
@@ -272,17 +275,25 @@ var listOrgCmd = &cobra.Command{
 	Short: pterm.Blue("Lists all organisations"),
 	//Long: pterm.Green(
 	//	`This method lists all the products for a given organisation`),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
 
 		// Retrieve the organisation resource
-		organisations, err := alisProductsClient.ListOrganisations(cmd.Context(),
+		organisations, err := clients.Products.ListOrganisations(cmd.Context(),
 			&pbProducts.ListOrganisationsRequest{})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("ListOrganisations:\n%s\n", organisations.GetOrganisations())
 
+		if machineOutput() {
+			return printResources(cmd, organisations)
+		}
+
 		table := pterm.TableData{{"Index", "OrganisationID", "Display Name", "Owner", "Google Project", "Resource Name", "State", "Updated"}}
 		for i, organisation := range organisations.GetOrganisations() {
 			resourceID := strings.Split(organisation.GetName(), "/")[1]
@@ -293,11 +304,13 @@ var listOrgCmd = &cobra.Command{
 				organisation.GetUpdateTime().AsTime().Format(time.RFC3339)})
 		}
 
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
+		err = renderTable(cmd, table)
 		if err != nil {
-			return
+			return err
 		}
 
+		return nil
+
 	},
 	//Args: validateOrgArg,
 	Example: pterm.LightYellow("alis org list"),
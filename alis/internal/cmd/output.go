@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// The formats supported by the global `--output` flag.  The default, empty, format is the styled console view.
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+)
+
+// outputFormat implements pflag.Value for the `--output` flag, restricting it to the supported formats.
+type outputFormat string
+
+var outputFlag outputFormat
+
+func (o *outputFormat) String() string { return string(*o) }
+
+func (o *outputFormat) Set(v string) error {
+	switch v {
+	case outputJSON, outputYAML, outputTable:
+		*o = outputFormat(v)
+		return nil
+	default:
+		return fmt.Errorf("must be one of %s, %s or %s", outputJSON, outputYAML, outputTable)
+	}
+}
+
+func (o *outputFormat) Type() string { return "format" }
+
+// machineOutput reports whether the resources are to be printed as JSON or YAML instead of the console view.
+func machineOutput() bool {
+	return outputFlag == outputJSON || outputFlag == outputYAML
+}
+
+// printResources writes v to the standard output of cmd as JSON or YAML.  Protocol buffer messages are
+// marshalled using protojson, and may be nested within maps and slices to combine several messages.
+func printResources(cmd *cobra.Command, v interface{}) error {
+	value, err := toJSONValue(v)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	switch outputFlag {
+	case outputYAML:
+		b, err = yaml.Marshal(value)
+	default:
+		b, err = json.MarshalIndent(value, "", "  ")
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(b)
+	return err
+}
+
+// toJSONValue converts v into the generic form produced by encoding/json, such that protocol buffer messages
+// keep their protojson field names and encoding.
+func toJSONValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case proto.Message:
+		if !t.ProtoReflect().IsValid() {
+			return nil, nil
+		}
+		b, err := protojson.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		var res interface{}
+		if err := json.Unmarshal(b, &res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := map[string]interface{}{}
+		for k, e := range t {
+			value, err := toJSONValue(e)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = value
+		}
+		return res, nil
+	}
+
+	// slices of messages, for example []*pbProducts.Neuron
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		res := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			value, err := toJSONValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, value)
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// renderTable renders the table with a header row.  With `--output table` the table is written to the standard
+// output of cmd as plain, tab aligned columns without any colours, which is stable enough to be parsed by scripts.
+func renderTable(cmd *cobra.Command, table pterm.TableData) error {
+	if outputFlag != outputTable {
+		return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	for _, row := range table {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = pterm.RemoveColorFromString(cell)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
@@ -8,8 +8,8 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"io/fs"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"text/template"
@@ -31,9 +31,11 @@ var (
 var productCmd = &cobra.Command{
 	Use:   "product",
 	Short: pterm.Blue("Manages products within your organisation."),
-	Long:  pterm.Green("Use this command to manage products within your organisation."),
-	Run: func(cmd *cobra.Command, args []string) {
-		pterm.Error.Println("a valid command is missing\nplease run 'alis product -h' for details.")
+	Long: pterm.Green("Use this command to manage products within your organisation.\n\n" +
+		"From within the directory of a product, in its product repository or the proto repository,\n" +
+		"the product argument may be left out, for example \"alis product build\"."),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
 	},
 }
 
@@ -43,105 +45,98 @@ var createProductCmd = &cobra.Command{
 	Short: pterm.Blue("Creates a new product"),
 	Long: pterm.Green(
 		`This method creates a new product in the specified organisation`),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// ensure that the product does not yet exist
 		// we perform the check here before asking the user a range of questions - i.e. fail fast ;)
-		_, err = alisProductsClient.GetProduct(cmd.Context(), &pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
+		_, err = clients.Products.GetProduct(cmd.Context(), &pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err == nil {
 			// the resource exists
-			pterm.Error.Printf("the product (%s.%s) already exist.\n", organisationID, productID)
-			return
+			return status.Errorf(codes.AlreadyExists, "the product (%s.%s) already exist.", organisationID, productID)
 		}
 
 		// Get additional user input
-		displayName, err := askUserString("Please provide a Display Name: ", `^[A-Za-z0-9- ]+$`)
+		displayName, err := ask("display-name", "Please provide a Display Name: ", `^[A-Za-z0-9- ]+$`)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
-		owner, err := askUserString(fmt.Sprintf("Please provide an owner who is a user within the organisation (for example name.surname@%s):", organisation.GetDomain()), `(?m)^([a-zA-Z0-9_\-\.]+)@([a-zA-Z0-9_\-\.]+)\.([a-zA-Z]{2,10})$`)
+		owner, err := ask("owner", fmt.Sprintf("Please provide an owner who is a user within the organisation (for example name.surname@%s):", organisation.GetDomain()), `(?m)^([a-zA-Z0-9_\-\.]+)@([a-zA-Z0-9_\-\.]+)\.([a-zA-Z]{2,10})$`)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-		description, err := askUserString("Describe the product: ", `^[A-Za-z0-9- .,_]+$`)
+		description, err := ask("description", "Describe the product: ", `^[A-Za-z0-9- .,_]+$`)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		ptermTip.Println("The organisation has a billing account ID of " + strings.Split(organisation.GetBillingAccount(), "/")[1] + "\nNavigate to https://console.cloud.google.com/billing to see the billing accounts available to you.")
-		billingAccountID, err := askUserString("Product level Billing Account ID: ", `^[A-Z0-9]{6}-[A-Z0-9]{6}-[A-Z0-9]{6}$`)
+		billingAccountID, err := ask("billing-account", "Product level Billing Account ID: ", `^[A-Z0-9]{6}-[A-Z0-9]{6}-[A-Z0-9]{6}$`)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// Get product Template files.
 		// push boiler plate code to local environment
-		files, err := TemplateFs.ReadDir("templates/product")
+		files, err := fs.ReadDir(TemplateFs, "templates/product")
 		if err != nil {
-			return
+			return err
 		}
 		for i, f := range files {
 
-			fileTemplate, err := TemplateFs.ReadFile("templates/product/" + f.Name())
+			fileTemplate, err := fs.ReadFile(TemplateFs, "templates/product/"+f.Name())
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			t, err := template.New(fmt.Sprintf("%v", i)).Parse(string(fileTemplate))
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// A temporary workaround for the .mod file templates.
 			filename := strings.Replace(f.Name(), ".tmpl", "", -1)
 
-			destDir := fmt.Sprintf("%s/alis.exchange/%s/proto/%s/%s", homeDir, organisationID, organisationID, productID)
+			destDir := currentWorkspace().ProductProtos(organisationID, productID)
+			if dryRunFlag {
+				dryRun("would create %s/%s", destDir, filename)
+				continue
+			}
 			err = os.MkdirAll(destDir, os.FileMode(0777))
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			file, err := os.Create(fmt.Sprintf("%s/%s", destDir, filename))
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// set the parameters, the project defaults to {organisation}-{product}-dev
 			p := Parameters{}
 			err = t.Execute(file, p)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			pterm.Info.Printf("Created %s/%s\n", destDir, filename)
 		}
-		pterm.Warning.Printf("The above files have been added to your proto repository.\n" +
-			"but have not yet been committed.\n" +
-			"Make the necessary changes to the files, commit them before running the `alis product build` " +
-			"command.\n")
-
 		// Create a product
-		op, err := alisProductsClient.CreateProduct(cmd.Context(), &pbProducts.CreateProductRequest{
+		req := &pbProducts.CreateProductRequest{
 			Parent: organisation.GetName(),
 			Product: &pbProducts.Product{
 				DisplayName:    displayName,
@@ -150,31 +145,39 @@ var createProductCmd = &cobra.Command{
 				BillingAccount: "billingAccounts/" + billingAccountID,
 			},
 			ProductId: productID,
-		})
+		}
+		if dryRunFlag {
+			dryRunRequest("CreateProduct", req)
+			dryRunDone()
+			return nil
+		}
+		pterm.Warning.Printf("The above files have been added to your proto repository.\n" +
+			"but have not yet been committed.\n" +
+			"Make the necessary changes to the files, commit them before running the `alis product build` " +
+			"command.\n")
+
+		op, err := clients.Products.CreateProduct(cmd.Context(), req)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// check if we need to wait for operation to complete.
 		if asyncFlag {
 			pterm.Debug.Printf("GetOperation:\n%s\n", op)
-			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state\n")
+			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
 		} else {
 			// wait for the long-running operation to complete.
 			err := wait(cmd.Context(), op, "Creating "+organisation.GetName()+"/products/"+productID+" (may take a few minutes)", "Created "+organisation.GetName()+"/products/"+productID, 300, true)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 		}
 
 		// Get a product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: organisation.GetName() + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// display some user instructions to perform once a new product has been created.
@@ -185,6 +188,7 @@ var createProductCmd = &cobra.Command{
 		pterm.Println("👉 Your product has a new service account")
 		pterm.Printf("👉 Retrieve a copy of your repository using the command: " + pterm.LightYellow(fmt.Sprintf("alis product get %s.%s \n", organisationID, productID)))
 		pterm.Println("👉 Open the repository in your IDE and create your first empty commit.")
+		return nil
 	},
 	Args:    validateProductArg,
 	Example: pterm.LightYellow("alis product create foo.aa"),
@@ -197,47 +201,46 @@ var getProductCmd = &cobra.Command{
 	Long: pterm.Green(
 		`This method clones or updates the specified product repository to your local environment
 under the 'alis.exchange' directory.`),
-	Args:    validateProductArg,
-	Example: pterm.LightYellow("alis product get {orgID}.{productID}"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	Example:           pterm.LightYellow("alis product get {orgID}.{productID}"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// Clone the product repository
-		spinner, _ := pterm.DefaultSpinner.Start("Updating " + homeDir + "/alis.exchange/" + organisationID + "/products/" + productID + "... ")
-		cmds := "git -C $HOME/alis.exchange/" + organisationID + "/products/" + productID + " pull --no-rebase || gcloud source repos clone product." + productID + " $HOME/alis.exchange/" + organisationID + "/products/" + productID + " --project=" + organisation.GetGoogleProjectId()
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		productPath := currentWorkspace().ProductRepo(organisationID, productID)
+		spinner, _ := pterm.DefaultSpinner.Start("Updating " + productPath + "... ")
+		err = pullOrClone(cmd.Context(), productPath, newCommand("gcloud", "source", "repos", "clone", "product."+productID, productPath, "--project="+organisation.GetGoogleProjectId()))
 		if err != nil {
-			pterm.Error.Printf(fmt.Sprintf("%s", out))
-			return
-		}
-		if strings.Contains(string(out), "Already") {
-			//fmt.Printf("\033[32m%s\033[0m", out)
-		} else {
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
+			spinner.Fail()
+			return err
 		}
-		spinner.Success("Updated " + homeDir + "/alis.exchange/" + organisationID + "/products/" + productID)
+		spinner.Success("Updated " + productPath)
 		ptermTip.Printf("Now that you have a local copy of the product, you may need to generate a key.\n" +
 			"run `alis product getkey " + organisationID + "." + productID + "` to generate one.\n")
+		return nil
 	},
 }
 
@@ -249,55 +252,61 @@ var clearProductCmd = &cobra.Command{
 		`This method removes the specified product from your local environment. 
 Please clear products not actively working on - its not great to leave these lying 
 around in your local development environment.`),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
-		productPath := homeDir + "/alis.exchange/" + organisationID + "/products/" + productID
+		productPath := currentWorkspace().ProductRepo(organisationID, productID)
+		if dryRunFlag {
+			dryRun("would remove %s", productPath)
+			dryRunDone()
+			return nil
+		}
 		pterm.Warning.Printf("Removing product '%s.%s' from your local environment.\nFolder location: %s\nPlease also ensure you close this product in any IDEs you may have open.\n", organisationID, productID, productPath)
-		userInput, err := askUserString("Are you sure? (y/n): ", `^[y|n]$`)
+		sure, err := confirm("clear", "Are you sure? (y/n): ")
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
-		if userInput == "y" {
-			cmds := "rm -rf " + productPath
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		if sure {
+			err := os.RemoveAll(productPath)
 			if err != nil {
-				pterm.Error.Printf(fmt.Sprintf("%s", out))
-				return
+				return err
 			}
-			pterm.Debug.Printf(fmt.Sprintf("%s", out))
 			pterm.Success.Printf("Removed product `%s.%s` from your local environment.\nFolder removed: %s\n", organisationID, productID, productPath)
 		} else {
-			pterm.Warning.Printf("Aborted operation.\n Did not remove %s\n", productPath)
+			return aborted("Aborted operation.\n Did not remove %s", productPath)
 		}
 
+		return nil
+
 	},
-	Args:    validateProductArg,
-	Example: pterm.LightYellow("alis product clear {orgID}.{productID}"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	Example:           pterm.LightYellow("alis product clear {orgID}.{productID}"),
 }
 
 // listProductCmd represents the list command
@@ -306,28 +315,36 @@ var listProductCmd = &cobra.Command{
 	Short: pterm.Blue("Lists all products in a given organisation"),
 	//Long: pterm.Green(
 	//	`This method lists all the products for a given organisation`),
-	Args:    validateOrgArg,
-	Example: pterm.LightYellow("alis product list {orgID}"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              validateOrgArg,
+	ValidArgsFunction: completeOrgArg,
+	Example:           pterm.LightYellow("alis product list {orgID}"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		products, err := alisProductsClient.ListProducts(cmd.Context(),
+		products, err := clients.Products.ListProducts(cmd.Context(),
 			&pbProducts.ListProductsRequest{
 				Parent: "organisations/" + organisationID,
 			})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
+		}
+
+		if machineOutput() {
+			return printResources(cmd, products)
 		}
 
 		table := pterm.TableData{{"Index", "Product ID", "Display Name", "Version", "Owner", "Google Project", "Resource Name"}}
@@ -338,11 +355,13 @@ var listProductCmd = &cobra.Command{
 				product.GetOwner(), product.GetGoogleProjectId(), product.GetName()})
 		}
 
-		err = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
+		err = renderTable(cmd, table)
 		if err != nil {
-			return
+			return err
 		}
 
+		return nil
+
 	},
 }
 
@@ -352,24 +371,35 @@ var treeProductCmd = &cobra.Command{
 	Short: pterm.Blue("Show a tree diagram of the product, its neurons and deployments"),
 	//Long: pterm.Green(
 	//	`This method lists all the products for a given organisation`),
-	Args:    validateProductArg,
-	Example: pterm.LightYellow("alis product tree {orgID}.{productID}"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	Example:           pterm.LightYellow("alis product tree {orgID}.{productID}"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		tree := pterm.LeveledList{}
 		tree = append(tree, pterm.LeveledListItem{Level: 0, Text: "Products:"})
 
+		// the same tree as resources for `--output json|yaml` and as flat rows for `--output table`.
+		neuronsOut, deploymentsOut := []interface{}{}, []interface{}{}
+		table := pterm.TableData{{"Type", "Resource Name", "Version", "State", "Update Time"}}
+		table = append(table, []string{"product", product.GetName(), product.GetVersion(), product.GetState().String(),
+			product.GetUpdateTime().AsTime().Format(time.RFC3339)})
+
 		productEntry := fmt.Sprintf("%s - %s | %s | %s | %s", strings.ToUpper(productID), product.GetDisplayName(), product.GetVersion(), product.GetState(), product.GetOwner())
 		switch product.GetState() {
 		case pbProducts.Product_FAILED:
@@ -385,7 +415,7 @@ var treeProductCmd = &cobra.Command{
 		tree = append(tree, pterm.LeveledListItem{Level: 1, Text: productEntry})
 
 		// append Neurons
-		neurons, err := alisProductsClient.ListNeurons(cmd.Context(), &pbProducts.ListNeuronsRequest{
+		neurons, err := clients.Products.ListNeurons(cmd.Context(), &pbProducts.ListNeuronsRequest{
 			Parent: product.GetName(),
 		})
 
@@ -395,13 +425,12 @@ var treeProductCmd = &cobra.Command{
 		for i, neuron := range neurons.GetNeurons() {
 
 			// Retrieve the latest version
-			res, err := alisProductsClient.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+			res, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
 				Parent:   neuron.GetName(),
 				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version", "state", "update_time"}},
 			})
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			var neuronVersion *pbProducts.NeuronVersion
@@ -425,18 +454,19 @@ var treeProductCmd = &cobra.Command{
 			neuronEntry += pterm.Gray(fmt.Sprintf(" | %s", pterm.Gray(neuron.GetEnvs())))
 
 			tree = append(tree, pterm.LeveledListItem{Level: 3, Text: neuronEntry})
+			neuronsOut = append(neuronsOut, map[string]interface{}{"neuron": neuron, "latestVersion": neuronVersion})
+			table = append(table, []string{"neuron", neuron.GetName(), neuronVersion.GetVersion(), neuronVersion.GetState().String(),
+				neuronVersion.GetUpdateTime().AsTime().Format(time.RFC3339)})
 		}
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// append Deployments
 		tree = append(tree, pterm.LeveledListItem{Level: 2, Text: pterm.Gray("Deployed Products:")})
-		productDeployments, err := alisProductsClient.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{Parent: product.GetName()})
+		productDeployments, err := clients.Products.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{Parent: product.GetName()})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		for i, productDeployment := range productDeployments.GetProductDeployments() {
 			productDeploymentEntry := fmt.Sprintf("%v: %s | %s | %s | %s | %s | %s | %s", i, productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId(), productDeployment.GetVersion(), productDeployment.GetState(), productDeployment.GetUpdateTime().AsTime().Format(time.RFC822), productDeployment.GetOwner(), strings.Split(productDeployment.GetName(), "/")[5])
@@ -454,12 +484,13 @@ var treeProductCmd = &cobra.Command{
 			}
 
 			tree = append(tree, pterm.LeveledListItem{Level: 3, Text: productDeploymentEntry})
+			table = append(table, []string{"product_deployment", productDeployment.GetName(), productDeployment.GetVersion(),
+				productDeployment.GetState().String(), productDeployment.GetUpdateTime().AsTime().Format(time.RFC3339)})
 			// Add neurons to deployment
 			//tree = append(tree, pterm.LeveledListItem{Level: 4, Text: pterm.Gray("Deployed Neurons:")})
-			neuronDeployments, err := alisProductsClient.ListNeuronDeployments(cmd.Context(), &pbProducts.ListNeuronDeploymentsRequest{Parent: productDeployment.GetName()})
+			neuronDeployments, err := clients.Products.ListNeuronDeployments(cmd.Context(), &pbProducts.ListNeuronDeploymentsRequest{Parent: productDeployment.GetName()})
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			for i, neuronDeployment := range neuronDeployments.GetNeuronDeployments() {
 
@@ -485,15 +516,27 @@ var treeProductCmd = &cobra.Command{
 				neuronDeploymentEntry += pterm.Gray(fmt.Sprintf(" | %s", neuronDeployment.GetEnvs()))
 
 				tree = append(tree, pterm.LeveledListItem{Level: 4, Text: neuronDeploymentEntry})
+				table = append(table, []string{"neuron_deployment", neuronDeployment.GetName(), neuronDeployment.GetVersion(),
+					neuronDeployment.GetState().String(), neuronDeployment.GetUpdateTime().AsTime().Format(time.RFC3339)})
 			}
+			deploymentsOut = append(deploymentsOut, map[string]interface{}{
+				"deployment": productDeployment, "neuronDeployments": neuronDeployments.GetNeuronDeployments()})
+		}
+
+		switch {
+		case machineOutput():
+			return printResources(cmd, map[string]interface{}{
+				"product": product, "neurons": neuronsOut, "deployments": deploymentsOut})
+		case outputFlag == outputTable:
+			return renderTable(cmd, table)
 		}
 
 		root := pterm.NewTreeFromLeveledList(tree)
 		err = pterm.DefaultTree.WithRoot(root).Render()
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
+		return nil
 	},
 }
 
@@ -505,76 +548,76 @@ var buildProductCmd = &cobra.Command{
 		`This method retrieves the current version of the product and increments it in line 
 with semantic versioning.  This also ensures that the product is inline with its infrastructure
 specification as determined by alis.exchange.`),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
-		// TODO: handle the scenario where user provides a specific version.
-		newVersion, err := bumpVersion(product.GetVersion(), releaseType)
+		newVersion, err := releaseVersion(product.GetVersion())
+		if err != nil {
+			return err
+		}
 
 		for {
 			pterm.Info.Printf("Updating from version " + product.GetVersion() + " to version " + newVersion + "...\n")
 
 			// tag product repository
 			tag := fmt.Sprintf("%s.%s.%s", organisationID, productID, newVersion)
-			repoPath := fmt.Sprintf("%s/alis.exchange/%s/products/%s", homeDir, organisationID, productID)
-			commitPath := fmt.Sprintf("%s/alis.exchange/%s/products/%s", homeDir, organisationID, productID)
+			repoPath := currentWorkspace().ProductRepo(organisationID, productID)
+			commitPath := currentWorkspace().ProductRepo(organisationID, productID)
 			message := fmt.Sprintf("update(%s.%s): %s", organisationID, productID, newVersion)
-			_, err = commitTagAndPush(cmd.Context(), repoPath, commitPath, message, tag, false, false)
+			_, err = commitTagAndPush(cmd.Context(), repoPath, []string{commitPath}, message, tag, false, false)
 			// handle the case when the version already exists
 			// ask whether the user would like to bump to the next version
 			if status.Code(err) == codes.AlreadyExists {
 				//pterm.Warning.Println(err)
-				newVersion, err = bumpVersion(newVersion, "patch")
+				newVersion, err = nextVersion(newVersion)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
-				input, err := askUserString(fmt.Sprintf("Bump to version %s and continue (y|n)?: ", newVersion), `^[y|n]$`)
+				bump, err := confirm("bump-version", fmt.Sprintf("Bump to version %s and continue (y|n)?: ", newVersion))
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
-				if input == "y" {
-					break
-				} else {
-					pterm.Warning.Println("Aborting operation")
-					return
+				if !bump {
+					return aborted("Aborting operation, version %s was not built", newVersion)
 				}
+				// tag both repositories with the next version.
+				continue
 			}
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// tag proto repository
-			repoPath = fmt.Sprintf("%s/alis.exchange/%s/proto", homeDir, organisationID)
-			commitPath = fmt.Sprintf("%s/alis.exchange/%s/proto/%s/%s", homeDir, organisationID, organisationID, productID)
+			repoPath = currentWorkspace().ProtoRepo(organisationID)
+			commitPath = currentWorkspace().ProductProtos(organisationID, productID)
 			message = fmt.Sprintf("update(%s.%s): %s", organisationID, productID, newVersion)
-			_, err = commitTagAndPush(cmd.Context(), repoPath, commitPath, message, tag, false, false)
+			_, err = commitTagAndPush(cmd.Context(), repoPath, []string{commitPath}, message, tag, false, false)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 			break
 		}
@@ -582,7 +625,7 @@ specification as determined by alis.exchange.`),
 		// Updating Product
 		//spinner, _ := pterm.DefaultSpinner.Start("Updating from version " + product.GetVersion() + " to version " + newVersion)
 
-		op, err := alisProductsClient.UpdateProduct(cmd.Context(), &pbProducts.UpdateProductRequest{
+		req := &pbProducts.UpdateProductRequest{
 			Product: &pbProducts.Product{
 				Name:    "organisations/" + organisationID + "/products/" + productID,
 				Version: newVersion,
@@ -590,27 +633,33 @@ specification as determined by alis.exchange.`),
 			UpdateMask: &fieldmaskpb.FieldMask{
 				Paths: []string{"version"},
 			},
-		})
+		}
+		if dryRunFlag {
+			dryRunRequest("UpdateProduct", req)
+			dryRunDone()
+			return nil
+		}
+		op, err := clients.Products.UpdateProduct(cmd.Context(), req)
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		// check if we need to wait for operation to complete.
 		if asyncFlag {
 			pterm.Debug.Printf("GetOperation:\n%s\n", op)
-			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state\n")
+			pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
 		} else {
 			// wait for the long-running operation to complete.
 			err := wait(cmd.Context(), op, "Updating "+product.GetName(), "Updated "+product.GetName(), 300, true)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 		}
+		return nil
 	},
-	Args:    validateProductArg,
-	Example: pterm.LightYellow("alis product build {orgID}.{productID}"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	Example:           pterm.LightYellow("alis product build {orgID}.{productID}"),
 }
 
 // deployProductCmd represents the get command
@@ -620,35 +669,38 @@ var deployProductCmd = &cobra.Command{
 	Long: pterm.Green(
 		`This method retrieves the latest version of the product and
 deploys it to one or more environments`),
-	Args:    validateProductArg,
-	Example: pterm.LightYellow("alis product deploy {orgID}.{productID}"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	Example:           pterm.LightYellow("alis product deploy {orgID}.{productID}"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// ask the user to select one or more deployments
 		productDeployments, err := selectProductDeployments(cmd.Context(), product.GetName())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		for _, productDeployment := range productDeployments {
@@ -657,24 +709,26 @@ deploys it to one or more environments`),
 			// don't update if the deployment already reflects the latest product version.
 			if productDeployment.GetVersion() == product.GetVersion() {
 				pterm.Warning.Printf("the deployment %s is running the latest product version of %s\n", productDeployment.GetGoogleProjectId(), product.GetVersion())
-				input, err := askUserString("Still continue (y|n)?: ", `^[y|n]$`)
+				redeploy, err := confirm("redeploy", "Still continue (y|n)?: ")
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
-				if input == "n" {
+				if !redeploy {
 					continue
 				}
 			}
 
 			// Update envs if '-e' flag was set.
 			envs := productDeployment.GetEnvs()
-			if setDeployProductEnvFlag {
+			if setDeployProductEnvFlag || envEditsProvided() {
 				envs, err = askUserProductEnvs(productDeployment.GetEnvs())
+				if err != nil {
+					return err
+				}
 			}
 
 			pterm.Info.Printf("Updating deployment: %s\nversion: %s -> %s...\n", productDeployment.GetGoogleProjectId(), productDeployment.GetVersion(), product.GetVersion())
-			op, err := alisProductsClient.UpdateProductDeployment(cmd.Context(), &pbProducts.UpdateProductDeploymentRequest{
+			req := &pbProducts.UpdateProductDeploymentRequest{
 				ProductDeployment: &pbProducts.ProductDeployment{
 					Name:    productDeployment.GetName(),
 					Version: product.GetVersion(),
@@ -683,22 +737,25 @@ deploys it to one or more environments`),
 				UpdateMask: &fieldmaskpb.FieldMask{
 					Paths: []string{"version", "envs"},
 				},
-			})
+			}
+			if dryRunFlag {
+				dryRunRequest("UpdateProductDeployment", req)
+				continue
+			}
+			op, err := clients.Products.UpdateProductDeployment(cmd.Context(), req)
 			if err != nil {
-				pterm.Error.Println(err)
-				return
+				return err
 			}
 
 			// check if we need to wait for operation to complete.
 			if asyncFlag {
 				pterm.Debug.Printf("GetOperation:\n%s\n", op)
-				pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state\n")
+				pterm.Success.Printf("Launched Update in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
 			} else {
 				// wait for the long-running operation to complete.
 				err := wait(cmd.Context(), op, "Updating "+productDeployment.GetName(), "Updated "+productDeployment.GetName(), 300, true)
 				if err != nil {
-					pterm.Error.Println(err)
-					return
+					return err
 				}
 			}
 			//// show link to Rover Visualisation
@@ -709,6 +766,10 @@ deploys it to one or more environments`),
 			//}
 			//pterm.Info.Printf("Terraform Visualisation:\n%s\n", productDeployment.GetInfrastructureUri())
 		}
+		if dryRunFlag {
+			dryRunDone()
+		}
+		return nil
 	},
 }
 
@@ -718,59 +779,61 @@ var getkeyProductCmd = &cobra.Command{
 	Short: pterm.Blue("Retrieves a service account key product"),
 	Long: pterm.Green(
 		`This method uses the gcloud command to create a key.`),
-	Args:    validateProductArg,
-	Example: pterm.LightYellow("alis product getkey {orgID}.{productID}"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	Example:           pterm.LightYellow("alis product getkey {orgID}.{productID}"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
 			// TODO: handle not found by listing available organisations.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
 			// TODO: handle not found by listing available products.
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
 		// ask the user to select a deployment
 		productDeployments, err := selectProductDeployments(cmd.Context(), product.GetName())
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 
 		for _, productDeployment := range productDeployments {
 			// Generate a token
 			spinner, _ := pterm.DefaultSpinner.Start("Generating token for " + productDeployment.GetGoogleProjectId() + "... ")
-			cmds := "gcloud iam service-accounts keys create $HOME/alis.exchange/" + organisationID + "/products/" + productID +
-				"/key-" + productDeployment.GetGoogleProjectId() + ".json --iam-account=alis-exchange@" +
-				productDeployment.GetGoogleProjectId() + ".iam.gserviceaccount.com --project=" +
-				productDeployment.GetGoogleProjectId()
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+			_, _, err := run(cmd.Context(), newCommand("gcloud", "iam", "service-accounts", "keys", "create",
+				currentWorkspace().ProductRepo(organisationID, productID)+"/key-"+productDeployment.GetGoogleProjectId()+".json",
+				"--iam-account=alis-exchange@"+productDeployment.GetGoogleProjectId()+".iam.gserviceaccount.com",
+				"--project="+productDeployment.GetGoogleProjectId()))
 			if err != nil {
-				pterm.Debug.Println(cmds)
-				spinner.Fail(fmt.Sprintf("%s", out))
-				return
+				spinner.Fail()
+				return err
 			}
-			spinner.Success("Retrieved Token: alis-exchange@" + productDeployment.GetGoogleProjectId() + ".iam.gserviceaccount.com\nSaved at: " + homeDir + "/alis.exchange/" + organisationID + "/products/" + productID + "\n")
+			spinner.Success("Retrieved Token: alis-exchange@" + productDeployment.GetGoogleProjectId() + ".iam.gserviceaccount.com\nSaved at: " + currentWorkspace().ProductRepo(organisationID, productID) + "\n")
 			ptermTip.Printf("In your IDE, ensure that you have the following environmental variable set:\n" +
 				"GOOGLE_APPLICATION_CREDENTIALS=../../../key-" + productDeployment.GetGoogleProjectId() + ".json\n")
 		}
 		pterm.Warning.Println("as always don't leave these lying around ;)")
 
+		return nil
+
 	},
 }
 
@@ -784,60 +847,53 @@ specified product.
 
 It makes use of protoc-gen-doc plugin. Installation requirements:
 https://github.com/pseudomuto/protoc-gen-doc#installation`),
-	Example: pterm.LightYellow("alis product gendocs {orgID}.{productID}"),
-	Args:    validateProductArg,
-	Run: func(cmd *cobra.Command, args []string) {
+	Example:           pterm.LightYellow("alis product gendocs {orgID}.{productID}"),
+	Args:              validateProductArg,
+	ValidArgsFunction: completeProductArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		organisationID = strings.Split(args[0], ".")[0]
 		productID = strings.Split(args[0], ".")[1]
 
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
 		// Retrieve the organisation resource
-		organisation, err := alisProductsClient.GetOrganisation(cmd.Context(),
+		organisation, err := clients.Products.GetOrganisation(cmd.Context(),
 			&pbProducts.GetOrganisationRequest{Name: "organisations/" + organisationID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetOrganisation:\n%s\n", organisation)
 
 		// Retrieve the product resource
-		product, err := alisProductsClient.GetProduct(cmd.Context(),
+		product, err := clients.Products.GetProduct(cmd.Context(),
 			&pbProducts.GetProductRequest{Name: "organisations/" + organisationID + "/products/" + productID})
 		if err != nil {
-			pterm.Error.Println(err)
-			return
+			return err
 		}
 		pterm.Debug.Printf("GetProduct:\n%s\n", product)
 
-		// Generate the index.html
-		cmds := "go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev &&" +
-			"protoc --plugin=protoc-gen-doc=$HOME/go/bin/protoc-gen-doc --doc_out=$HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " --doc_opt=html,docs.html -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto $(find $HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " -iname \"*.proto\")"
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		err = setGoPrivate(cmd.Context(), organisationID)
 		if err != nil {
-			pterm.Error.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
 		}
-
-		// Generate markdown
-		cmds = "go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev &&" +
-			"protoc --plugin=protoc-gen-doc=$HOME/go/bin/protoc-gen-doc --doc_out=$HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " --doc_opt=markdown,docs.md -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto $(find $HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " -iname \"*.proto\")"
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
-		if err != nil {
-			pterm.Error.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
-		}
-		// Generate json
-		cmds = "go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev &&" +
-			"protoc --plugin=protoc-gen-doc=$HOME/go/bin/protoc-gen-doc --doc_out=$HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " --doc_opt=json,docs.json -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto $(find $HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " -iname \"*.proto\")"
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		productProtoPath := currentWorkspace().ProductProtos(organisationID, productID)
+		files, err := findProtoFiles(productProtoPath)
 		if err != nil {
-			pterm.Error.Printf(fmt.Sprintf("%s", out))
-			pterm.Error.Println(err)
-			return
+			return err
+		}
+
+		// Generate the index.html, markdown and json
+		var stderr string
+		for _, docOpt := range []string{"html,docs.html", "markdown,docs.md", "json,docs.json"} {
+			protocArgs := append([]string{"--plugin=protoc-gen-doc=" + homeDir + "/go/bin/protoc-gen-doc",
+				"--doc_out=" + productProtoPath, "--doc_opt=" + docOpt}, protoIncludes(organisationID)...)
+			_, stderr, err = run(cmd.Context(), newCommand("protoc", append(protocArgs, files...)...))
+			if err != nil {
+				return err
+			}
 		}
 
 		//// Generate openapi description
@@ -850,15 +906,13 @@ https://github.com/pseudomuto/protoc-gen-doc#installation`),
 		//	return
 		//}
 
-		if strings.Contains(fmt.Sprintf("%s", out), "warning") {
-			pterm.Warning.Print(fmt.Sprintf("Generating documentation from protos...\n%s", out))
-		} else {
-			pterm.Debug.Print(fmt.Sprintf("%s\n", out))
+		if strings.Contains(stderr, "warning") {
+			pterm.Warning.Print(fmt.Sprintf("Generating documentation from protos...\n%s", stderr))
 		}
 
-		pterm.Success.Printf("Generated documentation at %s\n", homeDir+"/alis.exchange/"+organisationID+"/products/"+productID)
+		pterm.Success.Printf("Generated documentation at %s\n", currentWorkspace().ProductRepo(organisationID, productID))
 
-		return
+		return nil
 	},
 }
 
@@ -873,11 +927,28 @@ func init() {
 	productCmd.AddCommand(deployProductCmd)
 	productCmd.AddCommand(getkeyProductCmd)
 	productCmd.AddCommand(gendocsProductCmd)
+
+	// the product may be left out when running from within its directory.
+	argFromWorkingDir(getProductCmd, productFromDir)
+	argFromWorkingDir(treeProductCmd, productFromDir)
+	argFromWorkingDir(buildProductCmd, productFromDir)
+	argFromWorkingDir(deployProductCmd, productFromDir)
+	argFromWorkingDir(getkeyProductCmd, productFromDir)
+	argFromWorkingDir(gendocsProductCmd, productFromDir)
 	productCmd.SilenceUsage = true
 	productCmd.SilenceErrors = true
 
-	buildProductCmd.Flags().StringVarP(&releaseType, "release", "r", "patch", pterm.Green("The update type, one of patch, minor & major"))
+	addReleaseFlags(buildProductCmd)
 	deployProductCmd.Flags().BoolVarP(&setDeployProductEnvFlag, "env", "e", false, pterm.Green("Set or update the ENV variables for the relevant deployment"))
+
+	// answers to the prompts, for use in CI pipelines.
+	addAnswerFlag(createProductCmd, "display-name", "The display name of the product")
+	addAnswerFlag(createProductCmd, "owner", "The owner (email) of the product")
+	addAnswerFlag(createProductCmd, "description", "A description of the product")
+	addAnswerFlag(createProductCmd, "billing-account", "The billing account ID of the product")
+	addDeploymentAnswerFlags(deployProductCmd)
+	addEnvAnswerFlags(deployProductCmd)
+	addDeploymentAnswerFlags(getkeyProductCmd)
 }
 
 //// buildProduct builds a new version of the neuron in the development deployment/project.
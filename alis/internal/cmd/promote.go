@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var promoteEnvsFlag bool
+
+// promoteNeuronCmd represents the promote command
+var promoteNeuronCmd = &cobra.Command{
+	Use:   "promote",
+	Short: pterm.Blue("Promotes the version of a neuron from one deployment to others"),
+	Long: pterm.Green(
+		`This method reads the version and environment variables of the neuron in the source product
+deployment, shows how they differ from the target deployments, and deploys the same version
+to the targets, such that exactly what was tested is shipped.
+
+Only the version is changed by default, the environment variables of the targets are kept.
+Use --envs to copy the environment variables of the source as well.`),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	Example:           pterm.LightYellow("alis neuron promote {orgID}.{productID}.{neuronID} --from dev --to prod\nalis neuron promote alis.in.resources-events-v1 --from in-dev-abc --to in-prod-def --envs"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		organisationID = strings.Split(args[0], ".")[0]
+		productID = strings.Split(args[0], ".")[1]
+		neuronID = strings.Split(args[0], ".")[2]
+
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		// Retrieve the neuron resource
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
+			&pbProducts.GetNeuronRequest{
+				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
+		if err != nil {
+			return err
+		}
+		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
+
+		// ask the user to select the source and target deployments
+		from, to, err := selectPromoteDeployments(cmd, "organisations/"+organisationID+"/products/"+productID)
+		if err != nil {
+			return err
+		}
+
+		// Retrieve the neuron deployments, the source first.
+		names := []string{from.GetName() + "/neurons/" + neuronID}
+		for _, productDeployment := range to {
+			names = append(names, productDeployment.GetName()+"/neurons/"+neuronID)
+		}
+		neuronDeployments, err := getNeuronDeployments(cmd.Context(), clients, names)
+		if err != nil {
+			return err
+		}
+		source := neuronDeployments[0]
+		if source.GetName() == "" {
+			return status.Errorf(codes.FailedPrecondition, "%s is not deployed to %s (%s)",
+				neuron.GetName(), from.GetDisplayName(), from.GetGoogleProjectId())
+		}
+		pterm.Info.Printf("%s (%s) runs v%s\n", from.GetDisplayName(), from.GetGoogleProjectId(), source.GetVersion())
+
+		// show the differences and collect the deployments which need an update.
+		var updates []*pbProducts.UpdateNeuronDeploymentRequest
+		var targets []*pbProducts.ProductDeployment
+		for i, productDeployment := range to {
+			target := neuronDeployments[i+1]
+			if target.GetName() == "" {
+				pterm.Warning.Printf("This neuron has not yet been deployed to %s (%s), skipping it.  Use `alis neuron deploy --version %s` to create it\n",
+					productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId(), source.GetVersion())
+				continue
+			}
+
+			pterm.DefaultSection.Printf("%s (%s) -> %s (%s)", from.GetDisplayName(), from.GetGoogleProjectId(),
+				productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId())
+			diff := neuronDeploymentDiff(source, target)
+			if len(diff) == 1 {
+				pterm.Info.Println("No differences")
+			} else if err := renderTable(cmd, diff); err != nil {
+				return err
+			}
+
+			req := &pbProducts.UpdateNeuronDeploymentRequest{
+				NeuronDeployment: &pbProducts.NeuronDeployment{
+					Name:    target.GetName(),
+					Version: source.GetVersion(),
+				},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"version"}},
+			}
+			envsChanged := promoteEnvsFlag && !equalEnvs(source.GetEnvs(), target.GetEnvs())
+			if envsChanged {
+				req.NeuronDeployment.Envs = source.GetEnvs()
+				req.UpdateMask.Paths = append(req.UpdateMask.Paths, "envs")
+			} else if !equalEnvs(source.GetEnvs(), target.GetEnvs()) {
+				pterm.Info.Println("The environment variables are kept, use --envs to copy them as well")
+			}
+			if target.GetVersion() == source.GetVersion() && !envsChanged {
+				pterm.Info.Printf("%s already runs v%s\n", productDeployment.GetGoogleProjectId(), source.GetVersion())
+				continue
+			}
+			updates = append(updates, req)
+			targets = append(targets, productDeployment)
+		}
+		if len(updates) == 0 {
+			pterm.Success.Println("Nothing to promote")
+			return nil
+		}
+
+		if dryRunFlag {
+			for _, req := range updates {
+				dryRunRequest("UpdateNeuronDeployment", req)
+			}
+			dryRunDone()
+			return nil
+		}
+
+		promote, err := confirm("promote", "Promote v"+source.GetVersion()+" to "+strconv.Itoa(len(updates))+" deployment(s)? (y|n): ")
+		if err != nil {
+			return err
+		}
+		if !promote {
+			return aborted("Aborting operation, nothing was promoted")
+		}
+
+		for i, req := range updates {
+			pterm.Info.Printf("Updating deployment: %s | v%s ...\n", targets[i].GetGoogleProjectId(), source.GetVersion())
+			op, err := clients.Products.UpdateNeuronDeployment(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			// check if we need to wait for operation to complete.
+			if asyncFlag {
+				pterm.Debug.Printf("GetOperation:\n%s\n", op)
+				pterm.Success.Printf("Launched promotion in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
+			} else {
+				// wait for the long-running operation to complete.
+				err := wait(cmd.Context(), op, "Updating "+targets[i].GetName(), "Updated "+targets[i].GetName(), 300, true)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	neuronCmd.AddCommand(promoteNeuronCmd)
+	argFromWorkingDir(promoteNeuronCmd, neuronFromDir)
+	addAnswerFlag(promoteNeuronCmd, "from", "The product deployment to promote from, as an index, deployment ID, Google project,\n"+
+		"display name or environment (for example: dev)")
+	addAnswerFlag(promoteNeuronCmd, "to", "The product deployments to promote to, as a comma separated list of indices, deployment IDs,\n"+
+		"Google projects, display names or environments (for example: prod)")
+	cobra.CheckErr(promoteNeuronCmd.RegisterFlagCompletionFunc("from", completeDeployments))
+	cobra.CheckErr(promoteNeuronCmd.RegisterFlagCompletionFunc("to", completeDeployments))
+	promoteNeuronCmd.Flags().BoolVar(&promoteEnvsFlag, "envs", false, pterm.Green("Copy the environment variables of the source deployment as well"))
+}
+
+// selectPromoteDeployments lists the deployments of a product and asks the user to select a single one to promote
+// from and one or more others to promote to.
+func selectPromoteDeployments(cmd *cobra.Command, parent string) (*pbProducts.ProductDeployment, []*pbProducts.ProductDeployment, error) {
+	clients, err := clientsFromContext(cmd.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := clients.Products.ListProductDeployments(cmd.Context(), &pbProducts.ListProductDeploymentsRequest{Parent: parent})
+	if err != nil {
+		return nil, nil, err
+	}
+	productDeployments := res.GetProductDeployments()
+	if len(productDeployments) < 2 {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "product %s needs at least two deployments to promote between", parent)
+	}
+
+	// the list is skipped when both ends are given with flags or the answers file.
+	if !answered("from") || !answered("to") {
+		table := pterm.TableData{{"Index", "Display Name", "Environment", "Deployment Project", "Owner", "Version", "State"}}
+		for i, depl := range productDeployments {
+			table = append(table, []string{strconv.Itoa(i), depl.GetDisplayName(), depl.GetEnvironment().String(),
+				depl.GetGoogleProjectId(), depl.GetOwner(), depl.GetVersion(), depl.GetState().String()})
+		}
+		if err := renderTable(cmd, table); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	input, err := ask("from", "Please select the deployment to promote from (index, ID or environment): ", `^[^,]+$`)
+	if err != nil {
+		return nil, nil, err
+	}
+	from, err := resolveProductDeployments(productDeployments, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(from) != 1 {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "%s matches %d deployments, select a single one to promote from", input, len(from))
+	}
+
+	input, err = ask("to", "Please select one or more deployments to promote to (use comma seperated indices, IDs or environments): ", `^(?:[^,]+,)*[^,]+$`)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := resolveProductDeployments(productDeployments, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, d := range to {
+		if d.GetName() == from[0].GetName() {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "cannot promote %s to itself", d.GetGoogleProjectId())
+		}
+	}
+	return from[0], to, nil
+}
+
+// neuronDeploymentDiff returns a table of the version and environment variables which differ between the source and
+// target neuron deployments.  Only the header row is returned when they do not differ.
+func neuronDeploymentDiff(source *pbProducts.NeuronDeployment, target *pbProducts.NeuronDeployment) pterm.TableData {
+	table := pterm.TableData{{"", "Setting", "From", "To"}}
+	if source.GetVersion() != target.GetVersion() {
+		table = append(table, []string{pterm.LightYellow("~"), "version", source.GetVersion(), target.GetVersion()})
+	}
+
+	sourceEnvs := map[string]string{}
+	for _, env := range source.GetEnvs() {
+		sourceEnvs[env.GetName()] = env.GetValue()
+	}
+	targetEnvs := map[string]string{}
+	for _, env := range target.GetEnvs() {
+		targetEnvs[env.GetName()] = env.GetValue()
+	}
+	var names []string
+	for name := range sourceEnvs {
+		names = append(names, name)
+	}
+	for name := range targetEnvs {
+		if _, ok := sourceEnvs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sourceValue, inSource := sourceEnvs[name]
+		targetValue, inTarget := targetEnvs[name]
+		switch {
+		case !inTarget:
+			table = append(table, []string{pterm.Green("+"), name, sourceValue, ""})
+		case !inSource:
+			table = append(table, []string{pterm.Red("-"), name, "", targetValue})
+		case sourceValue != targetValue:
+			table = append(table, []string{pterm.LightYellow("~"), name, sourceValue, targetValue})
+		}
+	}
+	return table
+}
+
+// equalEnvs reports whether a and b hold the same environment variables, in any order.
+func equalEnvs(a []*pbProducts.Neuron_Env, b []*pbProducts.Neuron_Env) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := map[string]string{}
+	for _, env := range a {
+		values[env.GetName()] = env.GetValue()
+	}
+	for _, env := range b {
+		if value, ok := values[env.GetName()]; !ok || value != env.GetValue() {
+			return false
+		}
+	}
+	return true
+}
+
+// getNeuronDeployments returns the neuron deployments of names, in the same order, with an empty NeuronDeployment in
+// place of each one which does not exist.  BatchGetNeuronDeployments is assumed to return such placeholders, as the
+// neuron get and list commands have always expected, but should it fail with NotFound instead, as AIP-231 prescribes,
+// each of them is retrieved on its own.
+func getNeuronDeployments(ctx context.Context, clients *clientSet, names []string) ([]*pbProducts.NeuronDeployment, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	res, err := clients.Products.BatchGetNeuronDeployments(ctx, &pbProducts.BatchGetNeuronDeploymentsRequest{Names: names})
+	if err == nil {
+		if len(res.GetNeuronDeployments()) != len(names) {
+			return nil, status.Errorf(codes.Internal, "requested %d neuron deployments but received %d", len(names), len(res.GetNeuronDeployments()))
+		}
+		return res.GetNeuronDeployments(), nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+
+	var neuronDeployments []*pbProducts.NeuronDeployment
+	for _, name := range names {
+		neuronDeployment, err := clients.Products.GetNeuronDeployment(ctx, &pbProducts.GetNeuronDeploymentRequest{Name: name})
+		if status.Code(err) == codes.NotFound {
+			neuronDeployment = &pbProducts.NeuronDeployment{}
+		} else if err != nil {
+			return nil, err
+		}
+		neuronDeployments = append(neuronDeployments, neuronDeployment)
+	}
+	return neuronDeployments, nil
+}
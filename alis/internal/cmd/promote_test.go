@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+)
+
+func TestPromoteNeuron(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	deployments := map[string]*pbProducts.NeuronDeployment{
+		"in-dev-abc": {Version: "1.2.0", Envs: []*pbProducts.Neuron_Env{
+			{Name: "ALIS_OS_LOG_LEVEL", Value: "debug"}, {Name: "ALIS_OS_NEW", Value: "on"}}},
+		"in-prod-def": {Version: "1.0.0", Envs: []*pbProducts.Neuron_Env{
+			{Name: "ALIS_OS_LOG_LEVEL", Value: "info"}}},
+	}
+	environments := map[string]pbProducts.ProductDeployment_Environment{
+		"in-dev-abc": pbProducts.ProductDeployment_DEV, "in-prod-def": pbProducts.ProductDeployment_PROD}
+	for id, d := range deployments {
+		name := "organisations/alis/products/in/deployments/" + id
+		e.backend.AddProductDeployment(&pbProducts.ProductDeployment{Name: name, GoogleProjectId: id, Environment: environments[id]})
+		d.Name = name + "/neurons/resources-events-v1"
+		e.backend.AddNeuronDeployment(d)
+	}
+	prod := func() *pbProducts.NeuronDeployment {
+		return e.backend.NeuronDeployment("organisations/alis/products/in/deployments/in-prod-def/neurons/resources-events-v1")
+	}
+
+	_, console := e.run("", "neuron", "promote", "alis.in.resources-events-v1", "--from", "dev", "--to", "prod", "--dry-run")
+	for _, want := range []string{"version", "1.2.0", "ALIS_OS_NEW", "debug", "info"} {
+		if !strings.Contains(console, want) {
+			t.Errorf("the diff does not show %s:\n%s", want, console)
+		}
+	}
+	if got := prod().GetVersion(); got != "1.0.0" {
+		t.Errorf("dry run promoted prod to %s", got)
+	}
+
+	// only the version is promoted by default.
+	_, console = e.run("y\n", "neuron", "promote", "alis.in.resources-events-v1", "--from", "dev", "--to", "prod")
+	if got := prod(); got.GetVersion() != "1.2.0" || len(got.GetEnvs()) != 1 || got.GetEnvs()[0].GetValue() != "info" {
+		t.Errorf("prod = %s, want version 1.2.0 with its envs kept\n%s", got, console)
+	}
+
+	e.run("", "neuron", "promote", "alis.in.resources-events-v1", "--from", "in-dev-abc", "--to", "in-prod-def", "--envs", "--yes")
+	if got := prod(); !equalEnvs(got.GetEnvs(), deployments["in-dev-abc"].GetEnvs()) {
+		t.Errorf("prod envs = %s, want those of dev", got.GetEnvs())
+	}
+
+	// deployments without the neuron are skipped, also when the batch get fails on them.
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-test-ghi", GoogleProjectId: "in-test-ghi"})
+	e.backend.StrictBatchGet = true
+	_, console = e.run("", "neuron", "promote", "alis.in.resources-events-v1", "--from", "dev", "--to", "in-prod-def,in-test-ghi", "--yes")
+	if !strings.Contains(console, "not yet been deployed to") || !strings.Contains(console, "in-test-ghi") {
+		t.Errorf("the deployment without the neuron is not skipped:\n%s", console)
+	}
+
+	_, console, err := e.runErr("", "neuron", "promote", "alis.in.resources-events-v1", "--from", "dev", "--to", "dev")
+	if exitCode(err) != exitInvalidArgument || !strings.Contains(console, "cannot promote in-dev-abc to itself") {
+		t.Errorf("promoting a deployment to itself is not rejected:\n%s", console)
+	}
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// answers holds the responses to prompts provided up front, keyed by the name of the prompt.  Each prompt
+	// has a flag of the same name, except confirmations which --yes answers, and the `--answers` file may
+	// provide any of them.
+	answers = map[string]string{}
+
+	yesFlag     bool
+	answersFile string
+)
+
+// answerFlag implements pflag.Value, storing the value of the flag as the answer to the prompt of the same name.
+// Repeated flags, for example `--set-env A=1 --set-env B=2`, accumulate as a comma separated list.
+type answerFlag struct {
+	name     string
+	multiple bool
+}
+
+func (a answerFlag) String() string { return answers[a.name] }
+
+func (a answerFlag) Set(v string) error {
+	if previous, ok := answers[a.name]; ok && a.multiple && previous != "" {
+		v = previous + "," + v
+	}
+	answers[a.name] = v
+	return nil
+}
+
+func (a answerFlag) Type() string { return "string" }
+
+// addAnswerFlag registers a flag on cmd which answers the prompt of the given name.
+func addAnswerFlag(cmd *cobra.Command, name string, usage string) {
+	cmd.Flags().Var(answerFlag{name: name}, name, pterm.Green(usage))
+}
+
+// addDeploymentAnswerFlags registers the flags answering the selection of product deployments, as well as the
+// prompts of creating a new one.
+func addDeploymentAnswerFlags(cmd *cobra.Command) {
+	addAnswerFlag(cmd, "deployments", "The product deployments to use, as a comma separated list of indices, deployment IDs,\n"+
+		"Google projects, display names or environments (for example: dev,prod), or NEW")
+	addAnswerFlag(cmd, "environment", "The environment of a new product deployment, one of PROD or DEV")
+	addAnswerFlag(cmd, "display-name", "The display name of a new product deployment")
+	addAnswerFlag(cmd, "owner", "The owner (email) of a new product deployment")
+	addAnswerFlag(cmd, "billing-account", "The billing account ID of a new product deployment")
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("deployments", completeDeployments))
+}
+
+// addEnvAnswerFlags registers the flags which update environment variables without walking through each of them.
+func addEnvAnswerFlags(cmd *cobra.Command) {
+	cmd.Flags().Var(answerFlag{name: "set-env", multiple: true}, "set-env", pterm.Green("Set an environment variable, as NAME=VALUE.  May be repeated"))
+	cmd.Flags().Var(answerFlag{name: "unset-env", multiple: true}, "unset-env", pterm.Green("Remove an environment variable by NAME.  May be repeated"))
+}
+
+// nonInteractive reports whether prompts should fail instead of waiting for the user.
+func nonInteractive() bool {
+	return viper.GetBool("non-interactive")
+}
+
+// loadAnswers reads the answers in the YAML (or JSON) file at path, for example:
+//
+//	deployments: dev,prod
+//	bump-version: y
+//	set-env: [ALIS_OS_LOG_LEVEL=debug]
+//
+// Answers already provided with flags take precedence.
+func loadAnswers(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fileAnswers := map[string]interface{}{}
+	err = yaml.Unmarshal(b, &fileAnswers)
+	if err != nil {
+		return fmt.Errorf("invalid answers file %s: %w", path, err)
+	}
+	for name, value := range fileAnswers {
+		if _, ok := answers[name]; ok {
+			continue
+		}
+		if values, ok := value.([]interface{}); ok {
+			var parts []string
+			for _, v := range values {
+				parts = append(parts, fmt.Sprint(v))
+			}
+			answers[name] = strings.Join(parts, ",")
+		} else {
+			answers[name] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// answered reports whether the prompt of the given name has been answered up front.
+func answered(name string) bool {
+	_, ok := answers[name]
+	return ok
+}
+
+// ask returns the answer to the named prompt if it was provided up front, and otherwise asks the user.  In
+// non-interactive mode a missing answer is an error.
+func ask(name string, question string, regex string) (string, error) {
+	if answer, ok := answers[name]; ok {
+		if !regexp.MustCompile(regex).MatchString(answer) {
+			return "", status.Errorf(codes.InvalidArgument, "the answer to %s (%s) is not of the right format: %s", name, answer, regex)
+		}
+		pterm.Debug.Printf("Answered %s: %s\n", name, answer)
+		return answer, nil
+	}
+	if nonInteractive() {
+		return "", status.Errorf(codes.FailedPrecondition,
+			"no answer to %q in non-interactive mode, provide it with --%s or in the --answers file", strings.TrimSpace(question), name)
+	}
+	return askUserString(question, regex)
+}
+
+// confirm asks the named yes or no question, which `--yes` answers with yes.
+func confirm(name string, question string) (bool, error) {
+	if yesFlag {
+		return true, nil
+	}
+	// confirmations have no flag of their own, --yes answers all of them.
+	if !answered(name) && nonInteractive() {
+		return false, status.Errorf(codes.FailedPrecondition,
+			"no answer to %q in non-interactive mode, confirm it with --yes or answer %s in the --answers file", strings.TrimSpace(question), name)
+	}
+	input, err := ask(name, question, `^[y|n]$`)
+	if err != nil {
+		return false, err
+	}
+	return input == "y", nil
+}
+
+// resolveProductDeployments returns the deployments matched by selection, a comma separated list of indices,
+// deployment IDs, Google projects, display names or environments.
+func resolveProductDeployments(deployments []*pbProducts.ProductDeployment, selection string) ([]*pbProducts.ProductDeployment, error) {
+	var res []*pbProducts.ProductDeployment
+	selected := map[string]bool{}
+	for _, s := range strings.Split(selection, ",") {
+		s = strings.TrimSpace(s)
+		var matches []*pbProducts.ProductDeployment
+		if i, err := strconv.Atoi(s); err == nil {
+			if i < 0 || i >= len(deployments) {
+				return nil, status.Errorf(codes.InvalidArgument, "%v is not a valid index selection", i)
+			}
+			matches = append(matches, deployments[i])
+		} else {
+			for _, d := range deployments {
+				parts := strings.Split(d.GetName(), "/")
+				if strings.EqualFold(s, parts[len(parts)-1]) || strings.EqualFold(s, d.GetGoogleProjectId()) ||
+					strings.EqualFold(s, d.GetDisplayName()) || strings.EqualFold(s, d.GetEnvironment().String()) {
+					matches = append(matches, d)
+				}
+			}
+		}
+		if len(matches) == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "%s does not match any of the deployments", s)
+		}
+		for _, d := range matches {
+			if !selected[d.GetName()] {
+				selected[d.GetName()] = true
+				res = append(res, d)
+			}
+		}
+	}
+	return res, nil
+}
+
+// envEditsProvided reports whether environment variables are to be updated with `--set-env` or `--unset-env`.
+func envEditsProvided() bool {
+	return answered("set-env") || answered("unset-env")
+}
+
+// editEnvs applies the `--set-env` and `--unset-env` answers to the name and value pairs of envs.  Variables
+// which are not mentioned keep their value, and new ones are added in the order given.
+func editEnvs(envs [][2]string) ([][2]string, error) {
+	var set [][2]string
+	if answers["set-env"] != "" {
+		var err error
+		set, err = parseEnvAssignments(strings.Split(answers["set-env"], ","), "--set-env")
+		if err != nil {
+			return nil, err
+		}
+	}
+	var unset []string
+	if answers["unset-env"] != "" {
+		unset = strings.Split(answers["unset-env"], ",")
+	}
+	return mergeEnvs(envs, set, unset), nil
+}
+
+// parseEnvAssignments parses NAME=VALUE assignments of environment variables, which must be prefixed with ALIS_OS_.
+// source names where the assignments come from in the errors, for example --set-env.
+func parseEnvAssignments(assignments []string, source string) ([][2]string, error) {
+	var res [][2]string
+	for _, e := range assignments {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 || !regexp.MustCompile("^ALIS_OS_[A-Z0-9_]+$").MatchString(parts[0]) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s %s, expected ALIS_OS_NAME=VALUE", source, e)
+		}
+		if !regexp.MustCompile(`^$|^[a-zA-Z0-9:._\/-]+$`).MatchString(parts[1]) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid value for %s: %s", parts[0], parts[1])
+		}
+		res = append(res, [2]string{parts[0], parts[1]})
+	}
+	return res, nil
+}
+
+// mergeEnvs sets and unsets environment variables of the name and value pairs of envs.  Variables which are not
+// mentioned keep their value, and new ones are added in the order given.
+func mergeEnvs(envs [][2]string, set [][2]string, unset []string) [][2]string {
+	values := map[string]string{}
+	var order []string
+	for _, env := range set {
+		if _, ok := values[env[0]]; !ok {
+			order = append(order, env[0])
+		}
+		values[env[0]] = env[1]
+	}
+	removed := map[string]bool{}
+	for _, name := range unset {
+		removed[name] = true
+	}
+
+	var res [][2]string
+	for _, env := range envs {
+		if removed[env[0]] {
+			continue
+		}
+		if value, ok := values[env[0]]; ok {
+			env[1] = value
+			delete(values, env[0])
+		}
+		res = append(res, env)
+	}
+	for _, name := range order {
+		if value, ok := values[name]; ok {
+			res = append(res, [2]string{name, value})
+		}
+	}
+	return res
+}
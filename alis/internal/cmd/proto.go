@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// skipLintFlag lets `alis neuron build` build protos which do not follow the conventions.
+var skipLintFlag bool
+
+// The rules checked by `alis proto lint`.
+const (
+	lintPackage   = "package"
+	lintGoPackage = "go-package"
+	lintComment   = "comment"
+	lintNaming    = "request-response-naming"
+)
+
+// standardMethods are the AIP standard methods, which return the resource they are named after rather than a
+// response message, for example GetEvent returns an Event.
+var standardMethods = []string{"Get", "Create", "Update", "Delete", "Undelete"}
+
+// lintIssue is a violation of the conventions found in the protos of a neuron.
+type lintIssue struct {
+	File string
+	// Line is the line in File of the offending element, 0 if the descriptor holds no source info.
+	Line    int
+	Rule    string
+	Message string
+}
+
+// protoCmd represents the proto command
+var protoCmd = &cobra.Command{
+	Use:   "proto",
+	Short: pterm.Blue("Checks the protocol buffers of neurons."),
+	Long: pterm.Green(
+		`Use this command to check the protocol buffers of a neuron, as compiled into the descriptor
+set which is stored with each neuron version, before building a new version.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
+	},
+}
+
+// lintProtoCmd represents the proto lint command
+var lintProtoCmd = &cobra.Command{
+	Use:   "lint",
+	Short: pterm.Blue("Checks the protos of a neuron against the alis_ conventions"),
+	Long: pterm.Green(
+		`This method compiles the protos of the neuron in the proto repository of the workspace, as
+'alis neuron build' does, and checks that:
+
+ - the package is {orgID}.{productID}.{contract}.{neuron}.{vN}, for example alis.in.resources.events.v1
+ - the go_package is go.protobuf.{orgID}.alis.exchange/{orgID}/{productID}/{contract}/{neuron}/{vN}
+ - every service, method, message and field has a comment
+ - every method takes a {Method}Request and returns a {Method}Response, a long-running operation,
+   google.protobuf.Empty or, for the Get, Create, Update, Delete and Undelete standard methods,
+   the resource it is named after
+
+'alis neuron build' runs the same checks and refuses to build a version which fails them,
+unless run with --skip-lint.`),
+	Example:           pterm.LightYellow("alis proto lint {orgID}.{productID}.{neuronID}\nalis proto lint alis.in.resources-events-v1 -o json"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		organisationID = strings.Split(args[0], ".")[0]
+		productID = strings.Split(args[0], ".")[1]
+		neuronID = strings.Split(args[0], ".")[2]
+
+		fds, err := getNeuronDescriptor(cmd.Context(), "organisations/"+organisationID+"/products/"+productID+"/neurons/"+neuronID)
+		if err != nil {
+			return err
+		}
+		if fds == nil {
+			return status.Errorf(codes.NotFound, "no protos found for %s in %s", args[0],
+				currentWorkspace().NeuronProtos(organisationID, productID, neuronID))
+		}
+
+		issues := lintNeuronDescriptor(fds, organisationID, productID, neuronID)
+		err = lintError(issues, args[0])
+
+		if machineOutput() {
+			res := []map[string]interface{}{}
+			for _, issue := range issues {
+				res = append(res, map[string]interface{}{
+					"file": issue.File, "line": issue.Line, "rule": issue.Rule, "message": issue.Message})
+			}
+			if err := printResources(cmd, res); err != nil {
+				return err
+			}
+			return err
+		}
+
+		if len(issues) == 0 {
+			pterm.Success.Printf("The %d proto file(s) of %s follow the conventions\n", len(fds.GetFile()), args[0])
+			return nil
+		}
+		if err := renderLintIssues(cmd, issues); err != nil {
+			return err
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(protoCmd)
+	protoCmd.AddCommand(lintProtoCmd)
+	argFromWorkingDir(lintProtoCmd, neuronFromDir)
+}
+
+// lintNeuronDescriptor checks the files of fds, the descriptor set of the neuron, against the conventions.  The
+// issues are sorted by file and line.
+func lintNeuronDescriptor(fds *descriptorpb.FileDescriptorSet, organisationID, productID, neuronID string) []lintIssue {
+	// for example alis.in.resources.events.v1 and go.protobuf.alis.alis.exchange/alis/in/resources/events/v1
+	wantPackage := organisationID + "." + productID + "." + strings.ReplaceAll(neuronID, "-", ".")
+	wantGoPackage := "go.protobuf." + organisationID + ".alis.exchange/" + strings.ReplaceAll(wantPackage, ".", "/")
+
+	var issues []lintIssue
+	for _, file := range fds.GetFile() {
+		l := newProtoLinter(file)
+
+		if file.GetPackage() != wantPackage {
+			l.report([]int32{2}, lintPackage, "package is %q, want %q", file.GetPackage(), wantPackage)
+		}
+		goPackage := strings.Split(file.GetOptions().GetGoPackage(), ";")[0]
+		if goPackage != wantGoPackage {
+			l.report([]int32{8, 11}, lintGoPackage, "go_package is %q, want %q", goPackage, wantGoPackage)
+		}
+
+		for i, message := range file.GetMessageType() {
+			l.lintMessage(message, []int32{4, int32(i)})
+		}
+
+		for i, service := range file.GetService() {
+			path := []int32{6, int32(i)}
+			l.requireComment(path, "service %s", service.GetName())
+			for j, method := range service.GetMethod() {
+				methodPath := append(append([]int32{}, path...), 2, int32(j))
+				l.requireComment(methodPath, "method %s.%s", service.GetName(), method.GetName())
+				l.lintMethodNaming(methodPath, service.GetName(), method)
+			}
+		}
+		issues = append(issues, l.issues...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// protoLinter collects the issues of a single file, locating them with its source info.
+type protoLinter struct {
+	file      *descriptorpb.FileDescriptorProto
+	locations map[string]*descriptorpb.SourceCodeInfo_Location
+	issues    []lintIssue
+}
+
+func newProtoLinter(file *descriptorpb.FileDescriptorProto) *protoLinter {
+	l := &protoLinter{file: file, locations: map[string]*descriptorpb.SourceCodeInfo_Location{}}
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		l.locations[fmt.Sprint(loc.GetPath())] = loc
+	}
+	return l
+}
+
+// report adds an issue for the element of the file at path, as defined by SourceCodeInfo.Location.
+func (l *protoLinter) report(path []int32, rule string, format string, a ...interface{}) {
+	issue := lintIssue{File: l.file.GetName(), Rule: rule, Message: fmt.Sprintf(format, a...)}
+	if loc, ok := l.locations[fmt.Sprint(path)]; ok && len(loc.GetSpan()) > 0 {
+		issue.Line = int(loc.GetSpan()[0]) + 1
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// requireComment reports the element at path, described by the format and arguments, if it has no comment.
+func (l *protoLinter) requireComment(path []int32, format string, a ...interface{}) {
+	loc := l.locations[fmt.Sprint(path)]
+	if strings.TrimSpace(loc.GetLeadingComments()) != "" || strings.TrimSpace(loc.GetTrailingComments()) != "" {
+		return
+	}
+	l.report(path, lintComment, "%s has no comment", fmt.Sprintf(format, a...))
+}
+
+// lintMessage checks the comments of message, at path, along with those of its fields and nested messages.
+func (l *protoLinter) lintMessage(message *descriptorpb.DescriptorProto, path []int32) {
+	// the entries of map fields are generated by protoc.
+	if message.GetOptions().GetMapEntry() {
+		return
+	}
+	l.requireComment(path, "message %s", message.GetName())
+	for i, field := range message.GetField() {
+		l.requireComment(append(append([]int32{}, path...), 2, int32(i)), "field %s.%s", message.GetName(), field.GetName())
+	}
+	for i, nested := range message.GetNestedType() {
+		l.lintMessage(nested, append(append([]int32{}, path...), 3, int32(i)))
+	}
+}
+
+// lintMethodNaming checks that method, at path, follows the AIP naming of its request and response messages.
+func (l *protoLinter) lintMethodNaming(path []int32, service string, method *descriptorpb.MethodDescriptorProto) {
+	name := method.GetName()
+	if input := shortTypeName(method.GetInputType()); input != name+"Request" {
+		l.report(path, lintNaming, "method %s.%s takes %s, want %sRequest", service, name, input, name)
+	}
+
+	output := shortTypeName(method.GetOutputType())
+	switch {
+	case output == name+"Response",
+		method.GetOutputType() == ".google.longrunning.Operation",
+		method.GetOutputType() == ".google.protobuf.Empty":
+		return
+	}
+	for _, verb := range standardMethods {
+		if strings.HasPrefix(name, verb) && output == strings.TrimPrefix(name, verb) {
+			return
+		}
+	}
+	l.report(path, lintNaming, "method %s.%s returns %s, want %sResponse", service, name, output, name)
+}
+
+// shortTypeName returns the name of a message without its package, for example GetEventRequest for
+// .alis.in.resources.events.v1.GetEventRequest.
+func shortTypeName(typeName string) string {
+	return typeName[strings.LastIndex(typeName, ".")+1:]
+}
+
+// lintError returns the error of the issues found in the protos of neuron, nil if there are none.
+func lintError(issues []lintIssue, neuron string) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition, "found %d issue(s) in the protos of %s", len(issues), neuron)
+}
+
+// renderLintIssues prints issues as a table.
+func renderLintIssues(cmd *cobra.Command, issues []lintIssue) error {
+	table := pterm.TableData{{"File", "Line", "Rule", "Issue"}}
+	for _, issue := range issues {
+		table = append(table, []string{issue.File, strconv.Itoa(issue.Line), pterm.Yellow(issue.Rule), issue.Message})
+	}
+	return renderTable(cmd, table)
+}
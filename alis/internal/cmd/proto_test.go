@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// eventsProto returns the descriptor of the protos of the alis.in.resources-events-v1 neuron, which follow the
+// conventions.  Each element is commented, on a line of its own.
+func eventsProto() *descriptorpb.FileDescriptorProto {
+	field := func(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), JsonName: proto.String(name),
+			Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()}
+		if typeName != "" {
+			f.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	repeated := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return f
+	}
+	method := func(name, input, output string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{Name: proto.String(name), InputType: proto.String(input), OutputType: proto.String(output)}
+	}
+	pkg := ".alis.in.resources.events.v1."
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("alis/in/resources/events/v1/events.proto"),
+		Package:    proto.String("alis.in.resources.events.v1"),
+		Dependency: []string{"google/protobuf/empty.proto"},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("go.protobuf.alis.alis.exchange/alis/in/resources/events/v1")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, ""), repeated(field("labels", 2, pkg+"Event.LabelsEntry"))},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name:    proto.String("LabelsEntry"),
+					Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, ""), field("value", 2, "")},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+			{Name: proto.String("GetEventRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, "")}},
+			{Name: proto.String("ListEventsRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("parent", 1, "")}},
+			{Name: proto.String("ListEventsResponse"), Field: []*descriptorpb.FieldDescriptorProto{repeated(field("events", 1, pkg+"Event"))}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("EventsService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetEvent", pkg+"GetEventRequest", pkg+"Event"),
+				method("ListEvents", pkg+"ListEventsRequest", pkg+"ListEventsResponse"),
+				method("DeleteEvent", pkg+"DeleteEventRequest", ".google.protobuf.Empty"),
+			},
+		}},
+	}
+
+	// the locations of the elements, in the order they appear in the file.
+	paths := [][]int32{{2}, {8, 11}}
+	for i, message := range file.GetMessageType() {
+		paths = append(paths, []int32{4, int32(i)})
+		for j := range message.GetField() {
+			paths = append(paths, []int32{4, int32(i), 2, int32(j)})
+		}
+	}
+	for i, service := range file.GetService() {
+		paths = append(paths, []int32{6, int32(i)})
+		for j := range service.GetMethod() {
+			paths = append(paths, []int32{6, int32(i), 2, int32(j)})
+		}
+	}
+	file.SourceCodeInfo = &descriptorpb.SourceCodeInfo{}
+	for i, path := range paths {
+		file.SourceCodeInfo.Location = append(file.SourceCodeInfo.Location, &descriptorpb.SourceCodeInfo_Location{
+			Path: path, Span: []int32{int32(i), 0, 10}, LeadingComments: proto.String(" Documented.\n")})
+	}
+	return file
+}
+
+// uncomment removes the comment of the element at path.
+func uncomment(file *descriptorpb.FileDescriptorProto, path ...int32) {
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		if reflect.DeepEqual(loc.GetPath(), path) {
+			loc.LeadingComments = nil
+		}
+	}
+}
+
+// protocWrites makes the stand-in for protoc write fds as the descriptor set.
+func (e *testEnv) protocWrites(fds *descriptorpb.FileDescriptorSet) {
+	e.t.Helper()
+
+	b, err := proto.Marshal(fds)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	src := filepath.Join(e.home, "descriptor.pb")
+	writeFile(e.t, src, string(b))
+	writeFile(e.t, filepath.Join(e.home, "bin", "protoc"), fmt.Sprintf(`#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+	--descriptor_set_out=*) cp %s "${arg#--descriptor_set_out=}" ;;
+	esac
+done
+`, src))
+}
+
+func TestLintNeuronDescriptor(t *testing.T) {
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{eventsProto()}}
+	if issues := lintNeuronDescriptor(fds, "alis", "in", "resources-events-v1"); len(issues) != 0 {
+		t.Errorf("got issues for protos which follow the conventions: %v", issues)
+	}
+
+	file := fds.GetFile()[0]
+	file.Package = proto.String("alis.in.events.v1")
+	file.Options.GoPackage = proto.String("go.protobuf.alis.alis.exchange/alis/in/events/v1;events")
+	uncomment(file, 4, 0, 2, 0)
+	uncomment(file, 6, 0)
+	file.Service[0].Method[0].InputType = proto.String(".alis.in.resources.events.v1.GetRequest")
+	file.Service[0].Method[1].OutputType = proto.String(".alis.in.resources.events.v1.Events")
+
+	var got []string
+	for _, issue := range lintNeuronDescriptor(fds, "alis", "in", "resources-events-v1") {
+		got = append(got, fmt.Sprintf("%d %s: %s", issue.Line, issue.Rule, issue.Message))
+	}
+	want := []string{
+		`1 package: package is "alis.in.events.v1", want "alis.in.resources.events.v1"`,
+		`2 go-package: go_package is "go.protobuf.alis.alis.exchange/alis/in/events/v1", want "go.protobuf.alis.alis.exchange/alis/in/resources/events/v1"`,
+		`4 comment: field Event.name has no comment`,
+		`12 comment: service EventsService has no comment`,
+		`13 request-response-naming: method EventsService.GetEvent takes GetRequest, want GetEventRequest`,
+		`14 request-response-naming: method EventsService.ListEvents returns Events, want ListEventsResponse`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestProtoLint(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.repo("alis/products/in", map[string]string{"resources/events/v1/Dockerfile": "FROM scratch\n"})
+	e.repo("alis/proto", map[string]string{"alis/in/resources/events/v1/events.proto": "syntax = \"proto3\";\n"})
+
+	file := eventsProto()
+	uncomment(file, 4, 1)
+	e.protocWrites(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+
+	stdout, console, err := e.runErr("", "proto", "lint", "alis.in.resources-events-v1", "-o", "json")
+	if errorCode(err) != codes.FailedPrecondition {
+		t.Errorf("got %v, want a failed precondition", err)
+	}
+	var issues []map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &issues); err != nil {
+		t.Fatalf("%v\n%s\n%s", err, stdout, console)
+	}
+	if len(issues) != 1 || issues[0]["message"] != "message GetEventRequest has no comment" {
+		t.Errorf("issues = %v", issues)
+	}
+
+	// the build fails before anything is tagged, unless the lint is skipped.
+	_, console, err = e.runErr("", "neuron", "build", "alis.in.resources-events-v1")
+	if err == nil || !strings.Contains(console, "message GetEventRequest has no comment") {
+		t.Errorf("build did not fail on the lint issues:\n%s", console)
+	}
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 0 {
+		t.Fatalf("got %d neuron versions, want 0", got)
+	}
+	if tags := e.git(filepath.Join(e.home, "remotes", "alis/proto.git"), "tag"); tags != "" {
+		t.Errorf("proto repository was tagged: %s", tags)
+	}
+	e.run("", "neuron", "build", "alis.in.resources-events-v1", "--skip-lint")
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 1 {
+		t.Fatalf("got %d neuron versions, want 1", got)
+	}
+
+	e.protocWrites(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{eventsProto()}})
+	_, console = e.run("", "proto", "lint", "alis.in.resources-events-v1")
+	if !strings.Contains(console, "follow the conventions") {
+		t.Errorf("expected success, got:\n%s", console)
+	}
+
+	// the descriptor set is only written by protoc.
+	if _, err := os.Stat(filepath.Join(e.home, "alis.exchange", "alis", "proto", "alis", "in", "resources", "events", "v1", "descriptor.pb")); !os.IsNotExist(err) {
+		t.Errorf("descriptor.pb was left behind: %v", err)
+	}
+}
+
+func TestBuildNeuronWithoutProtos(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	e.repo("alis/products/in", map[string]string{"resources/events/v1/Dockerfile": "FROM scratch\n"})
+	e.repo("alis/proto", map[string]string{"README.md": "# proto\n"})
+
+	// the protos can be neither linted nor compared with the latest version, so the build fails unless both are skipped.
+	for _, flags := range [][]string{nil, {"--skip-lint"}, {"--allow-breaking"}} {
+		args := append([]string{"neuron", "build", "alis.in.resources-events-v1"}, flags...)
+		if _, console, err := e.runErr("", args...); errorCode(err) != codes.NotFound {
+			t.Errorf("%v: got %v, want not found\n%s", flags, err, console)
+		}
+	}
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 0 {
+		t.Fatalf("got %d neuron versions, want 0", got)
+	}
+	e.run("", "neuron", "build", "alis.in.resources-events-v1", "--skip-lint", "--allow-breaking")
+	if got := len(e.backend.NeuronVersions(neuron.GetName())); got != 1 {
+		t.Fatalf("got %d neuron versions, want 1", got)
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// rollbackNeuronCmd represents the rollback command
+var rollbackNeuronCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: pterm.Blue("Redeploys a previous version of a neuron"),
+	Long: pterm.Green(
+		`This method lists the versions of the neuron, along with their commits and the versions
+currently deployed, and deploys the one you select to one or more environments.
+
+Only the version of the neuron deployments is changed, their environment variables are kept.
+Use 'alis neuron deploy --version' to also create missing deployments or update their settings.`),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	Example:           pterm.LightYellow("alis neuron rollback {orgID}.{productID}.{neuronID}\nalis neuron rollback alis.in.resources-events-v1 --deployments prod --version 1.2.0"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		organisationID = strings.Split(args[0], ".")[0]
+		productID = strings.Split(args[0], ".")[1]
+		neuronID = strings.Split(args[0], ".")[2]
+
+		clients, err := clientsFromContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		// Retrieve the neuron resource
+		neuron, err := clients.Products.GetNeuron(cmd.Context(),
+			&pbProducts.GetNeuronRequest{
+				Name: "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID})
+		if err != nil {
+			return err
+		}
+		pterm.Debug.Printf("GetNeuron:\n%s\n", neuron)
+
+		// ask the user to select a product deployment
+		productDeployments, err := selectProductDeployments(cmd.Context(), "organisations/"+organisationID+"/products/"+productID)
+		if err != nil {
+			return err
+		}
+
+		// the neuron deployments to roll back, along with the deployments which currently run each version.
+		neuronDeployments := map[string]*pbProducts.NeuronDeployment{}
+		deployedTo := map[string][]string{}
+		for _, productDeployment := range productDeployments {
+			neuronDeployment, err := clients.Products.GetNeuronDeployment(cmd.Context(),
+				&pbProducts.GetNeuronDeploymentRequest{Name: productDeployment.GetName() + "/neurons/" + neuronID})
+			if status.Code(err) == codes.NotFound {
+				pterm.Warning.Printf("This neuron has not yet been deployed to %s (%s), skipping it\n",
+					productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId())
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			neuronDeployments[productDeployment.GetName()] = neuronDeployment
+			deployedTo[neuronDeployment.GetVersion()] = append(deployedTo[neuronDeployment.GetVersion()], productDeployment.GetGoogleProjectId())
+		}
+		if len(neuronDeployments) == 0 {
+			return status.Errorf(codes.FailedPrecondition, "%s is not deployed to any of the selected deployments", neuron.GetName())
+		}
+
+		res, err := clients.Products.ListNeuronVersions(cmd.Context(), &pbProducts.ListNeuronVersionsRequest{
+			Parent:   neuron.GetName(),
+			ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"version", "state", "commit_sha", "proto_commit_sha", "create_time"}},
+		})
+		if err != nil {
+			return err
+		}
+		version, err := selectNeuronVersion(cmd, res.GetNeuronVersions(), deployedTo)
+		if err != nil {
+			return err
+		}
+
+		for _, productDeployment := range productDeployments {
+			neuronDeployment, ok := neuronDeployments[productDeployment.GetName()]
+			if !ok {
+				continue
+			}
+			if neuronDeployment.GetVersion() == version.GetVersion() {
+				pterm.Info.Printf("%s already runs v%s\n", productDeployment.GetGoogleProjectId(), version.GetVersion())
+				continue
+			}
+
+			pterm.DefaultSection.Printf("Rolling back %s (%s)", productDeployment.GetDisplayName(), productDeployment.GetGoogleProjectId())
+			pterm.Info.Printf("Updating deployment: %s | v%s -> v%s ...\n",
+				productDeployment.GetGoogleProjectId(), neuronDeployment.GetVersion(), version.GetVersion())
+			req := &pbProducts.UpdateNeuronDeploymentRequest{
+				NeuronDeployment: &pbProducts.NeuronDeployment{
+					Name:    neuronDeployment.GetName(),
+					Version: version.GetVersion(),
+				},
+				UpdateMask: &fieldmaskpb.FieldMask{
+					Paths: []string{"version"},
+				},
+			}
+			if dryRunFlag {
+				dryRunRequest("UpdateNeuronDeployment", req)
+				continue
+			}
+			op, err := clients.Products.UpdateNeuronDeployment(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			// check if we need to wait for operation to complete.
+			if asyncFlag {
+				pterm.Debug.Printf("GetOperation:\n%s\n", op)
+				pterm.Success.Printf("Launched rollback in async mode.\n see long-running operation " + op.GetName() + " to monitor state,\n for example with `alis operation wait " + op.GetName() + "`\n")
+			} else {
+				// wait for the long-running operation to complete.
+				err := wait(cmd.Context(), op, "Updating "+productDeployment.GetName(), "Updated "+productDeployment.GetName(), 300, true)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if dryRunFlag {
+			dryRunDone()
+		}
+		return nil
+	},
+}
+
+func init() {
+	neuronCmd.AddCommand(rollbackNeuronCmd)
+	argFromWorkingDir(rollbackNeuronCmd, neuronFromDir)
+	addDeploymentAnswerFlags(rollbackNeuronCmd)
+	addAnswerFlag(rollbackNeuronCmd, "version", "The version to roll back to, as its index in the list or the version itself, for example 1.2.0")
+}
+
+// selectNeuronVersion lists the versions of a neuron, latest first, and asks the user to select one.  deployedTo holds
+// the Google projects of the deployments running each version.
+func selectNeuronVersion(cmd *cobra.Command, versions []*pbProducts.NeuronVersion, deployedTo map[string][]string) (*pbProducts.NeuronVersion, error) {
+	if len(versions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "there are no versions available, please run `alis neuron build ...` to create a version")
+	}
+
+	// the list is skipped when the version is given with --version or the answers file.
+	if _, ok := answers["version"]; !ok {
+		table := pterm.TableData{{"Index", "Version", "State", "Commit", "Proto Commit", "Created", "Deployed To"}}
+		for i, v := range versions {
+			table = append(table, []string{strconv.Itoa(i), v.GetVersion(), v.GetState().String(),
+				shortSha(v.GetCommitSha()), shortSha(v.GetProtoCommitSha()),
+				v.GetCreateTime().AsTime().Format(time.RFC3339), strings.Join(deployedTo[v.GetVersion()], ", ")})
+		}
+		if err := renderTable(cmd, table); err != nil {
+			return nil, err
+		}
+	}
+
+	answer, err := ask("version", "Select the version to deploy (index or version): ", `^(\d+|v?\d+\.\d+\.\d+\S*)$`)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(answer, ".") {
+		i, _ := strconv.Atoi(answer)
+		if i >= len(versions) {
+			return nil, status.Errorf(codes.InvalidArgument, "%d is not an index of the list of versions", i)
+		}
+		answer = versions[i].GetVersion()
+	}
+	return findNeuronVersion(versions, answer)
+}
+
+// findNeuronVersion returns the version of a neuron which may be deployed, with or without a leading v.
+func findNeuronVersion(versions []*pbProducts.NeuronVersion, version string) (*pbProducts.NeuronVersion, error) {
+	version = strings.TrimPrefix(version, "v")
+	for _, v := range versions {
+		if v.GetVersion() != version {
+			continue
+		}
+		if v.GetState() == pbProducts.NeuronVersion_BUILDING || v.GetState() == pbProducts.NeuronVersion_FAILED {
+			return nil, status.Errorf(codes.FailedPrecondition, "version %s is %s and cannot be deployed", version, v.GetState())
+		}
+		return v, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "version %s not found, run `alis neuron get` to list the versions", version)
+}
+
+// shortSha abbreviates a commit hash, as git does.
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRollbackNeuron(t *testing.T) {
+	e := newTestEnv(t)
+	neuron := e.seedNeuron()
+	for _, v := range []*pbProducts.NeuronVersion{
+		{Version: "1.0.0", State: pbProducts.NeuronVersion_BUILT, CommitSha: "abc1234567890"},
+		{Version: "1.1.0", State: pbProducts.NeuronVersion_FAILED, CommitSha: "def1234567890"},
+		{Version: "1.2.0", State: pbProducts.NeuronVersion_BUILT, CommitSha: "0121234567890"},
+	} {
+		v.Name = neuron.GetName() + "/versions/" + v.GetVersion()
+		e.backend.AddNeuronVersion(v)
+	}
+	for _, id := range []string{"in-dev-abc", "in-prod-def"} {
+		deployment := "organisations/alis/products/in/deployments/" + id
+		e.backend.AddProductDeployment(&pbProducts.ProductDeployment{Name: deployment, GoogleProjectId: id})
+		e.backend.AddNeuronDeployment(&pbProducts.NeuronDeployment{Name: deployment + "/neurons/resources-events-v1", Version: "1.2.0"})
+	}
+	version := func(id string) string {
+		return e.backend.NeuronDeployment("organisations/alis/products/in/deployments/" + id + "/neurons/resources-events-v1").GetVersion()
+	}
+
+	// the versions are listed latest first, so index 2 is 1.0.0.
+	_, console := e.run("2\n", "neuron", "rollback", "alis.in.resources-events-v1", "--deployments", "in-prod-def")
+	if !strings.Contains(console, "abc1234") || !strings.Contains(console, "FAILED") {
+		t.Errorf("the versions are not listed with their commits and states:\n%s", console)
+	}
+	if got := version("in-prod-def"); got != "1.0.0" {
+		t.Errorf("version of in-prod-def = %s, want 1.0.0\n%s", got, console)
+	}
+	if got := version("in-dev-abc"); got != "1.2.0" {
+		t.Errorf("version of in-dev-abc = %s, want 1.2.0", got)
+	}
+
+	// failed builds cannot be deployed.
+	_, console, err := e.runErr("", "neuron", "rollback", "alis.in.resources-events-v1", "--deployments", "in-dev-abc", "--version", "1.1.0")
+	if errorCode(err) != codes.FailedPrecondition {
+		t.Errorf("got %v, want a failed precondition", err)
+	}
+	if got := version("in-dev-abc"); got != "1.2.0" || !strings.Contains(console, "version 1.1.0 is FAILED") {
+		t.Errorf("version of in-dev-abc = %s, want 1.2.0\n%s", got, console)
+	}
+
+	_, console = e.run("", "neuron", "deploy", "alis.in.resources-events-v1", "--deployments", "in-dev-abc", "--version", "v1.0.0")
+	if got := version("in-dev-abc"); got != "1.0.0" {
+		t.Errorf("version of in-dev-abc = %s, want 1.0.0\n%s", got, console)
+	}
+}
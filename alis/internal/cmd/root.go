@@ -2,40 +2,37 @@ package cmd
 
 import (
 	"context"
-	"embed"
 	"fmt"
-	"log"
-	"math/rand"
+	"io/fs"
 	"os"
-	"os/exec"
+	"os/signal"
+	"strings"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	pbOperations "go.protobuf.alis.alis.exchange/alis/os/resources/operations/v1"
-	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
-	pbParsers "go.protobuf.alis.alis.exchange/alis/os/services/parsers/v1"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	organisationID       string
-	productID            string
-	neuronID             string
-	releaseType          string
-	debugFlag            bool
-	cfgFile              string
-	homeDir              string
-	asyncFlag            bool
-	alisProductsClient   pbProducts.ServiceClient
-	alisParsersClient    pbParsers.ParserServiceClient
-	alisOperationsClient pbOperations.ServiceClient
-	TemplateFs           embed.FS
-	ptermTip             pterm.PrefixPrinter
-	ptermInput           pterm.PrefixPrinter
+	organisationID string
+	productID      string
+	neuronID       string
+	releaseType    string
+	debugFlag      bool
+	cfgFile        string
+	homeDir        string
+	asyncFlag      bool
+	TemplateFs     fs.FS
+	ptermTip       pterm.PrefixPrinter
+	ptermInput     pterm.PrefixPrinter
 )
 
+// configErr is the error of applying the profile of the config file, which is returned once a command runs.
+var configErr error
+
 const VERSION = "3.9.1"
 
 // rootCmd represents the base command when called without any subcommands
@@ -44,26 +41,37 @@ var rootCmd = &cobra.Command{
 	Short: pterm.Green("alis_ Technologies LLC - Command Line Interface"),
 	Long: pterm.Green("The alis CLI manages authentication, local configuration, developer workflow, \n" +
 		"and interactions with the alis_ os resources"),
-	Run: func(cmd *cobra.Command, args []string) {
-		pterm.Error.Println("a valid command is missing\nplease run 'alis -h' for details.")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return missingCommand(cmd)
 	},
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	// errors are rendered once, by Execute, which exits with the code matching the error.
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if configErr != nil {
+			return configErr
+		}
 		if debugFlag {
 			pterm.EnableDebugMessages()
 		}
-	},
-	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		// Randomly update the commandline one in every 21 times.
-		if rand.Intn(21) == 7 {
-			pterm.Info.Println("running a random CLI update check...")
-			cmds := "alis update"
-			pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-			_, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
-			if err != nil {
-				pterm.Debug.Println(cmds)
-				return
+		// the active profile may set the output format.
+		if outputFlag == "" && viper.GetString("output") != "" {
+			if err := outputFlag.Set(viper.GetString("output")); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid output setting in %s: %s", viper.ConfigFileUsed(), err)
 			}
 		}
+		// keep the standard output free of anything but the requested output.
+		if outputFlag != "" {
+			pterm.DisableColor()
+			pterm.SetDefaultOutput(cmd.ErrOrStderr())
+		}
+		if answersFile != "" {
+			return loadAnswers(answersFile)
+		}
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		notifyUpdate(cmd)
 	},
 }
 
@@ -86,8 +94,18 @@ var rootCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// A failed command exits with one of the exit codes in errors.go.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	// The alis_ OS clients are only dialed once a command requests them.
+	ctx := withClientSet(context.Background(), dialClientSet)
+	// Ctrl-C cancels the context, which stops waiting on long-running operations and any shell commands.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		renderError(err)
+		os.Exit(exitCode(err))
+	}
 }
 
 func init() {
@@ -96,30 +114,11 @@ func init() {
 	if err != nil {
 		fmt.Printf("\033[32m%s\033[0m", err)
 	}
-	// Initialise alis Products client
-	var connProducts *grpc.ClientConn
-	connProducts, err = NewServerConnection(context.Background(), "resources-products-v1-ntaj7kcaca-ew.a.run.app")
-	if err != nil {
-		log.Fatalf("alis.NewServerConnection: %s", err)
-	}
-	alisProductsClient = pbProducts.NewServiceClient(connProducts)
-	// Initialise alis Products client
-	var connParsers *grpc.ClientConn
-	connParsers, err = NewServerConnection(context.Background(), "services-parsers-v1-ntaj7kcaca-ew.a.run.app")
-	if err != nil {
-		log.Fatalf("alis.NewServerConnection: %s", err)
-	}
-	alisParsersClient = pbParsers.NewParserServiceClient(connParsers)
-
-	// Initialise alis Services client
-	var connOperations *grpc.ClientConn
-	connOperations, err = NewServerConnection(context.Background(), "resources-operations-v1-ntaj7kcaca-ew.a.run.app")
-	if err != nil {
-		log.Fatalf("alis.NewServerConnection: %s", err)
-	}
-	alisOperationsClient = pbOperations.NewServiceClient(connOperations)
 
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, usageErrors)
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
 	rootCmd.Version = VERSION
 	//rootCmd.AddCommand(testCmd)
 
@@ -128,8 +127,28 @@ func init() {
 	// will be global for your application.
 	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, pterm.Green("Run the commands in DEBUG mode."))
 	rootCmd.PersistentFlags().BoolVarP(&asyncFlag, "async", "a", false, pterm.Green("Return immediately, without waiting for the operation in progress to complete.\nOnly relevant if the command involves a long-running operation"))
-	rootCmd.SilenceUsage = true
-	rootCmd.SilenceErrors = true
+	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 0, pterm.Green("How long to wait for a long-running operation to complete, for example 10m.\nDefaults to a timeout suited to the command"))
+	rootCmd.PersistentFlags().VarP(&outputFlag, "output", "o", pterm.Green("Print the resources as json, yaml or a plain table instead of the console view.\nOnly relevant for the get, list and tree commands"))
+	rootCmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false, pterm.Green("Print what the build, deploy, create and genproto commands would change, without changing anything"))
+	rootCmd.PersistentFlags().BoolVarP(&yesFlag, "yes", "y", false, pterm.Green("Answer yes to all confirmations"))
+	rootCmd.PersistentFlags().Bool("non-interactive", false, pterm.Green("Fail instead of prompting when an answer is not provided with a flag or the answers file.\nMay also be set with ALIS_NON_INTERACTIVE"))
+	rootCmd.PersistentFlags().StringVar(&answersFile, "answers", "", pterm.Green("A YAML file with answers to the prompts, keyed by the name of their flags"))
+	cobra.CheckErr(viper.BindPFlag("non-interactive", rootCmd.PersistentFlags().Lookup("non-interactive")))
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", pterm.Green("The config file to use (default is $HOME/.alis.yaml)"))
+	rootCmd.PersistentFlags().String("profile", "", pterm.Green("The profile of the config file to use (default is the one selected with `alis config use`).\nMay also be set with ALIS_PROFILE"))
+	cobra.CheckErr(viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile")))
+	rootCmd.PersistentFlags().String("workspace", "", pterm.Green("The directory holding the local clones of the repositories (default is $HOME/alis.exchange).\nMay also be set with ALIS_WORKSPACE"))
+	cobra.CheckErr(viper.BindPFlag("workspace", rootCmd.PersistentFlags().Lookup("workspace")))
+
+	// Endpoints of the alis_ OS services, which may also be set in the config file or with ALIS_* environment variables.
+	rootCmd.PersistentFlags().String("products-endpoint", defaultProductsEndpoint, pterm.Green("The host[:port] of the products service"))
+	rootCmd.PersistentFlags().String("operations-endpoint", defaultOperationsEndpoint, pterm.Green("The host[:port] of the operations service"))
+	rootCmd.PersistentFlags().String("parsers-endpoint", defaultParsersEndpoint, pterm.Green("The host[:port] of the parsers service"))
+	rootCmd.PersistentFlags().Bool("insecure", false, pterm.Green("Connect to the endpoints in plaintext, without credentials.\nUse this for a local stand-in of the alis_ OS services"))
+	cobra.CheckErr(viper.BindPFlag("endpoints.products", rootCmd.PersistentFlags().Lookup("products-endpoint")))
+	cobra.CheckErr(viper.BindPFlag("endpoints.operations", rootCmd.PersistentFlags().Lookup("operations-endpoint")))
+	cobra.CheckErr(viper.BindPFlag("endpoints.parsers", rootCmd.PersistentFlags().Lookup("parsers-endpoint")))
+	cobra.CheckErr(viper.BindPFlag("insecure", rootCmd.PersistentFlags().Lookup("insecure")))
 
 	// Define own commandline message type to use for tips.
 	ptermTip = pterm.PrefixPrinter{
@@ -167,15 +186,19 @@ func initConfig() {
 		home, err := homedir.Dir()
 		cobra.CheckErr(err)
 
-		// Search config in home directory with name ".alis" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".alis")
+		// Use the config file in the home directory with name ".alis" and the extension of any supported format.
+		viper.SetConfigFile(defaultConfigFile(home))
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// read in environment variables that match, for example ALIS_ENDPOINTS_PRODUCTS for endpoints.products
+	viper.SetEnvPrefix("alis")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
 
 	// If a config file is found, read it in.
+	configErr = nil
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		configErr = applyProfile()
 	}
 }
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	runPortFlag        int
+	runWatchFlag       bool
+	runCredentialsFlag string
+)
+
+const (
+	// neuronStopTimeout is how long a neuron is given to shut down once interrupted, before it is killed.
+	neuronStopTimeout = 5 * time.Second
+	// watchDebounce is how long to wait for further changes, such that saving several files restarts a neuron once.
+	watchDebounce = 200 * time.Millisecond
+)
+
+// runNeuronCmd represents the neuron run command
+var runNeuronCmd = &cobra.Command{
+	Use:   "run",
+	Short: pterm.Blue("Runs a neuron locally with the environment of one of its deployments"),
+	Long: pterm.Green(
+		`This method builds the neuron in the product repository of the workspace and runs it with the
+environment variables of the neuron in the selected product deployment, along with:
+
+ - PORT, the port to serve on, set with --port
+ - ENV=LOCAL
+ - ALIS_OS_PROJECT, the Google project of the deployment, unless the deployment sets it
+ - GOOGLE_APPLICATION_CREDENTIALS, the key of the deployment created with 'alis product getkey',
+   or the file given with --credentials
+
+The neuron is rebuilt and restarted whenever one of its files changes, unless run with
+--watch=false.  Press Ctrl+C to stop it.`),
+	Example: pterm.LightYellow("alis neuron run {orgID}.{productID}.{neuronID} --deployment dev\n" +
+		"alis neuron run alis.in.resources-events-v1 --deployment dev --port 8081"),
+	Args:              validateNeuronArg,
+	ValidArgsFunction: completeNeuronArg,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		organisationID = strings.Split(args[0], ".")[0]
+		productID = strings.Split(args[0], ".")[1]
+		neuronID = strings.Split(args[0], ".")[2]
+		neuronName := "organisations/" + organisationID + "/products/" + productID + "/neurons/" + neuronID
+
+		dir := currentWorkspace().NeuronDir(organisationID, productID, neuronID)
+		if _, err := os.Stat(dir); err != nil {
+			return status.Errorf(codes.NotFound, "%s not found in the product repository at %s", args[0],
+				currentWorkspace().ProductRepo(organisationID, productID))
+		}
+
+		deployment, neuronDeployment, err := selectNeuronDeployment(cmd, neuronName, "to take the environment from")
+		if err != nil {
+			return err
+		}
+		project := deployment.GetGoogleProjectId()
+
+		credentials := runCredentialsFlag
+		if credentials == "" {
+			key := filepath.Join(currentWorkspace().ProductRepo(organisationID, productID), "key-"+project+".json")
+			if _, err := os.Stat(key); err == nil {
+				credentials = key
+			} else {
+				pterm.Warning.Printf("No key found at %s, the neuron uses your application default credentials\n", key)
+				ptermTip.Printf("Run `alis product getkey %s.%s --deployments %s` to create one.\n", organisationID, productID, project)
+			}
+		}
+
+		envs := [][2]string{{"ALIS_OS_PROJECT", project}}
+		envs = mergeEnvs(envs, neuronEnvPairs(neuronDeployment.GetEnvs()), nil)
+		envs = mergeEnvs(envs, [][2]string{{"PORT", strconv.Itoa(runPortFlag)}, {"ENV", "LOCAL"}}, nil)
+		if credentials != "" {
+			envs = mergeEnvs(envs, [][2]string{{"GOOGLE_APPLICATION_CREDENTIALS", credentials}}, nil)
+		}
+
+		table := pterm.TableData{{"Name", "Value"}}
+		var env []string
+		for _, e := range envs {
+			table = append(table, []string{e[0], e[1]})
+			env = append(env, e[0]+"="+e[1])
+		}
+		pterm.Info.Printf("Running %s with the environment of %s (%s) on port %d\n", args[0],
+			deployment.GetDisplayName(), project, runPortFlag)
+		if err := renderTable(cmd, table); err != nil {
+			return err
+		}
+
+		r := &neuronRun{Dir: dir, Env: env, Watch: runWatchFlag, Stdout: cmd.OutOrStdout(), Stderr: cmd.ErrOrStderr()}
+		return r.Run(cmd.Context())
+	},
+}
+
+func init() {
+	neuronCmd.AddCommand(runNeuronCmd)
+	argFromWorkingDir(runNeuronCmd, neuronFromDir)
+	runNeuronCmd.Flags().IntVar(&runPortFlag, "port", 8080, pterm.Green("The port the neuron serves on"))
+	runNeuronCmd.Flags().BoolVar(&runWatchFlag, "watch", true, pterm.Green("Rebuild and restart the neuron when its files change"))
+	runNeuronCmd.Flags().StringVar(&runCredentialsFlag, "credentials", "", pterm.Green("The service account key to run the neuron with, instead of the key of the deployment"))
+	addAnswerFlag(runNeuronCmd, "deployment", "The product deployment to take the environment from, as an index, deployment ID,\n"+
+		"Google project, display name or environment (for example: dev)")
+	cobra.CheckErr(runNeuronCmd.RegisterFlagCompletionFunc("deployment", completeDeployments))
+}
+
+// neuronRun builds the neuron in Dir and runs it with Env, in addition to the environment of the CLI.  With Watch, the
+// neuron is rebuilt and restarted whenever a file within Dir changes.
+type neuronRun struct {
+	Dir    string
+	Env    []string
+	Watch  bool
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run runs the neuron until it exits, or until ctx is done when watching.  A build which fails while watching is
+// reported and the neuron is built again on the next change.
+func (r *neuronRun) Run(ctx context.Context) error {
+	binDir, err := ioutil.TempDir("", "alis-neuron-run")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(binDir)
+	binary := filepath.Join(binDir, "neuron")
+
+	var watcher *fsnotify.Watcher
+	if r.Watch {
+		watcher, err = watchDir(r.Dir)
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+	}
+
+	for {
+		var process *neuronProcess
+		spinner, _ := pterm.DefaultSpinner.Start("Building the neuron...")
+		_, _, err := run(ctx, command{Name: "go", Args: []string{"build", "-o", binary, "."}, Dir: r.Dir})
+		if err != nil {
+			if ctx.Err() != nil {
+				spinner.Stop()
+				return nil
+			}
+			spinner.Fail(err)
+			if !r.Watch {
+				return err
+			}
+			pterm.Info.Println("Waiting for changes...")
+		} else {
+			spinner.Success("Built the neuron")
+			process, err = startNeuronProcess(binary, r.Dir, r.Env, r.Stdout, r.Stderr)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !r.Watch {
+			select {
+			case <-process.done:
+				return process.err
+			case <-ctx.Done():
+				process.stop()
+				return nil
+			}
+		}
+
+		changed, err := waitForChange(ctx, watcher, process)
+		process.stop()
+		if err != nil || !changed {
+			return err
+		}
+		pterm.Info.Println("Files changed, restarting the neuron...")
+	}
+}
+
+// waitForChange waits for a file watched by watcher to change, and tells whether one did before ctx was done.  The
+// neuron may exit in the meantime, which is reported.
+func waitForChange(ctx context.Context, watcher *fsnotify.Watcher, process *neuronProcess) (bool, error) {
+	exited := process.exited()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-exited:
+			if process.err != nil {
+				pterm.Error.Printf("The neuron exited: %s\n", process.err)
+			} else {
+				pterm.Warning.Println("The neuron exited")
+			}
+			pterm.Info.Println("Waiting for changes...")
+			exited = nil
+		case err := <-watcher.Errors:
+			return false, err
+		case event := <-watcher.Events:
+			if !watchedChange(watcher, event) {
+				continue
+			}
+			// wait for the changes to settle.
+			timer := time.NewTimer(watchDebounce)
+			for settled := false; !settled; {
+				select {
+				case event := <-watcher.Events:
+					watchedChange(watcher, event)
+				case <-timer.C:
+					settled = true
+				}
+			}
+			return true, nil
+		}
+	}
+}
+
+// watchDir returns a watcher of dir and its sub directories, except hidden ones such as .git.
+func watchDir(dir string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := addWatchedDirs(watcher, dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// addWatchedDirs adds dir and its sub directories to watcher, skipping hidden ones.
+func addWatchedDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// watchedChange tells whether event changes the neuron, ignoring hidden files, editor backups and permission changes.
+// New directories are watched as well.
+func watchedChange(watcher *fsnotify.Watcher, event fsnotify.Event) bool {
+	name := filepath.Base(event.Name)
+	if event.Op == fsnotify.Chmod || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+		return false
+	}
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := addWatchedDirs(watcher, event.Name); err != nil {
+				pterm.Warning.Printf("Unable to watch %s: %s\n", event.Name, err)
+			}
+		}
+	}
+	return true
+}
+
+// neuronProcess is a running neuron.
+type neuronProcess struct {
+	cmd *exec.Cmd
+	// done is closed once the neuron exited, with err set to the reason.
+	done chan struct{}
+	err  error
+}
+
+// startNeuronProcess starts binary within dir, with env in addition to the environment of the CLI.
+func startNeuronProcess(binary string, dir string, env []string, stdout io.Writer, stderr io.Writer) (*neuronProcess, error) {
+	c := exec.Command(binary)
+	c.Dir = dir
+	c.Env = append(os.Environ(), env...)
+	c.Stdout = stdout
+	c.Stderr = stderr
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	p := &neuronProcess{cmd: c, done: make(chan struct{})}
+	go func() {
+		p.err = c.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// exited returns a channel which is closed once the neuron exited, nil if p is not running.
+func (p *neuronProcess) exited() <-chan struct{} {
+	if p == nil {
+		return nil
+	}
+	return p.done
+}
+
+// stop interrupts the neuron and waits for it to exit, killing it if it does not within neuronStopTimeout.
+func (p *neuronProcess) stop() {
+	if p == nil {
+		return
+	}
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+		// interrupts are not supported on Windows.
+		_ = p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.done:
+	case <-time.After(neuronStopTimeout):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pterm/pterm"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+)
+
+// goBuilds makes the stand-in for go build write a neuron which runs script.
+func (e *testEnv) goBuilds(script string) {
+	e.t.Helper()
+
+	path := filepath.Join(e.home, "bin", "go")
+	writeFile(e.t, path, `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-o) out=$2; shift ;;
+	esac
+	shift
+done
+cat > "$out" <<'EOF'
+#!/bin/sh
+`+script+`
+EOF
+chmod +x "$out"
+`)
+	if err := os.Chmod(path, 0755); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+// waitForLines waits for the file at path to hold n lines.
+func waitForLines(t *testing.T, path string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		b, _ := ioutil.ReadFile(path)
+		if strings.Count(string(b), "\n") >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s holds %q, want %d lines", path, b, n)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRunNeuron(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", Environment: pbProducts.ProductDeployment_DEV,
+		GoogleProjectId: "alis-in-dev-abc"})
+	e.backend.AddNeuronDeployment(&pbProducts.NeuronDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc/neurons/resources-events-v1", Version: "1.0.0",
+		Envs: []*pbProducts.Neuron_Env{{Name: "ALIS_OS_LOG_LEVEL", Value: "debug"}, {Name: "PORT", Value: "80"}}})
+	repo := e.repo("alis/products/in", map[string]string{"resources/events/v1/main.go": "package main\n"})
+	e.goBuilds(`echo "ENV=$ENV PORT=$PORT ALIS_OS_PROJECT=$ALIS_OS_PROJECT ALIS_OS_LOG_LEVEL=$ALIS_OS_LOG_LEVEL GOOGLE_APPLICATION_CREDENTIALS=$GOOGLE_APPLICATION_CREDENTIALS"`)
+
+	stdout, console := e.run("", "neuron", "run", "alis.in.resources-events-v1", "--deployment", "dev", "--port", "9090", "--watch=false")
+	if want := "ENV=LOCAL PORT=9090 ALIS_OS_PROJECT=alis-in-dev-abc ALIS_OS_LOG_LEVEL=debug GOOGLE_APPLICATION_CREDENTIALS=\n"; stdout != want {
+		t.Errorf("neuron printed %q, want %q", stdout, want)
+	}
+	if !strings.Contains(console, "alis product getkey alis.in --deployments alis-in-dev-abc") {
+		t.Errorf("expected a tip to create a key, got:\n%s", console)
+	}
+
+	// the key created by getkey is used once it exists.
+	key := filepath.Join(repo, "key-alis-in-dev-abc.json")
+	writeFile(t, key, "{}")
+	stdout, _ = e.run("", "neuron", "run", "alis.in.resources-events-v1", "--deployment", "dev", "--watch=false")
+	if !strings.Contains(stdout, "PORT=8080") || !strings.Contains(stdout, "GOOGLE_APPLICATION_CREDENTIALS="+key+"\n") {
+		t.Errorf("neuron printed %q", stdout)
+	}
+
+	// a neuron which fails is reported with its exit code.
+	e.goBuilds("exit 3")
+	_, console, err := e.runErr("", "neuron", "run", "alis.in.resources-events-v1", "--deployment", "dev", "--watch=false")
+	if err == nil || exitCode(err) != exitFailure {
+		t.Errorf("got %v, want a failure\n%s", err, console)
+	}
+}
+
+func TestNeuronRunWatch(t *testing.T) {
+	e := newTestEnv(t)
+	pterm.SetDefaultOutput(ioutil.Discard)
+	defer pterm.SetDefaultOutput(os.Stdout)
+
+	dir := filepath.Join(e.home, "neuron")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	log := filepath.Join(e.home, "run.log")
+	e.goBuilds(`echo started >> "$RUN_LOG"
+exec sleep 60`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		r := &neuronRun{Dir: dir, Env: []string{"RUN_LOG=" + log}, Watch: true, Stdout: ioutil.Discard, Stderr: ioutil.Discard}
+		done <- r.Run(ctx)
+	}()
+	waitForLines(t, log, 1)
+
+	// hidden files are ignored, while the neuron restarts once for files changed together.
+	writeFile(t, filepath.Join(dir, ".main.go.swp"), "")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "internal", "events.go"), "package internal\n")
+	waitForLines(t, log, 2)
+	time.Sleep(2 * watchDebounce)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run returned %v", err)
+		}
+	case <-time.After(neuronStopTimeout * 2):
+		t.Fatal("the neuron was not stopped")
+	}
+	if b, _ := ioutil.ReadFile(log); string(b) != "started\nstarted\n" {
+		t.Errorf("neuron was started %d times, want 2", strings.Count(string(b), "started"))
+	}
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+// command is an external program to run along with its arguments.  The arguments are passed to the program as
+// they are, without a shell in between, so they need no quoting.
+type command struct {
+	Name string
+	Args []string
+	// Dir is the working directory of the program, the current directory if empty.
+	Dir string
+	// Env holds environment variables, as NAME=VALUE, in addition to those of the CLI itself.
+	Env []string
+}
+
+// newCommand returns the command to run the program name with args.
+func newCommand(name string, args ...string) command {
+	return command{Name: name, Args: args}
+}
+
+// gitCommand returns the git command to run within the repository at repoPath.
+func gitCommand(repoPath string, args ...string) command {
+	return newCommand("git", append([]string{"-C", repoPath}, args...)...)
+}
+
+// unquotedArg matches the arguments which may be shown as they are.
+var unquotedArg = regexp.MustCompile(`^[a-zA-Z0-9@%_+=:,./-]+$`)
+
+// String returns c as it would be typed in a shell, for use in debug output and error messages.
+func (c command) String() string {
+	var parts []string
+	for _, arg := range append([]string{c.Name}, c.Args...) {
+		if !unquotedArg.MatchString(arg) {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	s := strings.Join(parts, " ")
+	if len(c.Env) > 0 {
+		s = strings.Join(c.Env, " ") + " " + s
+	}
+	if c.Dir != "" {
+		s = "(cd " + c.Dir + " && " + s + ")"
+	}
+	return s
+}
+
+// commandRunner runs commands.  Tests may replace the runner to fake the programs the CLI depends on.
+type commandRunner interface {
+	// Run runs c and returns what it wrote to its standard output and standard error.
+	Run(ctx context.Context, c command) (stdout []byte, stderr []byte, err error)
+}
+
+// runner runs the external programs of all commands.
+var runner commandRunner = execRunner{}
+
+// execRunner runs commands as processes, which are killed once the context is done.
+type execRunner struct{}
+
+func (execRunner) Run(ctx context.Context, c command) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// commandError is returned when a command fails, along with what it wrote to its standard error.
+type commandError struct {
+	Command string
+	Stderr  string
+	Err     error
+}
+
+func (e *commandError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s: %s", e.Command, e.Err)
+	}
+	return fmt.Sprintf("%s: %s\n%s", e.Command, e.Err, e.Stderr)
+}
+
+func (e *commandError) Unwrap() error { return e.Err }
+
+// run runs c with the runner, logging the command and its output in debug mode.  It returns what c wrote to its
+// standard output and standard error.
+func run(ctx context.Context, c command) (string, string, error) {
+	pterm.Debug.Printf("Shell command:\n%s\n", c)
+	stdout, stderr, err := runner.Run(ctx, c)
+	if len(stdout) > 0 {
+		pterm.Debug.Printf("%s\n", stdout)
+	}
+	if len(stderr) > 0 {
+		pterm.Debug.Printf("%s\n", stderr)
+	}
+	if err != nil {
+		return string(stdout), string(stderr), &commandError{Command: c.String(), Stderr: strings.TrimSpace(string(stderr)), Err: err}
+	}
+	return string(stdout), string(stderr), nil
+}
+
+// pullOrClone updates the git repository at repoPath, or runs clone if it cannot be updated, for example when the
+// repository has not been cloned yet.
+func pullOrClone(ctx context.Context, repoPath string, clone command) error {
+	_, _, err := run(ctx, gitCommand(repoPath, "pull", "--no-rebase"))
+	if err == nil {
+		return nil
+	}
+	_, _, err = run(ctx, clone)
+	return err
+}
+
+// cliModules are the modules of the CLI itself, which need to be fetched without the public Go module proxy to
+// install it.
+var cliModules = []string{"go.protobuf.alis.alis.exchange", "github.com/alis-x/cli/alis", "go.lib.alis.dev"}
+
+// privateModules returns the modules which need to be fetched without the public Go module proxy: those of the CLI
+// and those the code generated for each of the organisations depends on.
+func privateModules(organisationIDs ...string) []string {
+	modules := append([]string{}, cliModules...)
+	for _, org := range organisationIDs {
+		modules = append(modules, "go.lib."+org+".alis.exchange", "go.protobuf."+org+".alis.exchange",
+			"proto."+org+".alis.exchange")
+	}
+	if len(organisationIDs) > 0 {
+		modules = append(modules, "cli.alis.dev")
+	}
+	return modules
+}
+
+// mergeGoPrivate returns the GOPRIVATE patterns of goPrivate followed by the modules they do not cover yet.
+func mergeGoPrivate(goPrivate string, modules []string) string {
+	var patterns []string
+	if goPrivate != "" {
+		patterns = append(patterns, goPrivate)
+	}
+	for _, module := range modules {
+		if !matchesGoPrivate(strings.Join(patterns, ","), module) {
+			patterns = append(patterns, module)
+		}
+	}
+	return strings.Join(patterns, ",")
+}
+
+// setGoPrivate configures the go command to fetch the modules of the CLI and of the organisation directly from their
+// private repositories.  The modules are added to those already in GOPRIVATE.
+func setGoPrivate(ctx context.Context, organisationID string) error {
+	stdout, _, err := run(ctx, newCommand("go", "env", "GOPRIVATE"))
+	if err != nil {
+		return err
+	}
+	goPrivate := strings.TrimSpace(stdout)
+	merged := mergeGoPrivate(goPrivate, privateModules(organisationID))
+	if merged == goPrivate {
+		return nil
+	}
+	_, _, err = run(ctx, newCommand("go", "env", "-w", "GOPRIVATE="+merged))
+	return err
+}
+
+// protoIncludes returns the protoc arguments which make the Google and organisation protos available for import.
+func protoIncludes(organisationID string) []string {
+	return []string{
+		"-I=" + currentWorkspace().GoogleProtos(),
+		"-I=" + currentWorkspace().ProtoRepo(organisationID),
+	}
+}
+
+// protoFiles returns the .proto files directly within dir.
+func protoFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.proto"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s/*.proto: No such file or directory", dir)
+	}
+	return files, nil
+}
+
+// findProtoFiles returns all the .proto files within root and its sub directories.
+func findProtoFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".proto") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// resetDir removes the contents of dir, creating it if needed.
+func resetDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0755)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+)
+
+// fakeRunner records the commands it is asked to run, without running them.
+type fakeRunner struct {
+	commands []command
+	// outputs are the standard outputs of the commands, keyed by the program and its arguments.
+	outputs map[string]string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, c command) ([]byte, []byte, error) {
+	f.commands = append(f.commands, c)
+	return []byte(f.outputs[strings.Join(append([]string{c.Name}, c.Args...), " ")]), nil, nil
+}
+
+// withRunner replaces the runner for the duration of the test.
+func withRunner(t *testing.T, r commandRunner) {
+	old := runner
+	runner = r
+	t.Cleanup(func() { runner = old })
+}
+
+func TestCommandString(t *testing.T) {
+	c := gitCommand("/home/a b", "commit", "-m", "fix: don't break", "--", "x/y.go")
+	if got, want := c.String(), `git -C '/home/a b' commit -m 'fix: don'\''t break' -- x/y.go`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestCommitMessageWithQuote(t *testing.T) {
+	e := newTestEnv(t)
+	dir := e.repo("alis/products/in", map[string]string{"README.md": "in\n"})
+	writeFile(t, filepath.Join(dir, "README.md"), "in, updated\n")
+
+	message := "update(alis.in): don't break on `quotes` or $VARIABLES"
+	_, err := commitTagAndPush(context.Background(), dir, []string{filepath.Join(dir, "README.md")}, message, "alis.in.1.0.1", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.git(dir, "log", "-1", "--format=%s", "alis.in.1.0.1"); got != message {
+		t.Errorf("commit message = %q, want %q", got, message)
+	}
+}
+
+func TestGetKeyRunsGcloud(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	e.backend.AddProductDeployment(&pbProducts.ProductDeployment{
+		Name: "organisations/alis/products/in/deployments/in-dev-abc", GoogleProjectId: "in-dev-abc"})
+	fake := &fakeRunner{}
+	withRunner(t, fake)
+
+	_, console := e.run("", "product", "getkey", "alis.in", "--deployments", "in-dev-abc")
+
+	want := newCommand("gcloud", "iam", "service-accounts", "keys", "create",
+		filepath.Join(e.home, "alis.exchange/alis/products/in/key-in-dev-abc.json"),
+		"--iam-account=alis-exchange@in-dev-abc.iam.gserviceaccount.com", "--project=in-dev-abc")
+	// the command may be followed by the occasional update check.
+	if len(fake.commands) == 0 || !reflect.DeepEqual(fake.commands[0], want) {
+		t.Errorf("commands = %v, want %v\n%s", fake.commands, want, console)
+	}
+}
+
+func TestPublishPythonProtobufsInWorkspace(t *testing.T) {
+	workspace := t.TempDir()
+	viper.Set("workspace", workspace)
+	t.Cleanup(func() { viper.Set("workspace", "") })
+	repo := currentWorkspace().ProtobufPythonRepo("alis")
+	writeFile(t, filepath.Join(repo, "setup.py"), "setup(\n    version=\"0.0.7\",\n)\n")
+	oldTemplateFs := TemplateFs
+	TemplateFs = os.DirFS(filepath.Join("..", ".."))
+	t.Cleanup(func() { TemplateFs = oldTemplateFs })
+	fake := &fakeRunner{outputs: map[string]string{"git -C " + repo + " symbolic-ref --quiet --short HEAD": "master\n"}}
+	withRunner(t, fake)
+
+	organisation := &pbProducts.Organisation{Name: "organisations/alis", GoogleProjectId: "alis-org-123"}
+	if err := publishPythonProtobufs(context.Background(), organisation, "in", "resources-events-v1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the package is built and uploaded from the repository of the workspace, the one setup.py was bumped in.
+	var publish *command
+	for i, c := range fake.commands {
+		if c.Name == "bash" {
+			publish = &fake.commands[i]
+		}
+	}
+	if publish == nil || publish.Dir != repo || strings.Contains(publish.Args[1], "alis.exchange") {
+		t.Errorf("publish command = %+v, want it to run in %s", publish, repo)
+	}
+	if b, _ := os.ReadFile(filepath.Join(repo, "setup.py")); !strings.Contains(string(b), `version="0.0.8"`) {
+		t.Errorf("setup.py = %s", b)
+	}
+}
@@ -1,49 +1,247 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
-	"os/exec"
-	"regexp"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// cliModule is the Go module the CLI is installed from.
+const cliModule = "github.com/alis-x/cli/alis"
+
+const (
+	// updateCheckInterval is how often the latest version of the CLI is looked up to notify about updates.
+	updateCheckInterval = 24 * time.Hour
+	// updateCheckTimeout bounds the lookup of the latest version, which delays the command it follows.
+	updateCheckTimeout = 3 * time.Second
 )
 
+// ciEnvVars are environment variables set by CI systems, in which the update check is skipped.
+var ciEnvVars = []string{"CI", "BUILD_ID", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "TRAVIS", "JENKINS_URL", "TF_BUILD"}
+
+var updateVersionFlag string
+
 // updateCmd represents the update command
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: pterm.Blue("Update the alis_ CLI to the latest version"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: pterm.Green(
+		`Use this command to install the latest version of the CLI, or a specific one with --version.
+
+The CLI looks up the latest version once a day and lets you know when there is a newer one,
+but never installs it by itself.  Installing a specific version pins it, which silences the
+notifications until "alis update" is run without --version.  Set ALIS_NO_UPDATE_CHECK=true,
+or "alis config set no-update-check true", to turn off the check altogether.  It is also
+skipped in CI.`),
+	Example: pterm.LightYellow("alis update\nalis update --version 3.9.0"),
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		pterm.Info.Printf("Current version: %s\n", VERSION)
-		spinner, _ := pterm.DefaultSpinner.Start("Updating alis_ command line interface...")
-		cmds := "go env -w GOPRIVATE=go.protobuf.alis.alis.exchange,github.com/alis-x/cli/alis,go.lib.alis.dev && go install github.com/alis-x/cli/alis@latest"
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err := exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
-		if err != nil {
-			pterm.Debug.Println(cmds)
-			spinner.Fail(fmt.Sprintf("%s", out))
-			return
+
+		target := updateVersionFlag
+		if target != "" {
+			v, err := parseSemver(target)
+			if err != nil {
+				return err
+			}
+			target = "v" + v.String()
+		} else {
+			latest, err := latestVersion(cmd.Context())
+			if err != nil {
+				return err
+			}
+			target = latest
+			if newer, _ := isNewerVersion(latest, VERSION); !newer {
+				writeUpdateCheck(updateCheck{Checked: time.Now(), Latest: latest})
+				pterm.Success.Println("You already have the latest version installed.")
+				return nil
+			}
 		}
 
-		// check latest version
-		cmds = "alis --version"
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(cmd.Context(), "bash", "-c", cmds).CombinedOutput()
+		goPrivate, err := privateGoEnv(cmd.Context())
 		if err != nil {
-			pterm.Debug.Println(cmds)
-			spinner.Fail(fmt.Sprintf("%s", out))
-			return
+			return err
+		}
+		install := newCommand("go", "install", cliModule+"@"+target)
+		install.Env = []string{goPrivate}
+		if dryRunFlag {
+			dryRun("would run %s", install)
+			dryRunDone()
+			return nil
 		}
 
-		// get new version, if updated.
-		v := regexp.MustCompile(`(?m)alis version (\d+.\d+.\d+)`).FindAllStringSubmatch(fmt.Sprintf("%s", out), -1)
-		if v[0][1] == VERSION {
-			spinner.Success("You already have the latest version installed.")
-		} else {
-			spinner.Success(fmt.Sprintf("Updated version: %s -> %s\n", VERSION, v[0][1]))
+		spinner, _ := pterm.DefaultSpinner.Start("Installing alis_ command line interface " + target + "...")
+		if _, _, err := run(cmd.Context(), install); err != nil {
+			spinner.Fail()
+			return err
+		}
+		check := updateCheck{Checked: time.Now(), Latest: target}
+		if updateVersionFlag != "" {
+			check = readUpdateCheck()
+			check.Pinned = target
+		}
+		writeUpdateCheck(check)
+
+		// the alis found in the PATH may not be the one just installed, for example if GOBIN is not in the PATH.
+		out, _, err := run(cmd.Context(), newCommand("alis", "--version"))
+		v := regexp.MustCompile(`(?m)alis version (\S+)`).FindStringSubmatch(out)
+		if err != nil || v == nil || strings.TrimPrefix(target, "v") != v[1] {
+			spinner.Warning("Installed " + target + ", but the alis in your PATH does not report it.  Check that $(go env GOPATH)/bin is in your PATH.")
+			return nil
 		}
+		spinner.Success("Updated version: " + VERSION + " -> " + v[1])
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().StringVar(&updateVersionFlag, "version", "", pterm.Green("Install this version, for example 3.9.0, instead of the latest one, and pin it"))
+}
+
+// updateCheck is the outcome of the last lookup of the latest version, kept in the cache directory of the user.
+type updateCheck struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest,omitempty"`
+	// Pinned is the version installed with `alis update --version`, which silences the notifications.
+	Pinned string `json:"pinned,omitempty"`
+}
+
+func updateCheckFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "alis", "update-check.json"), nil
+}
+
+// readUpdateCheck returns the last update check, which is empty if there is none.
+func readUpdateCheck() updateCheck {
+	var check updateCheck
+	if path, err := updateCheckFile(); err == nil {
+		if b, err := ioutil.ReadFile(path); err == nil {
+			_ = json.Unmarshal(b, &check)
+		}
+	}
+	return check
+}
+
+// writeUpdateCheck records an update check.  It is only an optimisation, so failing to write it is ignored.
+func writeUpdateCheck(check updateCheck) {
+	path, err := updateCheckFile()
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(check)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0700) == nil {
+		_ = ioutil.WriteFile(path, b, 0600)
+	}
+}
+
+// updateCheckDisabled reports whether the update check is turned off with ALIS_NO_UPDATE_CHECK or the
+// no-update-check setting, or is skipped as the CLI runs in CI.
+func updateCheckDisabled() bool {
+	if viper.GetBool("no-update-check") {
+		return true
+	}
+	for _, name := range ciEnvVars {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyUpdate lets the user know when there is a newer version of the CLI, looking up the latest version at most
+// once per updateCheckInterval.  It never installs anything.
+func notifyUpdate(cmd *cobra.Command) {
+	switch {
+	case updateCheckDisabled(),
+		cmd == updateCmd,
+		cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd,
+		cmd.HasParent() && cmd.Parent() == completionCmd,
+		cmd == completionCmd:
+		return
+	}
+
+	check := readUpdateCheck()
+	if time.Since(check.Checked) > updateCheckInterval {
+		ctx, cancel := context.WithTimeout(cmd.Context(), updateCheckTimeout)
+		latest, err := latestVersion(ctx)
+		cancel()
+		// failed lookups are recorded too, to not delay every command while offline.
+		check.Checked = time.Now()
+		if err == nil {
+			check.Latest = latest
+		} else {
+			pterm.Debug.Printf("update check: %s\n", err)
+		}
+		writeUpdateCheck(check)
+	}
+
+	if check.Latest == "" || check.Pinned == "v"+VERSION {
+		return
+	}
+	if newer, _ := isNewerVersion(check.Latest, VERSION); newer {
+		ptermTip.Printf("alis %s is available, you have %s.  Run `alis update` to install it.\n",
+			strings.TrimPrefix(check.Latest, "v"), VERSION)
+	}
+}
+
+// isNewerVersion reports whether version has a higher precedence than current.
+func isNewerVersion(version string, current string) (bool, error) {
+	v, err := parseSemver(version)
+	if err != nil {
+		return false, err
+	}
+	c, err := parseSemver(current)
+	if err != nil {
+		return false, err
+	}
+	return v.compare(c) > 0, nil
+}
+
+// latestVersion looks up the latest release of the CLI, for example v3.9.1.
+func latestVersion(ctx context.Context) (string, error) {
+	goPrivate, err := privateGoEnv(ctx)
+	if err != nil {
+		return "", err
+	}
+	// run outside of any module the working directory may be in.
+	c := newCommand("go", "list", "-m", "-f", "{{.Version}}", cliModule+"@latest")
+	c.Env = []string{goPrivate}
+	c.Dir = os.TempDir()
+	stdout, _, err := run(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	latest := strings.TrimSpace(stdout)
+	if _, err := parseSemver(latest); err != nil {
+		return "", status.Errorf(codes.Internal, "unexpected version %q of %s", latest, cliModule)
+	}
+	return latest, nil
+}
+
+// privateGoEnv returns the GOPRIVATE environment variable which adds the private alis_ modules to the GOPRIVATE of
+// the user, for use by a single go command rather than changing the Go environment of the user.
+func privateGoEnv(ctx context.Context) (string, error) {
+	stdout, _, err := run(ctx, newCommand("go", "env", "GOPRIVATE"))
+	if err != nil {
+		return "", err
+	}
+	return "GOPRIVATE=" + mergeGoPrivate(strings.TrimSpace(stdout), cliModules), nil
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateNotification(t *testing.T) {
+	e := newTestEnv(t)
+	for _, name := range ciEnvVars {
+		t.Setenv(name, "")
+	}
+	t.Setenv("ALIS_NO_UPDATE_CHECK", "")
+	fake := &fakeRunner{outputs: map[string]string{
+		"go list -m -f {{.Version}} github.com/alis-x/cli/alis@latest": "v99.0.0\n",
+		"alis --version": "alis version " + VERSION + "\n",
+	}}
+	withRunner(t, fake)
+
+	_, console := e.run("", "config", "list")
+	if !strings.Contains(console, "alis 99.0.0 is available, you have "+VERSION) {
+		t.Errorf("no notification of the new version:\n%s", console)
+	}
+	var lookups int
+	for _, c := range fake.commands {
+		if c.Name == "go" && c.Args[0] == "list" {
+			lookups++
+			if got, want := strings.Join(c.Env, " "), "GOPRIVATE=go.protobuf.alis.alis.exchange,github.com/alis-x/cli/alis,go.lib.alis.dev"; got != want {
+				t.Errorf("env = %s, want %s", got, want)
+			}
+		}
+		if c.Name == "go" && c.Args[0] == "env" && c.Args[1] == "-w" || c.Name == "go" && c.Args[0] == "install" {
+			t.Errorf("the update check ran %s", c)
+		}
+	}
+
+	// the latest version is only looked up once a day.
+	_, console = e.run("", "config", "list")
+	if !strings.Contains(console, "alis 99.0.0 is available") {
+		t.Errorf("no notification of the new version:\n%s", console)
+	}
+	if lookups != 1 || len(fake.commands) != 2 {
+		t.Errorf("ran %v, want a single lookup of the latest version", fake.commands)
+	}
+
+	// pinning the current version silences the notification.
+	_, console = e.run("", "update", "--version", VERSION)
+	if c := fake.commands[len(fake.commands)-2]; c.String() != "GOPRIVATE=go.protobuf.alis.alis.exchange,github.com/alis-x/cli/alis,go.lib.alis.dev go install github.com/alis-x/cli/alis@v"+VERSION {
+		t.Errorf("ran %s, want go install of v%s\n%s", c, VERSION, console)
+	}
+	_, console = e.run("", "config", "list")
+	if strings.Contains(console, "is available") {
+		t.Errorf("notified about a new version of a pinned one:\n%s", console)
+	}
+
+	// updating to the latest version lifts the pin, but the check is turned off.
+	t.Setenv("ALIS_NO_UPDATE_CHECK", "true")
+	e.run("", "update")
+	_, console = e.run("", "config", "list")
+	if strings.Contains(console, "is available") {
+		t.Errorf("notified about a new version with ALIS_NO_UPDATE_CHECK:\n%s", console)
+	}
+}
@@ -2,27 +2,32 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"math/rand"
 	"os"
-	"os/exec"
+
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	pbOperations "go.protobuf.alis.alis.exchange/alis/os/resources/operations/v1"
 	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
 	pbParsers "go.protobuf.alis.alis.exchange/alis/os/services/parsers/v1"
 	"google.golang.org/genproto/googleapis/longrunning"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
@@ -57,145 +62,201 @@ type GoMod struct {
 }
 
 // commitTagAndPush is a utility to manage commits, tagging and git push commands.
+// It pushes the checked out branch, refuses to run when files outside of commitPaths have uncommitted changes and
+// fails with AlreadyExists, before anything is committed, if the tag exists locally or on the remote.
 // Returns the commit hash.
-func commitTagAndPush(ctx context.Context, repoPath string, commitPath string, message string, tag string, add bool, commit bool) (string, error) {
+func commitTagAndPush(ctx context.Context, repoPath string, commitPaths []string, message string, tag string, add bool, commit bool) (string, error) {
+	if dryRunFlag {
+		return planCommitTagAndPush(ctx, repoPath, commitPaths, message, tag, add, commit)
+	}
+	repo := gitRepo{Path: repoPath}
 
 	// Pull the latest changes to local environment
 	spinner, _ := pterm.DefaultSpinner.Start("Updating repositories updates for " + repoPath)
-	cmds := "git -C " + repoPath + " pull --no-rebase"
-	pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-	out, err := exec.CommandContext(ctx, "bash", "-c", cmds).CombinedOutput()
+	branch, err := repo.CurrentBranch(ctx)
+	if err == nil {
+		err = repo.CheckClean(ctx, commitPaths...)
+	}
+	if err == nil {
+		err = repo.Pull(ctx, branch)
+	}
 	if err != nil {
-		pterm.Debug.Println(fmt.Sprintf("%s", out))
+		spinner.Fail()
 		return "", err
 	}
 
+	// Make sure the tag is available before changing anything.
+	if tag != "" {
+		exists, err := repo.TagExists(ctx, tag)
+		if err != nil {
+			spinner.Fail()
+			return "", err
+		}
+		if exists {
+			err = status.Errorf(codes.AlreadyExists, "tag %s already exists in %s", tag, repoPath)
+			spinner.Warning(err.Error())
+			return "", err
+		}
+	}
+
 	// Commit changes.
 	if commit {
-		spinner.UpdateText("Commit changes for " + commitPath)
-		cmds = "git -C " + repoPath + " pull --no-rebase"
-		if add {
-			cmds = cmds + " && git -C " + repoPath + " add -- " + commitPath
-		}
-		cmds = cmds + " && git -C " + repoPath + " commit -m '" + message + "' -- " + commitPath
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err := exec.CommandContext(ctx, "bash", "-c", cmds).CombinedOutput()
-		if strings.Contains(fmt.Sprintf("%s", out), "Already up to date.") {
-			pterm.Warning.Println(fmt.Sprintf("%s", out))
-		} else if err != nil {
-			pterm.Debug.Println(fmt.Sprintf("%s", out))
-			return "", err
+		spinner.UpdateText("Commit changes for " + strings.Join(commitPaths, ", "))
+		changes, err := repo.Changes(ctx, add, commitPaths...)
+		if err == nil && len(changes) == 0 {
+			pterm.Warning.Printf("No changes to commit in %s\n", strings.Join(commitPaths, ", "))
+		} else {
+			if err == nil && add {
+				err = repo.Add(ctx, commitPaths...)
+			}
+			if err == nil {
+				err = repo.Commit(ctx, message, commitPaths...)
+			}
+			if err != nil {
+				spinner.Fail()
+				return "", err
+			}
 		}
 	}
 
 	// Push changes.
 	spinner.UpdateText("Pushing changes for " + repoPath)
 	if tag != "" {
-		cmds = "git -C " + repoPath + " tag '" + tag + "' && " +
-			"git -C " + repoPath + " push origin refs/heads/master:master --tags"
-	} else {
-		cmds = "git -C " + repoPath + " push origin refs/heads/master:master"
+		err = repo.Tag(ctx, tag)
 	}
-	pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-	out, err = exec.CommandContext(ctx, "bash", "-c", cmds).CombinedOutput()
-	if strings.Contains(fmt.Sprintf("%s", out), "already exists") {
-		spinner.Warning(fmt.Sprintf("%s", out))
-		return "", status.Errorf(codes.AlreadyExists, fmt.Sprintf("%s", out))
+	if err == nil {
+		err = repo.Push(ctx, branch, tag)
+		// leave the tag free for the next attempt.
+		if err != nil && tag != "" {
+			_, _ = repo.git(ctx, "tag", "--delete", tag)
+		}
 	}
 	if err != nil {
-		spinner.Fail(fmt.Sprintf("%s", out))
+		spinner.Fail()
 		return "", err
 	}
 	spinner.Success("Pushed repository " + pterm.LightGreen(repoPath) + " with tag " + pterm.LightGreen(tag))
 
 	// Return the hash of the commit if a tag was provided.
-	if tag != "" {
-		cmds = "git -C " + repoPath + " rev-parse " + tag
-		pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-		out, err = exec.CommandContext(ctx, "bash", "-c", cmds).Output()
-		if err != nil {
-			pterm.Debug.Println(fmt.Sprintf("%s", out))
-		}
-		pterm.Debug.Printf(string(out))
-
-		sha := strings.Replace(string(out), "\n", "", -1)
-		// sha should not be empty
-		if sha == "" {
-			return "", fmt.Errorf("the following command did not return a valid sha:\n%s\nplease run the following command to update the repo and try again:\n%s", cmds, "git -C "+repoPath+" pull --no-rebase")
-		}
-
-		return sha, nil
-	} else {
+	if tag == "" {
 		return "", nil
 	}
+	return repo.RevParse(ctx, tag)
 }
 
-// bumpVersion is a utility to increment the specified version by the releaseType
-// inline with semantic versioning.
-func bumpVersion(version string, releaseType string) (string, error) {
-	major, err := strconv.Atoi(strings.Split(version, ".")[0])
+var (
+	// pollInterval is the time to wait before the first poll of a long-running operation, which grows by half
+	// after every poll up to maxPollInterval.
+	pollInterval    = 2 * time.Second
+	maxPollInterval = 30 * time.Second
+
+	// timeoutFlag overrides the time a command waits for its long-running operations to complete.
+	timeoutFlag time.Duration
+)
+
+// waits for operation to complete
+func wait(ctx context.Context, operation *longrunning.Operation, startMessage string, successMessage string, timeout int, useSpinner bool) error {
+	_, err := waitOperation(ctx, operation, startMessage, successMessage, time.Duration(timeout)*time.Second, useSpinner)
+	return err
+}
+
+// waitOperation waits for operation to complete and returns the completed operation.  It gives up once timeout,
+// or the --timeout flag if set, has passed or ctx is cancelled, in which case the operation itself carries on.
+func waitOperation(ctx context.Context, operation *longrunning.Operation, startMessage string, successMessage string, timeout time.Duration, useSpinner bool) (*longrunning.Operation, error) {
+	clients, err := clientsFromContext(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	minor, err := strconv.Atoi(strings.Split(version, ".")[1])
-	if err != nil {
-		return "", err
+	if timeoutFlag > 0 {
+		timeout = timeoutFlag
+	}
+	pollCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	patch, err := strconv.Atoi(strings.Split(version, ".")[2])
-	if err != nil {
-		return "", err
+	var spinner *pterm.SpinnerPrinter
+	if useSpinner {
+		spinner, _ = pterm.DefaultSpinner.Start(startMessage)
+	}
+	fail := func(err error) (*longrunning.Operation, error) {
+		if spinner != nil {
+			spinner.Fail()
+		}
+		return nil, err
 	}
 
-	// increment version inline with semantic versioning
-	switch releaseType {
-	case "minor":
-		minor++
-	case "patch":
-		patch++
-	default:
-		return "", fmt.Errorf("release type %s not supported", releaseType)
+	name := operation.GetName()
+	operation, err = pollOperation(pollCtx, clients.Operations, operation, func(op *longrunning.Operation) {
+		if progress := operationProgress(op); spinner != nil && progress != "" {
+			spinner.UpdateText(startMessage + " (" + progress + ")")
+		}
+	})
+	switch {
+	case ctx.Err() != nil:
+		return fail(status.Errorf(codes.Canceled,
+			"stopped waiting for %s, which carries on in the background.\nrun `alis operation wait %s` to resume waiting", name, name))
+	case pollCtx.Err() != nil:
+		return fail(status.Errorf(codes.DeadlineExceeded,
+			"%s did not complete within %s, it carries on in the background.\nrun `alis operation wait %s` to resume waiting", name, timeout, name))
+	case err != nil:
+		return fail(err)
+	case operation.GetError() != nil:
+		return fail(&operationError{Name: name, Err: status.ErrorProto(operation.GetError())})
+	}
+
+	if spinner != nil {
+		spinner.Success(successMessage)
 	}
-	return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
+	return operation, nil
 }
 
-// waits for operation to complete
-func wait(ctx context.Context, operation *longrunning.Operation, startMessage string, successMessage string, timeout int, useSpinner bool) error {
-	// TODO: implement timeout
-	_ = timeout
-	if useSpinner {
-		var err error
-		spinner, _ := pterm.DefaultSpinner.Start(startMessage)
-		for !operation.GetDone() {
-			time.Sleep(5 * time.Second)
-			operation, err = alisOperationsClient.GetOperation(ctx, &pbOperations.GetOperationRequest{Name: operation.GetName()})
-			if err != nil {
-				spinner.Fail(err.Error())
-				return err
-			}
-			// TODO: improve user logging by updating text with metadata details of the operations object.
-			if operation.GetError() != nil {
-				spinner.Fail(operation.GetError().GetMessage())
-				return fmt.Errorf(operation.GetError().GetMessage())
-			}
+// pollOperation polls operation until it is done, backing off from pollInterval up to maxPollInterval between
+// polls.  progress, if not nil, is called with every update of the operation.
+func pollOperation(ctx context.Context, client pbOperations.ServiceClient, operation *longrunning.Operation, progress func(*longrunning.Operation)) (*longrunning.Operation, error) {
+	interval := pollInterval
+	for !operation.GetDone() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
 		}
-		spinner.Success(successMessage)
-	} else {
+
 		var err error
-		for !operation.GetDone() {
-			time.Sleep(5 * time.Second)
-			operation, err = alisOperationsClient.GetOperation(ctx, &pbOperations.GetOperationRequest{Name: operation.GetName()})
-			if err != nil {
-				return err
-			}
-			// TODO: improve user logging by updating text with metadata details of the operations object.
-			if operation.GetError() != nil {
-				return fmt.Errorf(operation.GetError().GetMessage())
-			}
+		operation, err = client.GetOperation(ctx, &pbOperations.GetOperationRequest{Name: operation.GetName()})
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(operation)
+		}
+
+		interval = interval * 3 / 2
+		if interval > maxPollInterval {
+			interval = maxPollInterval
 		}
 	}
-	return nil
+	return operation, nil
+}
+
+// operationProgress summarises the metadata of operation on a single line, or returns an empty string if
+// there is no metadata to show.
+func operationProgress(operation *longrunning.Operation) string {
+	if operation.GetMetadata() == nil {
+		return ""
+	}
+	metadata, err := operation.GetMetadata().UnmarshalNew()
+	if err != nil {
+		return ""
+	}
+	progress := prototext.MarshalOptions{}.Format(metadata)
+	if len(progress) > 80 {
+		progress = progress[:77] + "..."
+	}
+	return progress
 }
 
 // validateArgument validates an argument and returns an error if not valid.
@@ -215,26 +276,34 @@ func validateArgument(value string, regex string) error {
 // ask the user to select one or more
 // parent is the name of the Product resource
 func selectProductDeployments(ctx context.Context, parent string) ([]*pbProducts.ProductDeployment, error) {
+	clients, err := clientsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// list the deployments and ask user to select one.
-	productDeployments, err := alisProductsClient.ListProductDeployments(ctx, &pbProducts.ListProductDeploymentsRequest{
+	productDeployments, err := clients.Products.ListProductDeployments(ctx, &pbProducts.ListProductDeploymentsRequest{
 		Parent: parent,
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	if len(productDeployments.GetProductDeployments()) == 0 {
 		pterm.Warning.Printf("the product (%s) has no deployments\n", parent)
-		input, err := askUserString("Create a new ProductDeployment? (y|n):", `^y|n$`)
+		create, err := confirm("create-deployment", "Create a new ProductDeployment? (y|n):")
 		if err != nil {
 			return nil, err
 		}
 
-		if input == "y" {
+		if create {
 			productDeployment, err := createProductDeployment(ctx, parent)
 			if err != nil {
 				return nil, err
 			}
 			return []*pbProducts.ProductDeployment{productDeployment}, nil
 		} else {
-			return nil, status.Errorf(codes.NotFound, "product % has no deployments", parent)
+			return nil, status.Errorf(codes.NotFound, "product %s has no deployments", parent)
 		}
 	}
 
@@ -256,8 +325,8 @@ func selectProductDeployments(ctx context.Context, parent string) ([]*pbProducts
 		return nil, err
 	}
 
-	input, err := askUserString("Please select one or more deployments (use comma seperated indices, for example 1,2,5)\n"+
-		"or type 'NEW' to create a new deployment: ", `^NEW$|^(?:[0-9]+,)*[0-9]+$`)
+	input, err := ask("deployments", "Please select one or more deployments (use comma seperated indices, IDs or environments,\n"+
+		"for example 1,2,5 or dev,prod) or type 'NEW' to create a new deployment: ", `^NEW$|^(?:[^,]+,)*[^,]+$`)
 	if err != nil {
 		return nil, err
 	}
@@ -268,31 +337,25 @@ func selectProductDeployments(ctx context.Context, parent string) ([]*pbProducts
 			return nil, err
 		}
 		return []*pbProducts.ProductDeployment{res}, nil
-	} else {
-
-		var productDeploymentsSelection []*pbProducts.ProductDeployment
-
-		for _, s := range strings.Split(input, ",") {
-			i, err := strconv.Atoi(s)
-			if err != nil {
-				return nil, err
-			}
-			if i >= len(productDeployments.GetProductDeployments()) {
-				return nil, status.Errorf(codes.InvalidArgument, "%v is not a valid index selection", i)
-			}
-			productDeploymentsSelection = append(productDeploymentsSelection, productDeployments.GetProductDeployments()[i])
-		}
-		return productDeploymentsSelection, nil
 	}
+	return resolveProductDeployments(productDeployments.GetProductDeployments(), input)
 }
 
 // selectProductDeployment retrieves a list of deployments for a particular product and
 // ask the user to select a single one.
 func selectProductDeployment(ctx context.Context, parent string) (*pbProducts.ProductDeployment, error) {
+	clients, err := clientsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// list the deployments and ask user to select one.
-	productDeployments, err := alisProductsClient.ListProductDeployments(ctx, &pbProducts.ListProductDeploymentsRequest{
+	productDeployments, err := clients.Products.ListProductDeployments(ctx, &pbProducts.ListProductDeploymentsRequest{
 		Parent: parent,
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	if len(productDeployments.GetProductDeployments()) == 0 {
 		return nil, fmt.Errorf("the product (%s) has no deployments", parent)
@@ -316,21 +379,21 @@ func selectProductDeployment(ctx context.Context, parent string) (*pbProducts.Pr
 		return nil, err
 	}
 
-	input, err := askUserString("Please select one deployment from the list by using the\n index value (Example: 1): ",
-		`^*[0-9]+$`)
+	input, err := ask("deployment", "Please select one deployment from the list by using the\n index value (Example: 1): ",
+		`^[^,]+$`)
 	if err != nil {
 		return nil, err
 	}
 
-	i, err := strconv.Atoi(input)
+	selection, err := resolveProductDeployments(productDeployments.GetProductDeployments(), input)
 	if err != nil {
 		return nil, err
 	}
-	if i >= len(productDeployments.GetProductDeployments()) {
-		return nil, status.Errorf(codes.InvalidArgument, "%v is not a valid index selection", i)
+	if len(selection) != 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "%s matches %v deployments, please select a single one", input, len(selection))
 	}
 
-	return productDeployments.GetProductDeployments()[i], nil
+	return selection[0], nil
 }
 
 type DnsConfig struct {
@@ -369,8 +432,8 @@ func selectDnsConfig(ctx context.Context) (*DnsConfig, error) {
 		return nil, err
 	}
 
-	input, err := askUserString("Please select a which base URL to use for the documentation: ",
-		`^*[0-9]+$`)
+	input, err := ask("base-url", "Please select a which base URL to use for the documentation: ",
+		`^[0-9]+$`)
 	if err != nil {
 		return nil, err
 	}
@@ -386,6 +449,10 @@ func selectDnsConfig(ctx context.Context) (*DnsConfig, error) {
 	return &availableDnsConfigs[i], nil
 }
 
+// userInput buffers the responses of the user.  It is shared between prompts such that input which is read ahead
+// by one prompt is not lost to the next.
+var userInput = bufio.NewReader(os.Stdin)
+
 // askUserString ask the user for feedback and returns the response as a string.
 func askUserString(question string, regex string) (string, error) {
 
@@ -393,8 +460,7 @@ func askUserString(question string, regex string) (string, error) {
 	for {
 		var err error
 		ptermInput.Printf(question)
-		reader := bufio.NewReader(os.Stdin)
-		input, err = reader.ReadString('\n')
+		input, err = userInput.ReadString('\n')
 		input = strings.Replace(input, "\n", "", -1)
 		if err != nil {
 			return "", err
@@ -431,17 +497,26 @@ func generateParsedText() {
 
 }
 
+// contextValue returns the default organisation or product, set in the active profile or with ALIS_ORG and
+// ALIS_PRODUCT, to complete short forms of arguments.  The arguments are completed in place, which cobra passes on
+// to the Run function of the command.
+func contextValue(key string) (string, error) {
+	value := viper.GetString(key)
+	if value == "" {
+		return "", status.Errorf(codes.InvalidArgument, "no default %s is set, run `alis config set %s {%sID}` or pass the full argument", key, key, key)
+	}
+	return value, nil
+}
+
 // validateOrgArg is a utility used by the cobra command to validate Arguments.
 func validateOrgArg(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		pterm.Error.Println("requires an organisation argument in the format: ^[a-z][a-z0-9]{2,7}$")
-		return fmt.Errorf("")
+		return status.Error(codes.InvalidArgument, "requires an organisation argument in the format: ^[a-z][a-z0-9]{2,7}$")
 	}
 
 	err := validateArgument(args[0], "^[a-z][a-z0-9]{2,7}$")
 	if err != nil {
-		pterm.Error.Println(err)
-		return fmt.Errorf("")
+		return err
 	}
 
 	return nil
@@ -450,14 +525,21 @@ func validateOrgArg(cmd *cobra.Command, args []string) error {
 // validateProductArg is a utility used by the cobra command to validate Arguments.
 func validateProductArg(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		pterm.Error.Println("requires an organisation.product argument in the format: ^[a-z][a-z0-9]{2,7}.[a-z]{2}$")
-		return fmt.Errorf("")
+		return status.Error(codes.InvalidArgument, "requires an organisation.product argument in the format: ^[a-z][a-z0-9]{2,7}.[a-z]{2}$")
+	}
+
+	// a product on its own belongs to the organisation of the active profile.
+	if regexp.MustCompile(`^[a-z]{2}$`).MatchString(args[0]) {
+		org, err := contextValue("org")
+		if err != nil {
+			return err
+		}
+		args[0] = org + "." + args[0]
 	}
 
 	err := validateArgument(args[0], `^[a-z][a-z0-9]{2,7}\.[a-z]{2}$`)
 	if err != nil {
-		pterm.Error.Println(err)
-		return fmt.Errorf("")
+		return err
 	}
 
 	return nil
@@ -466,14 +548,28 @@ func validateProductArg(cmd *cobra.Command, args []string) error {
 // validateNeuronArg is a utility used by the cobra command to validate Arguments.
 func validateNeuronArg(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		pterm.Error.Println("requires an organisation.product.neuron argument in the format: ^[a-z]+.[a-z]{2}.(resources|services)-[a-z]+-v[0-9]+$")
-		return fmt.Errorf("")
+		return status.Error(codes.InvalidArgument, "requires an organisation.product.neuron argument in the format: ^[a-z]+.[a-z]{2}.(resources|services)-[a-z]+-v[0-9]+$")
+	}
+
+	// short forms, product.neuron and neuron, are completed from the active profile.
+	if regexp.MustCompile(`^([a-z]{2}\.)?(resources|services)-[a-z]+-v[0-9]+$`).MatchString(args[0]) {
+		if !strings.Contains(args[0], ".") {
+			product, err := contextValue("product")
+			if err != nil {
+				return err
+			}
+			args[0] = product + "." + args[0]
+		}
+		org, err := contextValue("org")
+		if err != nil {
+			return err
+		}
+		args[0] = org + "." + args[0]
 	}
 
 	err := validateArgument(args[0], `^[a-z][a-z0-9]{2,7}\.[a-z]{2}\.(resources|services)-[a-z]+-v[0-9]+$`)
 	if err != nil {
-		pterm.Error.Println(err)
-		return fmt.Errorf("")
+		return err
 	}
 	return nil
 }
@@ -481,14 +577,12 @@ func validateNeuronArg(cmd *cobra.Command, args []string) error {
 // validateOrgOrProductOrNeuron is a utility used by the cobra command to validate Arguments.
 func validateOrgOrProductOrNeuron(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		pterm.Error.Println(`requires an organisation product or neuron argument in the format: ^([a-z][a-z0-9]{2,7})(\.[a-z]{2})*(\.(resources|services)-[a-z]+-v[0-9]+)*$`)
-		return fmt.Errorf("")
+		return status.Error(codes.InvalidArgument, `requires an organisation product or neuron argument in the format: ^([a-z][a-z0-9]{2,7})(\.[a-z]{2})*(\.(resources|services)-[a-z]+-v[0-9]+)*$`)
 	}
 
 	err := validateArgument(args[0], `^([a-z][a-z0-9]{2,7})(\.[a-z]{2})*(\.(resources|services)-[a-z]+-v[0-9]+)*$`)
 	if err != nil {
-		pterm.Error.Println(err)
-		return fmt.Errorf("")
+		return err
 	}
 
 	return nil
@@ -497,8 +591,13 @@ func validateOrgOrProductOrNeuron(cmd *cobra.Command, args []string) error {
 // createProductDeployment creates a new product deployment and waits until done.
 func createProductDeployment(ctx context.Context, productName string) (*pbProducts.ProductDeployment, error) {
 
+	clients, err := clientsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// retrieve a copy of the Product Resource
-	product, err := alisProductsClient.GetProduct(ctx, &pbProducts.GetProductRequest{Name: productName})
+	product, err := clients.Products.GetProduct(ctx, &pbProducts.GetProductRequest{Name: productName})
 	if err != nil {
 		return nil, err
 	}
@@ -507,7 +606,7 @@ func createProductDeployment(ctx context.Context, productName string) (*pbProduc
 	pterm.Info.Println("Great. Let's create a new deployment.  Please provide the following for the deployment:")
 
 	env := pbProducts.ProductDeployment_DEV
-	envStr, err := askUserString("Development or Production environment? (PROD|DEV): ", `^PROD$|^DEV$`)
+	envStr, err := ask("environment", "Development or Production environment? (PROD|DEV): ", `^PROD$|^DEV$`)
 	if err != nil {
 		return nil, err
 	}
@@ -515,21 +614,21 @@ func createProductDeployment(ctx context.Context, productName string) (*pbProduc
 		env = pbProducts.ProductDeployment_PROD
 	}
 
-	displayName, err := askUserString("Display Name: ", `^[A-Za-z0-9- ]+$`)
+	displayName, err := ask("display-name", "Display Name: ", `^[A-Za-z0-9- ]+$`)
 	if err != nil {
 		return nil, err
 	}
-	owner, err := askUserString("Owner (email): ", `(?m)^([a-zA-Z0-9_\-\.]+)@([a-zA-Z0-9_\-\.]+)\.([a-zA-Z]{2,10})$`)
+	owner, err := ask("owner", "Owner (email): ", `(?m)^([a-zA-Z0-9_\-\.]+)@([a-zA-Z0-9_\-\.]+)\.([a-zA-Z]{2,10})$`)
 	if err != nil {
 		return nil, err
 	}
 	ptermTip.Printf("The Product (%s) has a billing account ID of %s\n", product.GetName(), strings.Split(product.GetBillingAccount(), "/")[1]+"\nNavigate to https://console.cloud.google.com/billing to see the billing accounts available to you.")
-	billingAccountID, err := askUserString("ProductDeployment Billing Account ID: ", `^[A-Z0-9]{6}-[A-Z0-9]{6}-[A-Z0-9]{6}$`)
+	billingAccountID, err := ask("billing-account", "ProductDeployment Billing Account ID: ", `^[A-Z0-9]{6}-[A-Z0-9]{6}-[A-Z0-9]{6}$`)
 	if err != nil {
 		return nil, err
 	}
 
-	op, err := alisProductsClient.CreateProductDeployment(ctx, &pbProducts.CreateProductDeploymentRequest{
+	op, err := clients.Products.CreateProductDeployment(ctx, &pbProducts.CreateProductDeploymentRequest{
 		Parent: product.GetName(),
 		ProductDeployment: &pbProducts.ProductDeployment{
 			Environment:    env,
@@ -548,7 +647,7 @@ func createProductDeployment(ctx context.Context, productName string) (*pbProduc
 		return nil, err
 	}
 
-	res, err := alisOperationsClient.GetOperation(ctx, &pbOperations.GetOperationRequest{Name: op.GetName()})
+	res, err := clients.Operations.GetOperation(ctx, &pbOperations.GetOperationRequest{Name: op.GetName()})
 	if err != nil {
 		return nil, err
 	}
@@ -567,6 +666,23 @@ func askUserProductEnvs(envs []*pbProducts.Product_Env) ([]*pbProducts.Product_E
 
 	var res []*pbProducts.Product_Env
 
+	// update the envs without walking through each of them when answered up front, or when the user is not around.
+	if envEditsProvided() || yesFlag || nonInteractive() {
+		var pairs [][2]string
+		for _, env := range envs {
+			pairs = append(pairs, [2]string{env.GetName(), env.GetValue()})
+		}
+		pairs, err := editEnvs(pairs)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			res = append(res, &pbProducts.Product_Env{Name: pair[0], Value: pair[1]})
+		}
+		pterm.Debug.Printf("Updated values:\n%s\n", res)
+		return res, nil
+	}
+
 	if len(envs) > 0 {
 		table := pterm.TableData{{"Index", "Environment Variable", "Current Value"}}
 		for i, env := range envs {
@@ -638,6 +754,23 @@ func askUserNeuronEnvs(envs []*pbProducts.Neuron_Env) ([]*pbProducts.Neuron_Env,
 
 	var res []*pbProducts.Neuron_Env
 
+	// update the envs without walking through each of them when answered up front, or when the user is not around.
+	if envEditsProvided() || yesFlag || nonInteractive() {
+		var pairs [][2]string
+		for _, env := range envs {
+			pairs = append(pairs, [2]string{env.GetName(), env.GetValue()})
+		}
+		pairs, err := editEnvs(pairs)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			res = append(res, &pbProducts.Neuron_Env{Name: pair[0], Value: pair[1]})
+		}
+		pterm.Debug.Printf("Updated values:\n%s\n", res)
+		return res, nil
+	}
+
 	if len(envs) > 0 {
 		table := pterm.TableData{{"Index", "Environment Variable", "Current Value"}}
 		for i, env := range envs {
@@ -704,49 +837,6 @@ func askUserNeuronEnvs(envs []*pbProducts.Neuron_Env) ([]*pbProducts.Neuron_Env,
 	return res, nil
 }
 
-// askUserNeuronState list the current envs and ask for updated values.
-func askUserNeuronState(state pbProducts.Neuron_State) (pbProducts.Neuron_State, error) {
-
-	table := pterm.TableData{{"Index", "Neuron States", "Current State"}}
-	for i := 0; i < len(pbProducts.Neuron_State_name); i++ {
-		currentState := ""
-		if state == pbProducts.Neuron_State(i) {
-			currentState = pterm.LightGreen("\u2713")
-		}
-		table = append(table, []string{
-			fmt.Sprintf("%v", i),
-			fmt.Sprintf("%v", pbProducts.Neuron_State_name[int32(i)]),
-			currentState,
-		})
-	}
-
-	err := pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(table).Render()
-	if err != nil {
-		return 0, err
-	}
-
-	var selection int
-	for {
-		input, err := askUserString("Please select a state (use Index): ", "^[0-9]+$")
-		if err != nil {
-			return 0, err
-		}
-		selection, err = strconv.Atoi(input)
-
-		if selection >= len(pbProducts.Neuron_State_name) {
-			pterm.Error.Printf("%v is an invalid selection, please try again...\n", selection)
-		} else {
-			break
-		}
-	}
-
-	var res pbProducts.Neuron_State
-	res = pbProducts.Neuron_State(selection)
-
-	pterm.Debug.Printf("User selected values:\n%s\n", res.String())
-	return res, nil
-}
-
 // askUserNeuronDeploymentState list the current envs and ask for updated values.
 func askUserNeuronDeploymentState(state pbProducts.NeuronDeployment_State) (pbProducts.NeuronDeployment_State, error) {
 
@@ -770,13 +860,16 @@ func askUserNeuronDeploymentState(state pbProducts.NeuronDeployment_State) (pbPr
 
 	var selection int
 	for {
-		input, err := askUserString("Please select a state (use Index): ", "^[0-9]+$")
+		input, err := ask("deployment-state", "Please select a state (use Index): ", "^[0-9]+$")
 		if err != nil {
 			return 0, err
 		}
 		selection, err = strconv.Atoi(input)
 
 		if selection >= len(pbProducts.NeuronDeployment_State_name) {
+			if answered("deployment-state") {
+				return 0, status.Errorf(codes.InvalidArgument, "%v is an invalid state selection", selection)
+			}
 			pterm.Error.Printf("%v is an invalid selection, please try again...\n", selection)
 		} else {
 			break
@@ -794,9 +887,9 @@ func askUserNeuronDeploymentState(state pbProducts.NeuronDeployment_State) (pbPr
 func findNeuronDockerFilePaths(neuron string) ([]string, error) {
 	orgId := strings.Split(neuron, ".")[0]
 	productId := strings.Split(neuron, ".")[1]
-	neuronId := strings.Join(strings.Split(neuron, ".")[2:], "/")
+	neuronId := strings.Join(strings.Split(neuron, ".")[2:], "-")
 
-	root := fmt.Sprintf("%s/alis.exchange/%s/products/%s/%s", homeDir, orgId, productId, neuronId)
+	root := currentWorkspace().NeuronDir(orgId, productId, neuronId)
 
 	var paths []string
 
@@ -816,7 +909,7 @@ func findNeuronDockerFilePaths(neuron string) ([]string, error) {
 }
 
 // getNeuronDescriptor creates a temp descriptor.pb file to parse the contents to a FileDescriptorSet object.
-func getNeuronDescriptor(neuron string) (*descriptorpb.FileDescriptorSet, error) {
+func getNeuronDescriptor(ctx context.Context, neuron string) (*descriptorpb.FileDescriptorSet, error) {
 
 	organisationID := strings.Split(neuron, "/")[1]
 	productID := strings.Split(neuron, "/")[3]
@@ -827,17 +920,17 @@ func getNeuronDescriptor(neuron string) (*descriptorpb.FileDescriptorSet, error)
 	// Generate the descriptor.pb at neuron level
 	// This descriptor file represents the .proto files at the point in time
 	// which will be used when creating a new NeuronVersion resource.
-	neuronProtoFullPath := homeDir + "/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
-	cmds := "protoc --descriptor_set_out=$HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/") + "/descriptor.pb -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto --include_source_info " + neuronProtoFullPath + "/*.proto"
-	pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-	out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
-	if err != nil {
-		if strings.Contains(fmt.Sprintf("%s", out), "No such file or directory") {
-			pterm.Warning.Print(fmt.Sprintf("%s\n", out))
-			return nil, nil
-		} else {
-			return nil, err
-		}
+	neuronProtoFullPath := currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+	files, err := protoFiles(neuronProtoFullPath)
+	if err != nil {
+		pterm.Warning.Print(fmt.Sprintf("%s\n", err))
+		return nil, nil
+	}
+	args := append([]string{"--descriptor_set_out=" + neuronProtoFullPath + "/descriptor.pb"}, protoIncludes(organisationID)...)
+	args = append(args, "--include_source_info")
+	_, _, err = run(ctx, newCommand("protoc", append(args, files...)...))
+	if err != nil {
+		return nil, err
 	}
 
 	b, err := ioutil.ReadFile(neuronProtoFullPath + "/descriptor.pb")
@@ -862,7 +955,7 @@ func getNeuronDescriptor(neuron string) (*descriptorpb.FileDescriptorSet, error)
 
 func generatePublicLocalDescriptorFileFromNeuron(ctx context.Context, neuron string, protobufFullPath string) (*string, error) {
 
-	fds, err := getNeuronDescriptor(neuron)
+	fds, err := getNeuronDescriptor(ctx, neuron)
 	if err != nil {
 		return nil, err
 	}
@@ -872,9 +965,14 @@ func generatePublicLocalDescriptorFileFromNeuron(ctx context.Context, neuron str
 		Restriction:       nil,
 	}
 
-	fds, err = alisParsersClient.GenerateRestrictionScopedFileDescriptorSet(ctx, &req)
+	clients, err := clientsFromContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("could not generate scoped FDS: ", err)
+		return nil, err
+	}
+
+	fds, err = clients.Parsers.GenerateRestrictionScopedFileDescriptorSet(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate scoped FDS: %w", err)
 	}
 
 	b, err := proto.Marshal(fds)
@@ -899,18 +997,15 @@ func generatePublicLocalDescriptorFileFromNeuron(ctx context.Context, neuron str
 	return &fileName, nil
 }
 
-func clearUncommittedRepoChanges(repoPath string) error {
+func clearUncommittedRepoChanges(ctx context.Context, repoPath string) error {
 	//// Clear any uncommitted changes to the repository
 	//// This ensures that we are able to push protobuf changes generated in the next section in all scenarios
 	//// When working on multiple neurons at the same time, there could be other uncommitted changes which will
 	//// cause a merge conflict when committing the new protocol buffers in the push section below.
-	cmds := "git -C " + repoPath + " reset --hard"
-	pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-	out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+	_, _, err := run(ctx, gitCommand(repoPath, "reset", "--hard"))
 	if err != nil {
-		return fmt.Errorf("could not reset uncommitted changes: %s", err)
+		return fmt.Errorf("could not reset uncommitted changes: %w", err)
 	}
-	pterm.Debug.Printf("%s\n", out)
 
 	return nil
 }
@@ -927,24 +1022,146 @@ func generateRandomId(n int) string {
 }
 
 // genProductDescriptorFile generates a descriptor.pb file at the product level.
-func genProductDescriptorFile(product string) error {
+func genProductDescriptorFile(ctx context.Context, product string) error {
 	organisationID := strings.Split(product, "/")[1]
 	productID := strings.Split(product, "/")[3]
 
 	// Generate the descriptor.pb at product level
 	// The descriptor.pb at product level represents all the underlying neurons.
-	cmds := "go env -w GOPRIVATE=go.lib." + organisationID + ".alis.exchange,go.protobuf." + organisationID + ".alis.exchange,proto." + organisationID + ".alis.exchange,cli.alis.dev && " +
-		"protoc --descriptor_set_out=$HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + "/descriptor.pb -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto --include_imports --include_source_info $(find $HOME/alis.exchange/" + organisationID + "/proto/" + organisationID + "/" + productID + " -iname \"*.proto\")"
-	pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-	out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+	err := setGoPrivate(ctx, organisationID)
 	if err != nil {
-		return fmt.Errorf("error %s, with command line output:\n %s", err, out)
+		return err
 	}
-	return nil
+	productProtoPath := currentWorkspace().ProductProtos(organisationID, productID)
+	files, err := findProtoFiles(productProtoPath)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"--descriptor_set_out=" + productProtoPath + "/descriptor.pb"}, protoIncludes(organisationID)...)
+	args = append(args, "--include_imports", "--include_source_info")
+	_, _, err = run(ctx, newCommand("protoc", append(args, files...)...))
+	return err
+}
+
+// generateGoProtobufs generates the Go protocol buffers of the protos in protoDir into outDir, the root of a
+// protobuf/go repository.  Returns what protoc reported on its standard error, such as warnings.
+func generateGoProtobufs(ctx context.Context, organisationID string, protoDir string, outDir string) (string, error) {
+	files, err := protoFiles(protoDir)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"--go_out=" + outDir, "--go_opt=paths=source_relative", "--go-grpc_out=" + outDir, "--go-grpc_opt=paths=source_relative"}
+	args = append(args, protoIncludes(organisationID)...)
+	_, stderr, err := run(ctx, newCommand("protoc", append(args, files...)...))
+	return stderr, err
+}
+
+// pythonNamespaceInit is the content of the __init__.py files of the namespace packages of the Python protocol buffers.
+const pythonNamespaceInit = "__import__('pkg_resources').declare_namespace(__name__)\n"
+
+// generatePythonProtobufs generates the Python protocol buffers of the neuron into the protobuf/python repository
+// of the organisation.  Returns what protoc reported on its standard error, such as warnings, along with the files
+// of the namespace packages it created.
+func generatePythonProtobufs(ctx context.Context, organisationID string, productID string, neuronID string) (string, []string, error) {
+	pythonRepo := currentWorkspace().ProtobufPythonRepo(organisationID)
+	neuronProtobufFullPath := pythonRepo + "/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
+	neuronProtoFullPath := currentWorkspace().NeuronProtos(organisationID, productID, neuronID)
+
+	err := resetDir(neuronProtobufFullPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// mark the product and each level of the neuron as namespace packages.
+	var initFiles []string
+	dir := pythonRepo + "/" + organisationID + "/" + productID
+	for _, part := range append([]string{""}, strings.Split(neuronID, "-")...) {
+		if part != "" {
+			dir += "/" + part
+		}
+		initFile := dir + "/__init__.py"
+		err = ioutil.WriteFile(initFile, []byte(pythonNamespaceInit), 0644)
+		if err != nil {
+			return "", nil, err
+		}
+		initFiles = append(initFiles, initFile)
+	}
+
+	files, err := protoFiles(neuronProtoFullPath)
+	if err != nil {
+		return "", nil, err
+	}
+	args := append([]string{"-m", "grpc_tools.protoc", "--python_out=" + pythonRepo, "--grpc_python_out=" + pythonRepo}, protoIncludes(organisationID)...)
+	_, stderr, err := run(ctx, newCommand("python3", append(args, files...)...))
+	return stderr, initFiles, err
+}
+
+// publishPythonProtobufs bumps the version of the protobuf/python package of the organisation, uploads it to its
+// artifact registry and pushes the generated files of the neuron.
+func publishPythonProtobufs(ctx context.Context, organisation *pbProducts.Organisation, productID string, neuronID string, initFiles []string) error {
+	organisationID := strings.Split(organisation.GetName(), "/")[1]
+	protobufPythonRepo := currentWorkspace().ProtobufPythonRepo(organisationID)
+	neuronProtobufFullPath := protobufPythonRepo + "/" + organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
+
+	// bump setup.py version
+	setupPy, err := ioutil.ReadFile(protobufPythonRepo + "/setup.py")
+	if err != nil {
+		return err
+	}
+	rel := regexp.MustCompile("version=\"(.*)\",")
+	match := rel.FindSubmatch(setupPy)
+	if match == nil {
+		return fmt.Errorf("no version found in %s/setup.py", protobufPythonRepo)
+	}
+	versionComponents := strings.Split(string(match[1]), ".")
+	patchVersion, err := strconv.Atoi(versionComponents[len(versionComponents)-1])
+	if err != nil {
+		return err
+	}
+	versionComponents[len(versionComponents)-1] = strconv.Itoa(patchVersion + 1)
+	setupPy = rel.ReplaceAll(setupPy, []byte("version=\""+strings.Join(versionComponents, ".")+"\","))
+	err = ioutil.WriteFile(protobufPythonRepo+"/setup.py", setupPy, 0644)
+	if err != nil {
+		return err
+	}
+
+	// publish Python package to artifact registry
+	var tpl bytes.Buffer
+	publishTemplate, err := fs.ReadFile(TemplateFs, "internal/cmd/neuron/python/publishPython.sh")
+	if err != nil {
+		return err
+	}
+	t, err := template.New("file").Parse(string(publishTemplate))
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(&tpl, struct {
+		OrgProjectID string
+	}{
+		OrgProjectID: organisation.GetGoogleProjectId(),
+	}); err != nil {
+		return err
+	}
+
+	// the script is part of the CLI, only takes the project of the organisation, and runs within the package.
+	publish := newCommand("bash", "-c", tpl.String())
+	publish.Dir = protobufPythonRepo
+	_, stderr, err := run(ctx, publish)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(stderr, "warning") {
+		pterm.Warning.Print(fmt.Sprintf("Publishing protocol buffers for python...\n%s", stderr))
+	}
+
+	message := fmt.Sprintf("chore(%s): updated by alis_ CLI", neuronID)
+	commitPaths := append([]string{neuronProtobufFullPath, protobufPythonRepo + "/setup.py"}, initFiles...)
+	_, err = commitTagAndPush(ctx, protobufPythonRepo, commitPaths, message, "", true, true)
+	return err
 }
 
 // genDescriptorFile generates a descriptor.pb file at the neuron level.
-func genDescriptorFile(name string) (string, error) {
+func genDescriptorFile(ctx context.Context, name string) (string, error) {
 	// parse the resource name
 	nameParts := strings.Split(name, "/")
 	organisationID = nameParts[1]
@@ -961,14 +1178,19 @@ func genDescriptorFile(name string) (string, error) {
 
 	// Generate the descriptor.pb at the relevant org/product/neuron level
 	// The descriptor.pb at product level represents all the underlying neurons.
-	cmds := "protoc --descriptor_set_out=$HOME/alis.exchange/" + organisationID + "/proto/" + protoPath + "/descriptor.pb -I=$HOME/alis.exchange/google/proto -I=$HOME/alis.exchange/" + organisationID + "/proto --include_imports --include_source_info $(find $HOME/alis.exchange/" + organisationID + "/proto/" + protoPath + " -iname \"*.proto\")"
-	pterm.Debug.Printf("Shell command:\n%s\n", cmds)
-	out, err := exec.CommandContext(context.Background(), "bash", "-c", cmds).CombinedOutput()
+	protoFullPath := filepath.Join(currentWorkspace().ProtoRepo(organisationID), protoPath)
+	files, err := findProtoFiles(protoFullPath)
 	if err != nil {
-		return "", fmt.Errorf("error %s, with command line output:\n %s", err, out)
+		return "", err
+	}
+	args := append([]string{"--descriptor_set_out=" + protoFullPath + "/descriptor.pb"}, protoIncludes(organisationID)...)
+	args = append(args, "--include_imports", "--include_source_info")
+	_, _, err = run(ctx, newCommand("protoc", append(args, files...)...))
+	if err != nil {
+		return "", err
 	}
 
-	return homeDir + "/alis.exchange/" + organisationID + "/proto/" + protoPath + "/descriptor.pb", nil
+	return protoFullPath + "/descriptor.pb", nil
 }
 
 // validGitDirectory check that the provided directory is a valid git directory.
@@ -982,13 +1204,12 @@ func getGoMod(ctx context.Context, neuronPath string) (*GoMod, error) {
 
 	goMod := &GoMod{}
 
-	cmds := "go mod edit -json " + neuronPath + "/go.mod"
-	out, err := exec.CommandContext(ctx, "bash", "-c", cmds).CombinedOutput()
+	out, _, err := run(ctx, newCommand("go", "mod", "edit", "-json", neuronPath+"/go.mod"))
 	if err != nil {
 		return nil, err
 	}
 	// marshall the commandline output to a GoMod type.
-	err = json.Unmarshal(out, &goMod)
+	err = json.Unmarshal([]byte(out), &goMod)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The release types of the `--release` flag.
+const (
+	releaseMajor      = "major"
+	releaseMinor      = "minor"
+	releasePatch      = "patch"
+	releasePrerelease = "prerelease"
+)
+
+var (
+	versionFlag       string
+	preReleaseFlag    string
+	buildMetadataFlag string
+)
+
+// semverRegex matches a semantic version as defined by https://semver.org, with an optional leading v.
+var semverRegex = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// semver is a semantic version, for example 1.4.0-rc.2+20220501.
+type semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// parseSemver parses version, which may have a leading v.
+func parseSemver(version string) (semver, error) {
+	m := semverRegex.FindStringSubmatch(version)
+	if m == nil {
+		return semver{}, status.Errorf(codes.InvalidArgument, "%s is not a valid semantic version, for example 1.4.0 or 1.4.0-rc.1", version)
+	}
+	var v semver
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	v.Prerelease = m[4]
+	v.Build = m[5]
+	return v, nil
+}
+
+func (v semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// compare returns -1, 0 or 1 if v has a lower, equal or higher precedence than o.  Build metadata does not
+// affect the precedence.
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// a pre-release has a lower precedence than the release itself.
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(o.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		switch {
+		case errX == nil && errY == nil:
+			return sign(x - y)
+		case errX == nil:
+			// numeric identifiers have a lower precedence than alphanumeric ones.
+			return -1
+		case errY == nil:
+			return 1
+		default:
+			return sign(strings.Compare(a[i], b[i]))
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+// bumpVersion is a utility to increment the specified version by the releaseType
+// inline with semantic versioning.
+//
+// With a preRelease identifier, for example rc, a major, minor or patch release becomes the first release candidate
+// of that release, such as 1.3.0-rc.1.  A prerelease release increments the last number of the pre-release of
+// version, or starts the first pre-release of the next patch.
+func bumpVersion(version string, releaseType string, preRelease string) (string, error) {
+	v, err := parseSemver(version)
+	if err != nil {
+		return "", err
+	}
+	v.Build = ""
+
+	switch releaseType {
+	case releaseMajor:
+		v = semver{Major: v.Major + 1}
+	case releaseMinor:
+		v = semver{Major: v.Major, Minor: v.Minor + 1}
+	case releasePatch:
+		// the release of a pre-release, for example 1.3.0 following 1.3.0-rc.2.
+		if v.Prerelease == "" || preRelease != "" {
+			v.Patch++
+		}
+		v.Prerelease = ""
+	case releasePrerelease:
+		if v.Prerelease == "" || (preRelease != "" && !strings.HasPrefix(v.Prerelease, preRelease+".")) {
+			if v.Prerelease == "" {
+				v.Patch++
+			}
+			if preRelease == "" {
+				preRelease = "rc"
+			}
+			v.Prerelease = preRelease + ".1"
+			return v.String(), nil
+		}
+		parts := strings.Split(v.Prerelease, ".")
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			parts = append(parts, "1")
+		} else {
+			parts[len(parts)-1] = strconv.Itoa(n + 1)
+		}
+		v.Prerelease = strings.Join(parts, ".")
+		return v.String(), nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "release type %s not supported, use one of %s, %s, %s or %s",
+			releaseType, releasePatch, releaseMinor, releaseMajor, releasePrerelease)
+	}
+
+	if preRelease != "" {
+		v.Prerelease = preRelease + ".1"
+	}
+	return v.String(), nil
+}
+
+// nextVersion returns the version to use instead of version when it already exists: the next pre-release of a
+// pre-release, and otherwise the next patch.
+func nextVersion(version string) (string, error) {
+	v, err := parseSemver(version)
+	if err != nil {
+		return "", err
+	}
+	if v.Prerelease != "" {
+		return bumpVersion(version, releasePrerelease, "")
+	}
+	return bumpVersion(version, releasePatch, "")
+}
+
+// releaseVersion returns the version to release after latest, as requested by the `--version`, `--release`,
+// `--pre` and `--build` flags.  The new version must be greater than latest.
+func releaseVersion(latest string) (string, error) {
+	var version string
+	var err error
+	if versionFlag != "" {
+		version = strings.TrimPrefix(versionFlag, "v")
+	} else {
+		version, err = bumpVersion(latest, releaseType, preReleaseFlag)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	v, err := parseSemver(version)
+	if err != nil {
+		return "", err
+	}
+	if buildMetadataFlag != "" {
+		v.Build = buildMetadataFlag
+	}
+	if _, err := parsedVersion(v); err != nil {
+		return "", err
+	}
+
+	current, err := parseSemver(latest)
+	if err != nil {
+		return "", err
+	}
+	if v.compare(current) <= 0 {
+		return "", status.Errorf(codes.InvalidArgument, "version %s is not greater than the latest version %s", v, latest)
+	}
+	return v.String(), nil
+}
+
+// initialVersion returns the first version to release, which is version unless the `--version` flag is set, along
+// with the pre-release and build metadata of the `--pre` and `--build` flags.
+func initialVersion(version string) (string, error) {
+	if versionFlag != "" {
+		version = strings.TrimPrefix(versionFlag, "v")
+	}
+	v, err := parseSemver(version)
+	if err != nil {
+		return "", err
+	}
+	if versionFlag == "" && preReleaseFlag != "" {
+		v.Prerelease = preReleaseFlag + ".1"
+	}
+	if buildMetadataFlag != "" {
+		v.Build = buildMetadataFlag
+	}
+	return parsedVersion(v)
+}
+
+// parsedVersion returns v as a string, provided the pre-release and build metadata taken from the flags are valid.
+func parsedVersion(v semver) (string, error) {
+	if _, err := parseSemver(v.String()); err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// addReleaseFlags registers the flags which choose the version of a new release.
+func addReleaseFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&releaseType, "release", "r", releasePatch, pterm.Green("The update type, one of patch, minor, major & prerelease"))
+	cmd.Flags().StringVar(&preReleaseFlag, "pre", "", pterm.Green("Release a pre-release with the given identifier, for example rc for 1.3.0-rc.1"))
+	cmd.Flags().StringVar(&buildMetadataFlag, "build", "", pterm.Green("Build metadata to add to the version, for example 20220501 for 1.3.0+20220501"))
+	cmd.Flags().StringVar(&versionFlag, "version", "", pterm.Green("An explicit version to release instead, which must be greater than the latest version"))
+}
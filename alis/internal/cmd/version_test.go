@@ -0,0 +1,52 @@
+package cmd
+
+import "testing"
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version, releaseType, preRelease string
+		want                             string
+	}{
+		{"1.2.3", "patch", "", "1.2.4"},
+		{"1.2.3", "minor", "", "1.3.0"},
+		{"1.2.3", "major", "", "2.0.0"},
+		{"v1.2.3+build.5", "patch", "", "1.2.4"},
+		{"1.2.3", "minor", "rc", "1.3.0-rc.1"},
+		{"1.2.3", "prerelease", "", "1.2.4-rc.1"},
+		{"1.3.0-rc.1", "prerelease", "", "1.3.0-rc.2"},
+		{"1.3.0-rc.1", "prerelease", "rc", "1.3.0-rc.2"},
+		{"1.3.0-alpha.4", "prerelease", "beta", "1.3.0-beta.1"},
+		{"1.3.0-beta", "prerelease", "", "1.3.0-beta.1"},
+		{"1.3.0-rc.2", "patch", "", "1.3.0"},
+	}
+	for _, tt := range tests {
+		got, err := bumpVersion(tt.version, tt.releaseType, tt.preRelease)
+		if err != nil || got != tt.want {
+			t.Errorf("bumpVersion(%q, %q, %q) = %q, %v, want %q", tt.version, tt.releaseType, tt.preRelease, got, err, tt.want)
+		}
+	}
+
+	for _, version := range []string{"1.2", "1.02.3", "1.2.3-", "1.2.3-rc..1"} {
+		if _, err := bumpVersion(version, "patch", ""); err == nil {
+			t.Errorf("bumpVersion(%q) succeeded, want an invalid version", version)
+		}
+	}
+	if _, err := bumpVersion("1.2.3", "huge", ""); err == nil {
+		t.Errorf("bumpVersion with an unknown release type succeeded")
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	// in increasing order of precedence, as listed by https://semver.org/#spec-item-11
+	versions := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := range versions {
+		for j := range versions {
+			a, _ := parseSemver(versions[i])
+			b, _ := parseSemver(versions[j])
+			if got, want := a.compare(b), sign(i-j); got != want {
+				t.Errorf("compare(%s, %s) = %d, want %d", versions[i], versions[j], got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// argFromWorkingDir lets cmd be run without its product or neuron argument from within the directory of the product
+// or neuron, in which case infer returns the argument for the current working directory.  The argument is still
+// checked by the Args validator of cmd.
+func argFromWorkingDir(cmd *cobra.Command, infer func(dir string) (string, bool)) {
+	validate := cmd.Args
+	run := cmd.RunE
+	inferArgs := func(args []string) ([]string, bool) {
+		if len(args) > 0 {
+			return args, false
+		}
+		dir, err := os.Getwd()
+		if err != nil {
+			return args, false
+		}
+		if arg, ok := infer(dir); ok {
+			return []string{arg}, true
+		}
+		return args, false
+	}
+
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		args, _ = inferArgs(args)
+		return validate(cmd, args)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		args, inferred := inferArgs(args)
+		if inferred {
+			pterm.Info.Printf("Using %s from the current directory\n", args[0])
+		}
+		return run(cmd, args)
+	}
+}
+
+// neuronFromDir returns the {orgID}.{productID}.{neuronID} name of the neuron whose directory holds dir, either in
+// the product repository or in the proto repository of the workspace.
+func neuronFromDir(dir string) (string, bool) {
+	org, product, rest, ok := splitWorkspaceDir(dir)
+	if !ok || len(rest) < 3 {
+		return "", false
+	}
+	name := org + "." + product + "." + strings.Join(rest[:3], "-")
+	if validateArgument(name, `^[a-z][a-z0-9]{2,7}\.[a-z]{2}\.(resources|services)-[a-z]+-v[0-9]+$`) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// productFromDir returns the {orgID}.{productID} name of the product whose directory holds dir, either in the
+// product repository or in the proto repository of the workspace.
+func productFromDir(dir string) (string, bool) {
+	org, product, _, ok := splitWorkspaceDir(dir)
+	if !ok {
+		return "", false
+	}
+	name := org + "." + product
+	if validateArgument(name, `^[a-z][a-z0-9]{2,7}\.[a-z]{2}$`) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// splitWorkspaceDir splits a directory of the workspace into the organisation and product it belongs to, and the
+// path within the product, for the layouts of a product repository, {org}/products/{product}/..., and of the proto
+// repository, {org}/proto/{org}/{product}/...
+func splitWorkspaceDir(dir string) (org string, product string, rest []string, ok bool) {
+	root := currentWorkspace().Root
+	// compare the resolved paths, as the working directory has its symbolic links resolved.
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", nil, false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	switch {
+	case len(parts) >= 3 && parts[1] == "products":
+		return parts[0], parts[2], parts[3:], true
+	case len(parts) >= 4 && parts[1] == "proto" && parts[2] == parts[0]:
+		return parts[0], parts[3], parts[4:], true
+	}
+	return "", "", nil, false
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArgFromDir(t *testing.T) {
+	oldHomeDir := homeDir
+	homeDir = "/home/dev"
+	t.Cleanup(func() { homeDir = oldHomeDir })
+
+	for _, tt := range []struct {
+		dir     string
+		neuron  string
+		product string
+	}{
+		{"/home/dev/alis.exchange/alis/products/in/resources/events/v1", "alis.in.resources-events-v1", "alis.in"},
+		{"/home/dev/alis.exchange/alis/products/in/services/parser/v2/internal", "alis.in.services-parser-v2", "alis.in"},
+		{"/home/dev/alis.exchange/alis/proto/alis/in/resources/events/v1", "alis.in.resources-events-v1", "alis.in"},
+		{"/home/dev/alis.exchange/alis/products/in", "", "alis.in"},
+		{"/home/dev/alis.exchange/alis/products/in/infrastructure/modules", "", "alis.in"},
+		{"/home/dev/alis.exchange/alis/proto/google/api", "", ""},
+		{"/home/dev/alis.exchange/alis/protobuf/go/alis/in/resources/events/v1", "", ""},
+		{"/home/dev/src/alis/products/in/resources/events/v1", "", ""},
+	} {
+		if got, _ := neuronFromDir(tt.dir); got != tt.neuron {
+			t.Errorf("neuronFromDir(%s) = %q, want %q", tt.dir, got, tt.neuron)
+		}
+		if got, _ := productFromDir(tt.dir); got != tt.product {
+			t.Errorf("productFromDir(%s) = %q, want %q", tt.dir, got, tt.product)
+		}
+	}
+}
+
+func TestNeuronArgFromWorkingDir(t *testing.T) {
+	e := newTestEnv(t)
+	e.seedNeuron()
+	dir := currentWorkspace().NeuronDir("alis", "in", "resources-events-v1")
+	if err := os.MkdirAll(filepath.Join(dir, "internal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if err := os.Chdir(filepath.Join(dir, "internal")); err != nil {
+		t.Fatal(err)
+	}
+	stdout, console := e.run("", "neuron", "get", "-o", "json")
+	if !strings.Contains(stdout, `"organisations/alis/products/in/neurons/resources-events-v1"`) {
+		t.Errorf("neuron get:\n%s\n%s", stdout, console)
+	}
+	if !strings.Contains(console, "Using alis.in.resources-events-v1 from the current directory") {
+		t.Errorf("the inferred neuron is not reported:\n%s", console)
+	}
+
+	// outside of the workspace the argument is still required.
+	if err := os.Chdir(e.home); err != nil {
+		t.Fatal(err)
+	}
+	if err := getNeuronCmd.Args(getNeuronCmd, nil); err == nil {
+		t.Error("neuron get is accepted without an argument outside of a neuron directory")
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// workspace is the local directory holding the clones of the repositories the CLI works with:
+//
+//	{root}/google/proto                             the Google protos
+//	{root}/{org}/proto                              the protos of the organisation
+//	{root}/{org}/products/{product}                 the product repositories
+//	{root}/{org}/protobuf/go, protobuf/python       the generated protocol buffers
+//	{root}/{org}/public/protobuf/go                 the generated public protocol buffers
+//	{root}/{org}/api/go                             the generated api libraries
+type workspace struct {
+	Root string
+}
+
+// currentWorkspace returns the workspace set with the --workspace flag, the workspace config key or the
+// ALIS_WORKSPACE environment variable, which defaults to $HOME/alis.exchange.
+func currentWorkspace() workspace {
+	root := viper.GetString("workspace")
+	if root == "" {
+		return workspace{Root: filepath.Join(homeDir, "alis.exchange")}
+	}
+	if expanded, err := homedir.Expand(root); err == nil {
+		root = expanded
+	}
+	return workspace{Root: filepath.Clean(root)}
+}
+
+// neuronPath returns the path of a neuron relative to the root of the proto, protobuf and api repositories, for
+// example alis/in/resources/events/v1 for the alis.in.resources-events-v1 neuron.
+func neuronPath(organisationID string, productID string, neuronID string) string {
+	return organisationID + "/" + productID + "/" + strings.ReplaceAll(neuronID, "-", "/")
+}
+
+// Org returns the directory of an organisation.
+func (w workspace) Org(organisationID string) string {
+	return filepath.Join(w.Root, organisationID)
+}
+
+// GoogleProtos returns the clone of the Google protos, shared by all organisations.
+func (w workspace) GoogleProtos() string {
+	return filepath.Join(w.Root, "google", "proto")
+}
+
+// ProtoRepo returns the clone of the proto repository of an organisation.
+func (w workspace) ProtoRepo(organisationID string) string {
+	return filepath.Join(w.Org(organisationID), "proto")
+}
+
+// ProductProtos returns the directory of the protos of a product.
+func (w workspace) ProductProtos(organisationID string, productID string) string {
+	return filepath.Join(w.ProtoRepo(organisationID), organisationID, productID)
+}
+
+// NeuronProtos returns the directory of the protos of a neuron.
+func (w workspace) NeuronProtos(organisationID string, productID string, neuronID string) string {
+	return filepath.Join(w.ProtoRepo(organisationID), neuronPath(organisationID, productID, neuronID))
+}
+
+// ProductRepo returns the clone of a product repository.
+func (w workspace) ProductRepo(organisationID string, productID string) string {
+	return filepath.Join(w.Org(organisationID), "products", productID)
+}
+
+// NeuronDir returns the directory of a neuron within its product repository.
+func (w workspace) NeuronDir(organisationID string, productID string, neuronID string) string {
+	return filepath.Join(w.ProductRepo(organisationID, productID), strings.ReplaceAll(neuronID, "-", "/"))
+}
+
+// ProtobufGoRepo returns the clone of the repository of the Go protocol buffers of an organisation.
+func (w workspace) ProtobufGoRepo(organisationID string) string {
+	return filepath.Join(w.Org(organisationID), "protobuf", "go")
+}
+
+// PublicProtobufGoRepo returns the clone of the repository of the public Go protocol buffers of an organisation.
+func (w workspace) PublicProtobufGoRepo(organisationID string) string {
+	return filepath.Join(w.Org(organisationID), "public", "protobuf", "go")
+}
+
+// ProtobufPythonRepo returns the clone of the repository of the Python protocol buffers of an organisation.
+func (w workspace) ProtobufPythonRepo(organisationID string) string {
+	return filepath.Join(w.Org(organisationID), "protobuf", "python")
+}
+
+// APIGoRepo returns the clone of the repository of the Go api libraries of an organisation.
+func (w workspace) APIGoRepo(organisationID string) string {
+	return filepath.Join(w.Org(organisationID), "api", "go")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWorkspace(t *testing.T) {
+	oldHomeDir := homeDir
+	homeDir = "/home/dev"
+	t.Cleanup(func() { homeDir = oldHomeDir })
+
+	ws := currentWorkspace()
+	for got, want := range map[string]string{
+		ws.GoogleProtos(): "/home/dev/alis.exchange/google/proto",
+		ws.NeuronProtos("alis", "in", "resources-events-v1"): "/home/dev/alis.exchange/alis/proto/alis/in/resources/events/v1",
+		ws.NeuronDir("alis", "in", "resources-events-v1"):    "/home/dev/alis.exchange/alis/products/in/resources/events/v1",
+		ws.ProductProtos("alis", "in"):                       "/home/dev/alis.exchange/alis/proto/alis/in",
+		ws.PublicProtobufGoRepo("alis"):                      "/home/dev/alis.exchange/alis/public/protobuf/go",
+		neuronPath("alis", "in", "resources-events-v1"):      "alis/in/resources/events/v1",
+	} {
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+
+	viper.Set("workspace", "/work/checkout-2/")
+	t.Cleanup(func() { viper.Set("workspace", "") })
+	if got, want := currentWorkspace().ProductRepo("alis", "in"), "/work/checkout-2/alis/products/in"; got != want {
+		t.Errorf("ProductRepo() = %s, want %s", got, want)
+	}
+}
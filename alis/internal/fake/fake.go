@@ -0,0 +1,636 @@
+// Package fake provides an in-memory implementation of the alis_ OS products, operations and parsers services.
+//
+// It is used to run the CLI commands end-to-end without access to the alis_ OS, for example:
+//
+//	backend := fake.NewBackend()
+//	server := grpc.NewServer()
+//	backend.Register(server)
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	pbOperations "go.protobuf.alis.alis.exchange/alis/os/resources/operations/v1"
+	pbProducts "go.protobuf.alis.alis.exchange/alis/os/resources/products/v1"
+	pbParsers "go.protobuf.alis.alis.exchange/alis/os/services/parsers/v1"
+	"google.golang.org/genproto/googleapis/longrunning"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Backend holds the state shared by the fake services.
+type Backend struct {
+	// OperationPolls is the number of GetOperation calls after which a long-running operation completes.
+	OperationPolls int
+	// StrictBatchGet makes BatchGetNeuronDeployments fail with NotFound when any of the neuron deployments does not
+	// exist, as AIP-231 prescribes, rather than return an empty one in its place.  Which of the two the products
+	// service does is not documented, the CLI handles both.
+	StrictBatchGet bool
+
+	mu             sync.Mutex
+	resources      map[string]proto.Message               // keyed by resource name
+	neuronVersions map[string][]*pbProducts.NeuronVersion // keyed by the parent Neuron, in order of creation
+	operations     map[string]*operation
+	operationOrder []string
+}
+
+// operation is a long-running operation which completes after a number of polls.
+type operation struct {
+	op       *longrunning.Operation
+	polls    int
+	complete func() (proto.Message, error)
+}
+
+// NewBackend returns an empty Backend whose operations complete on the first poll.
+func NewBackend() *Backend {
+	return &Backend{
+		OperationPolls: 1,
+		resources:      map[string]proto.Message{},
+		neuronVersions: map[string][]*pbProducts.NeuronVersion{},
+		operations:     map[string]*operation{},
+	}
+}
+
+// Register registers the products, operations and parsers services on s.
+func (b *Backend) Register(s *grpc.Server) {
+	pbProducts.RegisterServiceServer(s, &productsServer{b: b})
+	pbOperations.RegisterServiceServer(s, &operationsServer{b: b})
+	pbParsers.RegisterParserServiceServer(s, &parsersServer{b: b})
+}
+
+// AddOrganisation stores a copy of the Organisation.
+func (b *Backend) AddOrganisation(o *pbProducts.Organisation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resources[o.GetName()] = proto.Clone(o)
+}
+
+// AddProduct stores a copy of the Product.
+func (b *Backend) AddProduct(p *pbProducts.Product) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resources[p.GetName()] = proto.Clone(p)
+}
+
+// AddNeuron stores a copy of the Neuron.
+func (b *Backend) AddNeuron(n *pbProducts.Neuron) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resources[n.GetName()] = proto.Clone(n)
+}
+
+// AddNeuronVersion stores a copy of the NeuronVersion as the latest version of its Neuron.
+func (b *Backend) AddNeuronVersion(v *pbProducts.NeuronVersion) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	parent := parentOf(v.GetName(), 2)
+	b.neuronVersions[parent] = append(b.neuronVersions[parent], proto.Clone(v).(*pbProducts.NeuronVersion))
+}
+
+// AddProductDeployment stores a copy of the ProductDeployment.
+func (b *Backend) AddProductDeployment(d *pbProducts.ProductDeployment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resources[d.GetName()] = proto.Clone(d)
+}
+
+// AddNeuronDeployment stores a copy of the NeuronDeployment.
+func (b *Backend) AddNeuronDeployment(d *pbProducts.NeuronDeployment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resources[d.GetName()] = proto.Clone(d)
+}
+
+// Product returns a copy of the named Product, or nil if it does not exist.
+func (b *Backend) Product(name string) *pbProducts.Product {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r, err := b.get(name, &pbProducts.Product{}); err == nil {
+		return r.(*pbProducts.Product)
+	}
+	return nil
+}
+
+// Neuron returns a copy of the named Neuron, or nil if it does not exist.
+func (b *Backend) Neuron(name string) *pbProducts.Neuron {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r, err := b.get(name, &pbProducts.Neuron{}); err == nil {
+		return r.(*pbProducts.Neuron)
+	}
+	return nil
+}
+
+// NeuronVersions returns copies of the versions of a Neuron, latest first.
+func (b *Backend) NeuronVersions(parent string) []*pbProducts.NeuronVersion {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.listNeuronVersions(parent)
+}
+
+// ProductDeployment returns a copy of the named ProductDeployment, or nil if it does not exist.
+func (b *Backend) ProductDeployment(name string) *pbProducts.ProductDeployment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r, err := b.get(name, &pbProducts.ProductDeployment{}); err == nil {
+		return r.(*pbProducts.ProductDeployment)
+	}
+	return nil
+}
+
+// NeuronDeployment returns a copy of the named NeuronDeployment, or nil if it does not exist.
+func (b *Backend) NeuronDeployment(name string) *pbProducts.NeuronDeployment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r, err := b.get(name, &pbProducts.NeuronDeployment{}); err == nil {
+		return r.(*pbProducts.NeuronDeployment)
+	}
+	return nil
+}
+
+// Operations returns copies of all the long-running operations, in order of creation.
+func (b *Backend) Operations() []*longrunning.Operation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var res []*longrunning.Operation
+	for _, name := range b.operationOrder {
+		res = append(res, proto.Clone(b.operations[name].op).(*longrunning.Operation))
+	}
+	return res
+}
+
+// startOperation registers a new long-running operation which runs complete once it has been polled
+// OperationPolls times.  The caller must hold b.mu.
+func (b *Backend) startOperation(complete func() (proto.Message, error)) *longrunning.Operation {
+	name := fmt.Sprintf("operations/%d", len(b.operationOrder)+1)
+	op := &operation{
+		op:       &longrunning.Operation{Name: name},
+		complete: complete,
+	}
+	b.operations[name] = op
+	b.operationOrder = append(b.operationOrder, name)
+	return proto.Clone(op.op).(*longrunning.Operation)
+}
+
+// pollOperation records a poll of the named operation and completes it once it is due.
+func (b *Backend) pollOperation(name string) (*longrunning.Operation, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	op, ok := b.operations[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "operation (%s) not found", name)
+	}
+	op.polls++
+	if !op.op.GetDone() && op.polls >= b.OperationPolls {
+		b.finishOperation(op)
+	}
+	return proto.Clone(op.op).(*longrunning.Operation), nil
+}
+
+// finishOperation runs the completion of op.  The caller must hold b.mu.
+func (b *Backend) finishOperation(op *operation) {
+	op.op.Done = true
+	res, err := op.complete()
+	if err != nil {
+		s, _ := status.FromError(err)
+		op.op.Result = &longrunning.Operation_Error{Error: &statuspb.Status{Code: int32(s.Code()), Message: s.Message()}}
+		return
+	}
+	response, err := anypb.New(res)
+	if err != nil {
+		op.op.Result = &longrunning.Operation_Error{Error: &statuspb.Status{Code: int32(codes.Internal), Message: err.Error()}}
+		return
+	}
+	op.op.Result = &longrunning.Operation_Response{Response: response}
+}
+
+// listNeuronVersions returns copies of the versions of a Neuron, latest first.  The caller must hold b.mu.
+func (b *Backend) listNeuronVersions(parent string) []*pbProducts.NeuronVersion {
+	versions := b.neuronVersions[parent]
+	res := make([]*pbProducts.NeuronVersion, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		res = append(res, proto.Clone(versions[i]).(*pbProducts.NeuronVersion))
+	}
+	return res
+}
+
+// productsServer implements the products service.
+type productsServer struct {
+	pbProducts.UnimplementedServiceServer
+	b *Backend
+}
+
+func (s *productsServer) GetOrganisation(ctx context.Context, req *pbProducts.GetOrganisationRequest) (*pbProducts.Organisation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	r, err := s.b.get(req.GetName(), &pbProducts.Organisation{})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*pbProducts.Organisation), nil
+}
+
+func (s *productsServer) ListOrganisations(ctx context.Context, req *pbProducts.ListOrganisationsRequest) (*pbProducts.ListOrganisationsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	res := &pbProducts.ListOrganisationsResponse{}
+	for _, r := range s.b.list("organisations/", &pbProducts.Organisation{}) {
+		res.Organisations = append(res.Organisations, r.(*pbProducts.Organisation))
+	}
+	return res, nil
+}
+
+func (s *productsServer) CreateOrganisation(ctx context.Context, req *pbProducts.CreateOrganisationRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	organisation := proto.Clone(req.GetOrganisation()).(*pbProducts.Organisation)
+	organisation.Name = "organisations/" + req.GetOrganisationId()
+	if _, ok := s.b.resources[organisation.GetName()]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "organisation (%s) already exists", organisation.GetName())
+	}
+	return s.b.startOperation(func() (proto.Message, error) {
+		organisation.UpdateTime = timestamppb.Now()
+		s.b.resources[organisation.GetName()] = organisation
+		return organisation, nil
+	}), nil
+}
+
+func (s *productsServer) GetProduct(ctx context.Context, req *pbProducts.GetProductRequest) (*pbProducts.Product, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	r, err := s.b.get(req.GetName(), &pbProducts.Product{})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*pbProducts.Product), nil
+}
+
+func (s *productsServer) ListProducts(ctx context.Context, req *pbProducts.ListProductsRequest) (*pbProducts.ListProductsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	res := &pbProducts.ListProductsResponse{}
+	for _, r := range s.b.list(req.GetParent()+"/products/", &pbProducts.Product{}) {
+		res.Products = append(res.Products, r.(*pbProducts.Product))
+	}
+	return res, nil
+}
+
+func (s *productsServer) CreateProduct(ctx context.Context, req *pbProducts.CreateProductRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, err := s.b.get(req.GetParent(), &pbProducts.Organisation{}); err != nil {
+		return nil, err
+	}
+	product := proto.Clone(req.GetProduct()).(*pbProducts.Product)
+	product.Name = req.GetParent() + "/products/" + req.GetProductId()
+	if _, ok := s.b.resources[product.GetName()]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "product (%s) already exists", product.GetName())
+	}
+	return s.b.startOperation(func() (proto.Message, error) {
+		product.UpdateTime = timestamppb.Now()
+		s.b.resources[product.GetName()] = product
+		return product, nil
+	}), nil
+}
+
+func (s *productsServer) UpdateProduct(ctx context.Context, req *pbProducts.UpdateProductRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.b.update(req.GetProduct(), req.GetUpdateMask())
+}
+
+func (s *productsServer) GetNeuron(ctx context.Context, req *pbProducts.GetNeuronRequest) (*pbProducts.Neuron, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	r, err := s.b.get(req.GetName(), &pbProducts.Neuron{})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*pbProducts.Neuron), nil
+}
+
+func (s *productsServer) ListNeurons(ctx context.Context, req *pbProducts.ListNeuronsRequest) (*pbProducts.ListNeuronsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	res := &pbProducts.ListNeuronsResponse{}
+	for _, r := range s.b.list(req.GetParent()+"/neurons/", &pbProducts.Neuron{}) {
+		res.Neurons = append(res.Neurons, r.(*pbProducts.Neuron))
+	}
+	return res, nil
+}
+
+func (s *productsServer) CreateNeuron(ctx context.Context, req *pbProducts.CreateNeuronRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, err := s.b.get(req.GetParent(), &pbProducts.Product{}); err != nil {
+		return nil, err
+	}
+	neuron := proto.Clone(req.GetNeuron()).(*pbProducts.Neuron)
+	neuron.Name = req.GetParent() + "/neurons/" + req.GetNeuronId()
+	if _, ok := s.b.resources[neuron.GetName()]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "neuron (%s) already exists", neuron.GetName())
+	}
+	return s.b.startOperation(func() (proto.Message, error) {
+		neuron.UpdateTime = timestamppb.Now()
+		s.b.resources[neuron.GetName()] = neuron
+		return neuron, nil
+	}), nil
+}
+
+func (s *productsServer) UpdateNeuron(ctx context.Context, req *pbProducts.UpdateNeuronRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.b.update(req.GetNeuron(), req.GetUpdateMask())
+}
+
+// ListNeuronVersions returns the versions latest first, with only the fields of the read mask if one is set.  The
+// page token is the index of the first version of the page.
+func (s *productsServer) ListNeuronVersions(ctx context.Context, req *pbProducts.ListNeuronVersionsRequest) (*pbProducts.ListNeuronVersionsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	versions := s.b.listNeuronVersions(req.GetParent())
+	start := 0
+	if req.GetPageToken() != "" {
+		var err error
+		if start, err = strconv.Atoi(req.GetPageToken()); err != nil || start < 0 || start > len(versions) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page_token: %s", req.GetPageToken())
+		}
+	}
+	res := &pbProducts.ListNeuronVersionsResponse{}
+	end := len(versions)
+	if size := int(req.GetPageSize()); size > 0 && start+size < end {
+		end = start + size
+		res.NextPageToken = strconv.Itoa(end)
+	}
+	for _, version := range versions[start:end] {
+		if len(req.GetReadMask().GetPaths()) > 0 {
+			masked := &pbProducts.NeuronVersion{}
+			if err := applyMask(masked, version, req.GetReadMask()); err != nil {
+				return nil, err
+			}
+			version = masked
+		}
+		res.NeuronVersions = append(res.NeuronVersions, version)
+	}
+	return res, nil
+}
+
+func (s *productsServer) CreateNeuronVersion(ctx context.Context, req *pbProducts.CreateNeuronVersionRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, err := s.b.get(req.GetParent(), &pbProducts.Neuron{}); err != nil {
+		return nil, err
+	}
+	version := proto.Clone(req.GetNeuronVersion()).(*pbProducts.NeuronVersion)
+	version.Name = req.GetParent() + "/versions/" + req.GetNeuronVersionId()
+	version.Version = req.GetNeuronVersionId()
+	for _, v := range s.b.neuronVersions[req.GetParent()] {
+		if v.GetName() == version.GetName() {
+			return nil, status.Errorf(codes.AlreadyExists, "neuron version (%s) already exists", version.GetName())
+		}
+	}
+	// the operation completes once the version is built.
+	return s.b.startOperation(func() (proto.Message, error) {
+		version.State = pbProducts.NeuronVersion_BUILT
+		version.CreateTime = timestamppb.Now()
+		version.UpdateTime = version.GetCreateTime()
+		s.b.neuronVersions[req.GetParent()] = append(s.b.neuronVersions[req.GetParent()], version)
+		return version, nil
+	}), nil
+}
+
+func (s *productsServer) GetProductDeployment(ctx context.Context, req *pbProducts.GetProductDeploymentRequest) (*pbProducts.ProductDeployment, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	r, err := s.b.get(req.GetName(), &pbProducts.ProductDeployment{})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*pbProducts.ProductDeployment), nil
+}
+
+func (s *productsServer) ListProductDeployments(ctx context.Context, req *pbProducts.ListProductDeploymentsRequest) (*pbProducts.ListProductDeploymentsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	res := &pbProducts.ListProductDeploymentsResponse{}
+	for _, r := range s.b.list(req.GetParent()+"/deployments/", &pbProducts.ProductDeployment{}) {
+		res.ProductDeployments = append(res.ProductDeployments, r.(*pbProducts.ProductDeployment))
+	}
+	return res, nil
+}
+
+func (s *productsServer) CreateProductDeployment(ctx context.Context, req *pbProducts.CreateProductDeploymentRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, err := s.b.get(req.GetParent(), &pbProducts.Product{}); err != nil {
+		return nil, err
+	}
+	deployment := proto.Clone(req.GetProductDeployment()).(*pbProducts.ProductDeployment)
+	id := fmt.Sprintf("%s-%d", strings.Split(req.GetParent(), "/")[3], len(s.b.resources)+1)
+	deployment.Name = req.GetParent() + "/deployments/" + id
+	deployment.GoogleProjectId = id
+	return s.b.startOperation(func() (proto.Message, error) {
+		deployment.UpdateTime = timestamppb.Now()
+		s.b.resources[deployment.GetName()] = deployment
+		return deployment, nil
+	}), nil
+}
+
+func (s *productsServer) UpdateProductDeployment(ctx context.Context, req *pbProducts.UpdateProductDeploymentRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.b.update(req.GetProductDeployment(), req.GetUpdateMask())
+}
+
+func (s *productsServer) GetNeuronDeployment(ctx context.Context, req *pbProducts.GetNeuronDeploymentRequest) (*pbProducts.NeuronDeployment, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	r, err := s.b.get(req.GetName(), &pbProducts.NeuronDeployment{})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*pbProducts.NeuronDeployment), nil
+}
+
+func (s *productsServer) ListNeuronDeployments(ctx context.Context, req *pbProducts.ListNeuronDeploymentsRequest) (*pbProducts.ListNeuronDeploymentsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	res := &pbProducts.ListNeuronDeploymentsResponse{}
+	for _, r := range s.b.list(req.GetParent()+"/neurons/", &pbProducts.NeuronDeployment{}) {
+		res.NeuronDeployments = append(res.NeuronDeployments, r.(*pbProducts.NeuronDeployment))
+	}
+	return res, nil
+}
+
+// BatchGetNeuronDeployments returns an empty NeuronDeployment in place of each one which does not exist,
+// such that the response lines up with the requested names, unless StrictBatchGet is set.  The placeholders are
+// assumed to mirror the products service, see StrictBatchGet.
+func (s *productsServer) BatchGetNeuronDeployments(ctx context.Context, req *pbProducts.BatchGetNeuronDeploymentsRequest) (*pbProducts.BatchGetNeuronDeploymentsResponse, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	res := &pbProducts.BatchGetNeuronDeploymentsResponse{}
+	for _, name := range req.GetNames() {
+		deployment := &pbProducts.NeuronDeployment{}
+		r, err := s.b.get(name, deployment)
+		if err == nil {
+			deployment = r.(*pbProducts.NeuronDeployment)
+		} else if s.b.StrictBatchGet {
+			return nil, err
+		}
+		res.NeuronDeployments = append(res.NeuronDeployments, deployment)
+	}
+	return res, nil
+}
+
+func (s *productsServer) CreateNeuronDeployment(ctx context.Context, req *pbProducts.CreateNeuronDeploymentRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, err := s.b.get(req.GetParent(), &pbProducts.ProductDeployment{}); err != nil {
+		return nil, err
+	}
+	deployment := proto.Clone(req.GetNeuronDeployment()).(*pbProducts.NeuronDeployment)
+	deployment.Name = req.GetParent() + "/neurons/" + req.GetNeuronDeploymentId()
+	if _, ok := s.b.resources[deployment.GetName()]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "neuron deployment (%s) already exists", deployment.GetName())
+	}
+	return s.b.startOperation(func() (proto.Message, error) {
+		deployment.UpdateTime = timestamppb.Now()
+		s.b.resources[deployment.GetName()] = deployment
+		return deployment, nil
+	}), nil
+}
+
+func (s *productsServer) UpdateNeuronDeployment(ctx context.Context, req *pbProducts.UpdateNeuronDeploymentRequest) (*longrunning.Operation, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.b.update(req.GetNeuronDeployment(), req.GetUpdateMask())
+}
+
+// operationsServer implements the operations service.
+type operationsServer struct {
+	pbOperations.UnimplementedServiceServer
+	b *Backend
+}
+
+func (s *operationsServer) GetOperation(ctx context.Context, req *pbOperations.GetOperationRequest) (*longrunning.Operation, error) {
+	return s.b.pollOperation(req.GetName())
+}
+
+func (s *operationsServer) ListOperations(ctx context.Context, req *pbOperations.ListOperationsRequest) (*longrunning.ListOperationsResponse, error) {
+	return &longrunning.ListOperationsResponse{Operations: s.b.Operations()}, nil
+}
+
+func (s *operationsServer) CancelOperation(ctx context.Context, req *pbOperations.CancelOperationRequest) (*emptypb.Empty, error) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	op, ok := s.b.operations[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "operation (%s) not found", req.GetName())
+	}
+	if !op.op.GetDone() {
+		op.complete = func() (proto.Message, error) {
+			return nil, status.Error(codes.Canceled, "operation cancelled")
+		}
+		s.b.finishOperation(op)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// parsersServer implements the parsers service.
+type parsersServer struct {
+	pbParsers.UnimplementedParserServiceServer
+	b *Backend
+}
+
+// GenerateRestrictionScopedFileDescriptorSet returns the FileDescriptorSet unchanged.
+func (s *parsersServer) GenerateRestrictionScopedFileDescriptorSet(ctx context.Context, req *pbParsers.GenerateRestrictionScopedFileDescriptorSetRequest) (*descriptorpb.FileDescriptorSet, error) {
+	return req.GetFileDescriptorSet(), nil
+}
+
+// get returns a copy of the named resource, provided it is of the same type as kind.  The caller must hold b.mu.
+func (b *Backend) get(name string, kind proto.Message) (proto.Message, error) {
+	r, ok := b.resources[name]
+	if !ok || proto.MessageName(r) != proto.MessageName(kind) {
+		return nil, status.Errorf(codes.NotFound, "%s not found", name)
+	}
+	return proto.Clone(r), nil
+}
+
+// list returns copies of the resources of the same type as kind whose names are directly beneath prefix,
+// sorted by name.  The caller must hold b.mu.
+func (b *Backend) list(prefix string, kind proto.Message) []proto.Message {
+	var names []string
+	for name, r := range b.resources {
+		if strings.HasPrefix(name, prefix) && !strings.Contains(strings.TrimPrefix(name, prefix), "/") &&
+			proto.MessageName(r) == proto.MessageName(kind) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	res := make([]proto.Message, 0, len(names))
+	for _, name := range names {
+		res = append(res, proto.Clone(b.resources[name]))
+	}
+	return res
+}
+
+// update starts an operation which applies the fields of r named in mask to the stored resource of the same
+// name.  The caller must hold b.mu.
+func (b *Backend) update(r proto.Message, mask *fieldmaskpb.FieldMask) (*longrunning.Operation, error) {
+	name := r.ProtoReflect().Get(r.ProtoReflect().Descriptor().Fields().ByName("name")).String()
+	stored, err := b.get(name, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyMask(stored, r, mask); err != nil {
+		return nil, err
+	}
+	return b.startOperation(func() (proto.Message, error) {
+		if fd := stored.ProtoReflect().Descriptor().Fields().ByName("update_time"); fd != nil {
+			stored.ProtoReflect().Set(fd, protoreflect.ValueOfMessage(timestamppb.Now().ProtoReflect()))
+		}
+		b.resources[name] = stored
+		return stored, nil
+	}), nil
+}
+
+// applyMask copies the top-level fields named in mask from src to dst.
+func applyMask(dst, src proto.Message, mask *fieldmaskpb.FieldMask) error {
+	dstMsg, srcMsg := dst.ProtoReflect(), src.ProtoReflect()
+	fields := dstMsg.Descriptor().Fields()
+	for _, path := range mask.GetPaths() {
+		fd := fields.ByName(protoreflect.Name(path))
+		if fd == nil {
+			return status.Errorf(codes.InvalidArgument, "invalid field mask path: %s", path)
+		}
+		if srcMsg.Has(fd) {
+			dstMsg.Set(fd, srcMsg.Get(fd))
+		} else {
+			dstMsg.Clear(fd)
+		}
+	}
+	return nil
+}
+
+// parentOf strips the last n segments from a resource name.
+func parentOf(name string, n int) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < n {
+		return ""
+	}
+	return strings.Join(parts[:len(parts)-n], "/")
+}